@@ -19,22 +19,263 @@
 
 package cloudstack
 
-import "github.com/xanzy/go-cloudstack/v2/cloudstack"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
 
 // Config is the configuration structure used to instantiate a
 // new CloudStack client.
 type Config struct {
-	APIURL      string
-	APIKey      string
-	SecretKey   string
+	APIURL    string
+	APIKey    string
+	SecretKey string
+
+	// Username, Password and Domain are used to login instead of using an
+	// API key and secret, if no API key is configured.
+	Username string
+	Password string
+	Domain   string
+
 	HTTPGETOnly bool
 	Timeout     int64
+
+	// MaxRetries is the number of times a failed API call is retried,
+	// waiting between RetryMinWait and RetryMaxWait seconds between retries.
+	MaxRetries   int
+	RetryMinWait int64
+	RetryMaxWait int64
+
+	// VerifySSL enables verifying the certificate of the API, using the
+	// system CA certificates and the ones in CAFile (if any).
+	VerifySSL bool
+	CAFile    string
+
+	// ClientCertFile and ClientKeyFile are the (PEM encoded) client
+	// certificate and key used to authenticate to the API.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// ProxyURL is the URL of the proxy used for all API calls. If not set,
+	// the proxy is configured using the environment.
+	ProxyURL string
+
+	// Headers are added to every API call.
+	Headers map[string]string
+
+	// DefaultZone and DefaultProject are used by resources that do not
+	// configure a zone or project themselves, and DefaultTags are added
+	// to the tags of every resource that supports tags.
+	DefaultZone    string
+	DefaultProject string
+	DefaultTags    map[string]string
+
+	// PrefetchLookups enables retrieving the IDs of all objects of a kind
+	// (like zones or templates) at once, instead of one name at a time.
+	PrefetchLookups bool
+}
+
+// Client is the CloudStack client used by the resources, together with the
+// provider level defaults.
+type Client struct {
+	*cloudstack.CloudStackClient
+
+	defaultZone    string
+	defaultProject string
+	defaultTags    map[string]string
+
+	// lookups caches the IDs of zones, offerings, projects and templates
+	lookups *lookupCache
+
+	// timeout is the provider timeout for async jobs
+	timeout time.Duration
+
+	// newClient returns a new CloudStack client sharing the configuration
+	// and HTTP client of this client, with the given async timeout
+	newClient func(timeout int64) *cloudstack.CloudStackClient
 }
 
 // NewClient returns a new CloudStack client.
-func (c *Config) NewClient() (*cloudstack.CloudStackClient, error) {
-	cs := cloudstack.NewAsyncClient(c.APIURL, c.APIKey, c.SecretKey, false)
-	cs.HTTPGETOnly = c.HTTPGETOnly
-	cs.AsyncTimeout(c.Timeout)
-	return cs, nil
+func (c *Config) NewClient() (*Client, error) {
+	client, err := c.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := func(timeout int64) *cloudstack.CloudStackClient {
+		cs := cloudstack.NewAsyncClient(c.APIURL, c.APIKey, c.SecretKey, c.VerifySSL,
+			cloudstack.WithHTTPClient(client))
+		cs.HTTPGETOnly = c.HTTPGETOnly
+		cs.AsyncTimeout(timeout)
+		return cs
+	}
+
+	return &Client{
+		CloudStackClient: newClient(c.Timeout),
+		defaultZone:      c.DefaultZone,
+		defaultProject:   c.DefaultProject,
+		defaultTags:      c.DefaultTags,
+		lookups:          newLookupCache(c.PrefetchLookups),
+		timeout:          time.Duration(c.Timeout) * time.Second,
+		newClient:        newClient,
+	}, nil
+}
+
+// newHTTPClient returns the HTTP client used by the CloudStack client, which
+// retries failed API calls according to the configured retry policy.
+func (c *Config) newHTTPClient() (*http.Client, error) {
+	tlsConfig, err := c.newTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL %q", c.ProxyURL)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	headers := make(http.Header)
+	for k, v := range c.Headers {
+		headers.Set(k, v)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// Send the headers to the proxy when tunneling HTTPS calls as
+		// well, so they can be used to authenticate to the proxy
+		ProxyConnectHeader: headers,
+	}
+
+	policy := retryPolicy{
+		maxRetries: c.MaxRetries,
+		minWait:    time.Duration(c.RetryMinWait) * time.Second,
+		maxWait:    time.Duration(c.RetryMaxWait) * time.Second,
+	}
+
+	// Every attempt times out after 60 seconds, the same as the default
+	// HTTP client of the CloudStack client does for every API call
+	var rt http.RoundTripper = transport
+	if len(headers) > 0 {
+		rt = &headerTransport{headers: headers, transport: rt}
+	}
+	if c.APIKey == "" && c.Username != "" {
+		rt = newSessionTransport(c.APIURL, c.Username, c.Password, c.Domain, rt)
+	}
+
+	return &http.Client{
+		Transport: newRetryTransport(policy, c.SecretKey, 60*time.Second, rt),
+	}, nil
+}
+
+// newTLSConfig returns the TLS configuration used to connect to the API.
+func (c *Config) newTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: !c.VerifySSL}
+
+	if c.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA file: %s", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No valid certificates found in CA file %s", c.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return nil, errors.New("'client_cert_file' and 'client_key_file' should both have a value")
+	}
+
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// headerTransport is a http.RoundTripper that adds the given headers to
+// every request.
+type headerTransport struct {
+	headers   http.Header
+	transport http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper should not modify the request, so use a copy
+	r := req.WithContext(req.Context())
+	r.Header = req.Header.Clone()
+
+	for k, v := range t.headers {
+		if strings.EqualFold(k, "Host") {
+			r.Host = v[0]
+			continue
+		}
+		r.Header[k] = v
+	}
+
+	return t.transport.RoundTrip(r)
+}
+
+// providerTimeout is the default of all configurable resource timeouts. It
+// makes resources without a timeouts block use the provider timeout.
+const providerTimeout time.Duration = 0
+
+// resourceTimeout returns the given timeout, or the provider timeout when the
+// given timeout is not configured.
+func resourceTimeout(cs *Client, timeout time.Duration) time.Duration {
+	if timeout == providerTimeout {
+		return cs.timeout
+	}
+	return timeout
+}
+
+// clientWithTimeout returns a copy of the given client that waits the given
+// amount of time for async jobs to finish. This allows resources to use their
+// own (create, update or delete) timeout instead of the provider timeout. All
+// services of the copy use the new timeout, and the copy shares the HTTP client
+// (and so the retries and session) of the given client. When the timeout is not
+// configured, the given client itself is returned.
+func clientWithTimeout(cs *Client, timeout time.Duration) *Client {
+	if timeout == providerTimeout {
+		return cs
+	}
+
+	client := *cs
+	client.CloudStackClient = cs.newClient(int64(timeout.Seconds()))
+
+	return &client
 }
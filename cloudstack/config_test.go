@@ -0,0 +1,253 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_verifySSL(t *testing.T) {
+	ts := httptest.NewTLSServer(testConfigHandler(nil))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cloudstack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	testWritePEM(t, caFile, "CERTIFICATE", ts.Certificate().Raw)
+
+	cases := []struct {
+		VerifySSL bool
+		CAFile    string
+		Err       string
+	}{
+		{VerifySSL: true, Err: "certificate"},
+		{VerifySSL: true, CAFile: caFile},
+		{VerifySSL: false},
+		{VerifySSL: true, CAFile: filepath.Join(dir, "missing.pem"), Err: "Error reading CA file"},
+	}
+
+	for _, c := range cases {
+		cfg := Config{
+			APIURL:    ts.URL,
+			VerifySSL: c.VerifySSL,
+			CAFile:    c.CAFile,
+		}
+
+		err := testConfigListZones(cfg)
+		if (err == nil) != (c.Err == "") || (err != nil && !strings.Contains(err.Error(), c.Err)) {
+			t.Fatalf("Expected error containing %q (verify_ssl = %t, ca_file = %q), got: %v",
+				c.Err, c.VerifySSL, c.CAFile, err)
+		}
+	}
+}
+
+func TestConfig_clientCert(t *testing.T) {
+	var subject string
+	ts := httptest.NewUnstartedServer(testConfigHandler(func(r *http.Request) {
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			subject = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cloudstack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	testWritePEM(t, certFile, "CERTIFICATE", cert)
+	testWritePEM(t, keyFile, "EC PRIVATE KEY", der)
+
+	cfg := Config{APIURL: ts.URL, ClientCertFile: certFile}
+	if err := testConfigListZones(cfg); err == nil || !strings.Contains(err.Error(), "should both have a value") {
+		t.Fatalf("Expected an error about the missing client key, got: %v", err)
+	}
+
+	cfg.ClientKeyFile = keyFile
+	if err := testConfigListZones(cfg); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if subject != "terraform" {
+		t.Fatalf("Expected the client certificate to be used, got subject %q", subject)
+	}
+}
+
+func TestConfig_proxyAndHeaders(t *testing.T) {
+	var host, auth string
+	proxy := httptest.NewServer(testConfigHandler(func(r *http.Request) {
+		host = r.URL.Host
+		auth = r.Header.Get("Proxy-Authorization")
+	}))
+	defer proxy.Close()
+
+	cfg := Config{
+		APIURL:   "http://cloudstack.invalid/client/api",
+		ProxyURL: proxy.URL,
+		Headers:  map[string]string{"Proxy-Authorization": "Bearer secret"},
+	}
+
+	if err := testConfigListZones(cfg); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if host != "cloudstack.invalid" {
+		t.Fatalf("Expected the call to be sent through the proxy, got host %q", host)
+	}
+	if auth != "Bearer secret" {
+		t.Fatalf("Expected the extra header to be sent, got %q", auth)
+	}
+
+	cfg.ProxyURL = "proxy.invalid"
+	if err := testConfigListZones(cfg); err == nil || !strings.Contains(err.Error(), "Invalid proxy URL") {
+		t.Fatalf("Expected an invalid proxy URL error, got: %v", err)
+	}
+}
+
+func testConfigHandler(f func(*http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f != nil {
+			f(r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"listzonesresponse":{}}`))
+	})
+}
+
+func testConfigListZones(cfg Config) error {
+	cs, err := cfg.NewClient()
+	if err != nil {
+		return err
+	}
+	_, err = cs.Zone.ListZones(cs.Zone.NewListZonesParams())
+	return err
+}
+
+func testWritePEM(t *testing.T, path, typ string, der []byte) {
+	b := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientWithTimeout(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	cfg := Config{
+		APIURL:    sim.URL + "/client/api",
+		APIKey:    testSimAPIKey,
+		SecretKey: testSimSecretKey,
+		Timeout:   300,
+	}
+
+	cs, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The timeout applies to all services of the copy, including services
+	// that are not used by any resource with configurable timeouts
+	rule := sim.add("firewallrule", simObject{"protocol": "tcp"})
+	sim.stall("deleteFirewallRule")
+
+	c := clientWithTimeout(cs, time.Millisecond)
+	_, err = c.Firewall.DeleteFirewallRule(c.Firewall.NewDeleteFirewallRuleParams(rule["id"].(string)))
+	if err == nil || !strings.Contains(err.Error(), "Timeout while waiting for async job to finish") {
+		t.Fatalf("Expected the async job to time out, got: %v", err)
+	}
+
+	// The original client keeps using its own timeout
+	rule = sim.add("firewallrule", simObject{"protocol": "tcp"})
+	if _, err := cs.Firewall.DeleteFirewallRule(cs.Firewall.NewDeleteFirewallRuleParams(rule["id"].(string))); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Resources without a timeouts block use the provider timeout
+	if c := clientWithTimeout(cs, providerTimeout); c != cs {
+		t.Fatalf("Expected the provider client without a configured timeout")
+	}
+}
+
+func TestResourceTimeout(t *testing.T) {
+	cfg := Config{
+		APIURL:    "https://cloudstack.example.com/client/api",
+		APIKey:    testSimAPIKey,
+		SecretKey: testSimSecretKey,
+		Timeout:   3600,
+	}
+
+	cs, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if timeout := resourceTimeout(cs, providerTimeout); timeout != time.Hour {
+		t.Fatalf("Expected the provider timeout of 1h0m0s, got: %s", timeout)
+	}
+	if timeout := resourceTimeout(cs, 5*time.Minute); timeout != 5*time.Minute {
+		t.Fatalf("Expected the configured timeout of 5m0s, got: %s", timeout)
+	}
+}
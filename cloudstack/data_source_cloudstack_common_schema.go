@@ -20,7 +20,15 @@
 package cloudstack
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 )
 
 func dataSourceFiltersSchema() *schema.Schema {
@@ -42,3 +50,164 @@ func dataSourceFiltersSchema() *schema.Schema {
 		},
 	}
 }
+
+// dataSourceOptionalFiltersSchema returns the filter schema used by the plural
+// data sources, which return all objects when no filters are given.
+func dataSourceOptionalFiltersSchema() *schema.Schema {
+	s := dataSourceFiltersSchema()
+	s.Required = false
+	s.Optional = true
+	return s
+}
+
+// dataSourceSortSchema returns the schema fields used by the plural data
+// sources to sort their results. The sort field is validated against the
+// fields of obj, which should be the type of the listed CloudStack objects.
+func dataSourceSortSchema(obj interface{}, defaultField string) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"sort_by": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      defaultField,
+			ValidateFunc: validateObjectField(obj),
+		},
+
+		"sort_order": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "asc",
+			ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+		},
+
+		"allow_empty": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+	}
+}
+
+// applyFilters returns true if the given CloudStack object matches all filters.
+// The filter names are the field names used by the CloudStack API, and the
+// values are regular expressions matched against the string representation
+// of those fields.
+func applyFilters(obj interface{}, filters *schema.Set) (bool, error) {
+	fields := objectFields(obj)
+
+	for _, f := range filters.List() {
+		m := f.(map[string]interface{})
+
+		r, err := regexp.Compile(m["value"].(string))
+		if err != nil {
+			return false, fmt.Errorf("Invalid regex: %s", err)
+		}
+
+		field, ok := fields[m["name"].(string)]
+		if !ok {
+			return false, fmt.Errorf("Invalid filter name: %s", m["name"].(string))
+		}
+
+		if !r.MatchString(fieldString(field)) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// validateObjectField returns a validation function that checks if a value is
+// the name of one of the fields of obj as used by the CloudStack API.
+func validateObjectField(obj interface{}) schema.SchemaValidateFunc {
+	fields := objectFields(obj)
+
+	return func(v interface{}, k string) (ws []string, errs []error) {
+		if _, ok := fields[v.(string)]; !ok {
+			errs = append(errs, fmt.Errorf("%q is not a valid field name: %s", v.(string), k))
+		}
+		return
+	}
+}
+
+// sortObjects sorts a slice of CloudStack objects on the given API field.
+func sortObjects(objs interface{}, field string, order string) error {
+	v := reflect.ValueOf(objs)
+
+	s := &fieldSorter{
+		values: make([]interface{}, v.Len()),
+		swap:   reflect.Swapper(objs),
+		desc:   order == "desc",
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		value, ok := objectFields(v.Index(i).Interface())[field]
+		if !ok {
+			return fmt.Errorf("Invalid sort field: %s", field)
+		}
+		s.values[i] = value
+	}
+
+	sort.Stable(s)
+
+	return nil
+}
+
+type fieldSorter struct {
+	values []interface{}
+	swap   func(i, j int)
+	desc   bool
+}
+
+func (s *fieldSorter) Len() int {
+	return len(s.values)
+}
+
+func (s *fieldSorter) Less(i, j int) bool {
+	if s.desc {
+		i, j = j, i
+	}
+
+	// Compare numbers by value instead of by their string representation
+	a, aok := s.values[i].(json.Number)
+	b, bok := s.values[j].(json.Number)
+	if aok && bok {
+		af, _ := a.Float64()
+		bf, _ := b.Float64()
+		return af < bf
+	}
+
+	return fieldString(s.values[i]) < fieldString(s.values[j])
+}
+
+func (s *fieldSorter) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+	s.swap(i, j)
+}
+
+// objectFields returns the fields of a CloudStack object keyed by the field
+// names used by the CloudStack API.
+func objectFields(obj interface{}) map[string]interface{} {
+	var fields map[string]interface{}
+	b, _ := json.Marshal(obj)
+
+	// Use json.Number to keep large numbers (like sizes) readable
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	dec.Decode(&fields)
+
+	return fields
+}
+
+// fieldString returns the string representation of a field value.
+func fieldString(field interface{}) string {
+	switch v := field.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
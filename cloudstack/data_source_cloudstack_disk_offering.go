@@ -0,0 +1,185 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackDiskOffering() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackDiskOfferingRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			// Computed values
+			"disk_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"disk_size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"storage_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"storage_tags": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"provisioning_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"cache_mode": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"min_iops": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_iops": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"hypervisor_snapshot_reserve": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"is_customized": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_customized_iops": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"display_offering": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceCloudstackDiskOfferingRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.DiskOffering.NewListDiskOfferingsParams()
+
+	l, err := cs.DiskOffering.ListDiskOfferings(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list disk offerings: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var diskOfferings []*cloudstack.DiskOffering
+
+	for _, o := range l.DiskOfferings {
+		match, err := applyFilters(o, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			diskOfferings = append(diskOfferings, o)
+		}
+	}
+
+	if len(diskOfferings) == 0 {
+		return fmt.Errorf("No disk offering is matching with the specified regex")
+	}
+
+	if len(diskOfferings) > 1 {
+		return fmt.Errorf(
+			"%d disk offerings are matching with the specified regex, please use a more specific filter", len(diskOfferings))
+	}
+
+	diskOffering := diskOfferings[0]
+	log.Printf("[DEBUG] Selected disk offering: %s\n", diskOffering.Name)
+
+	return diskOfferingDescriptionAttributes(d, diskOffering)
+}
+
+func diskOfferingDescriptionAttributes(d *schema.ResourceData, diskOffering *cloudstack.DiskOffering) error {
+	d.SetId(diskOffering.Id)
+	d.Set("disk_offering_id", diskOffering.Id)
+	d.Set("name", diskOffering.Name)
+	d.Set("display_text", diskOffering.Displaytext)
+	d.Set("disk_size", diskOffering.Disksize)
+	d.Set("storage_type", diskOffering.Storagetype)
+	d.Set("storage_tags", diskOffering.Tags)
+	d.Set("provisioning_type", diskOffering.Provisioningtype)
+	d.Set("cache_mode", diskOffering.CacheMode)
+	d.Set("min_iops", diskOffering.Miniops)
+	d.Set("max_iops", diskOffering.Maxiops)
+	d.Set("hypervisor_snapshot_reserve", diskOffering.Hypervisorsnapshotreserve)
+	d.Set("is_customized", diskOffering.Iscustomized)
+	d.Set("is_customized_iops", diskOffering.Iscustomizediops)
+	d.Set("display_offering", diskOffering.Displayoffering)
+	d.Set("domain", diskOffering.Domain)
+	d.Set("domain_id", diskOffering.Domainid)
+	d.Set("created", diskOffering.Created)
+
+	return nil
+}
@@ -0,0 +1,59 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackDiskOfferingDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackDiskOfferingDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_disk_offering.foo", "name", "Medium"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_disk_offering.foo", "is_customized", "false"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCloudStackDiskOfferingDataSource_basic = `
+data "cloudstack_disk_offering" "foo" {
+  filter {
+    name = "iscustomized"
+    value = "false"
+  }
+
+  filter {
+    name = "disksize"
+    value = "^20$"
+  }
+}`
@@ -0,0 +1,334 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackInstance() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackInstanceRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"tags": tagsSchema(),
+
+			// Computed values
+			"instance_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"group": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"account": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"zone_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"zone": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"template_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"template": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"service_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"service_offering": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"cpu_number": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"cpu_speed": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"memory": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"host_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"host_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"hypervisor": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"keypair": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ip_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"public_ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"nic": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"network_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"network_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip6_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mac_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"netmask": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"gateway": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"is_default": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"secondary_ip_addresses": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceCloudstackInstanceRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.VirtualMachine.NewListVirtualMachinesParams()
+	p.SetListall(true)
+
+	// If there is a project supplied, we retrieve and set the project id
+	if err := setProjectid(p, cs, d); err != nil {
+		return err
+	}
+
+	// Only list instances with all the given tags
+	if tags := tagsFromSchema(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		p.SetTags(tags)
+	}
+
+	csInstances, err := cs.VirtualMachine.ListVirtualMachines(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list instances: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var instances []*cloudstack.VirtualMachine
+
+	for _, vm := range csInstances.VirtualMachines {
+		match, err := applyFilters(vm, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			instances = append(instances, vm)
+		}
+	}
+
+	if len(instances) == 0 {
+		return fmt.Errorf("No instance is matching with the specified regex")
+	}
+
+	if len(instances) > 1 {
+		return fmt.Errorf(
+			"%d instances are matching with the specified regex, please use a more specific filter", len(instances))
+	}
+
+	instance := instances[0]
+	log.Printf("[DEBUG] Selected instance: %s\n", instance.Name)
+
+	return instanceDescriptionAttributes(d, instance)
+}
+
+func instanceDescriptionAttributes(d *schema.ResourceData, vm *cloudstack.VirtualMachine) error {
+	d.SetId(vm.Id)
+	d.Set("instance_id", vm.Id)
+	d.Set("name", vm.Name)
+	d.Set("display_name", vm.Displayname)
+	d.Set("state", vm.State)
+	d.Set("group", vm.Group)
+	d.Set("account", vm.Account)
+	d.Set("domain", vm.Domain)
+	d.Set("created", vm.Created)
+	d.Set("zone_id", vm.Zoneid)
+	d.Set("zone", vm.Zonename)
+	d.Set("template_id", vm.Templateid)
+	d.Set("template", vm.Templatename)
+	d.Set("service_offering_id", vm.Serviceofferingid)
+	d.Set("service_offering", vm.Serviceofferingname)
+	d.Set("cpu_number", vm.Cpunumber)
+	d.Set("cpu_speed", vm.Cpuspeed)
+	d.Set("memory", vm.Memory)
+	d.Set("host_id", vm.Hostid)
+	d.Set("host_name", vm.Hostname)
+	d.Set("hypervisor", vm.Hypervisor)
+	d.Set("keypair", vm.Keypair)
+	d.Set("public_ip", vm.Publicip)
+
+	if vm.Project != "" {
+		d.Set("project", vm.Project)
+	}
+
+	var nics []map[string]interface{}
+	for _, n := range vm.Nic {
+		var secondaryIPs []string
+		for _, ip := range n.Secondaryip {
+			secondaryIPs = append(secondaryIPs, ip.Ipaddress)
+		}
+
+		nics = append(nics, map[string]interface{}{
+			"id":                     n.Id,
+			"network_id":             n.Networkid,
+			"network_name":           n.Networkname,
+			"ip_address":             n.Ipaddress,
+			"ip6_address":            n.Ip6address,
+			"mac_address":            n.Macaddress,
+			"netmask":                n.Netmask,
+			"gateway":                n.Gateway,
+			"is_default":             n.Isdefault,
+			"secondary_ip_addresses": secondaryIPs,
+		})
+
+		if n.Isdefault {
+			d.Set("ip_address", n.Ipaddress)
+		}
+	}
+	d.Set("nic", nics)
+
+	tags := make(map[string]interface{})
+	for _, tag := range vm.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	d.Set("tags", tags)
+
+	return nil
+}
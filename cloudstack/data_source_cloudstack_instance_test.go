@@ -0,0 +1,91 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackInstanceDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstanceDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.cloudstack_instance.foo", "id",
+						"cloudstack_instance.foobar", "id"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_instance.foo", "state", "Running"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_instance.foo", "service_offering", "Small Instance"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_instance.foo", "ip_address", "10.1.1.123"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_instance.foo", "nic.#", "1"),
+					resource.TestCheckResourceAttrPair(
+						"data.cloudstack_instance.foo", "nic.0.network_id",
+						"cloudstack_network.foo", "id"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_instance.foo", "tags.terraform-tag", "true"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCloudStackInstanceDataSource_basic = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  ip_address = "10.1.1.123"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  expunge = true
+  tags = {
+    terraform-tag = "true"
+  }
+}
+
+data "cloudstack_instance" "foo" {
+  filter {
+    name = "name"
+    value = "^${cloudstack_instance.foobar.name}$"
+  }
+
+  tags = {
+    terraform-tag = "true"
+  }
+}`
@@ -0,0 +1,221 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackInstances() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"filter": dataSourceOptionalFiltersSchema(),
+
+		"project": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+
+		"tags": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		// Computed values
+		"ids": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		"instances": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"display_name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"state": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"group": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"created": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"zone": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"template": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"service_offering": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"host_name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"ip_address": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"network_id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"tags": {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+
+	for k, v := range dataSourceSortSchema(cloudstack.VirtualMachine{}, "name") {
+		s[k] = v
+	}
+
+	return &schema.Resource{
+		Read:   dataSourceCloudstackInstancesRead,
+		Schema: s,
+	}
+}
+
+func dataSourceCloudstackInstancesRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.VirtualMachine.NewListVirtualMachinesParams()
+	p.SetListall(true)
+
+	// If there is a project supplied, we retrieve and set the project id
+	if err := setProjectid(p, cs, d); err != nil {
+		return err
+	}
+
+	// Only list instances with all the given tags
+	if tags := tagsFromSchema(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		p.SetTags(tags)
+	}
+
+	csInstances, err := cs.VirtualMachine.ListVirtualMachines(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list instances: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var instances []*cloudstack.VirtualMachine
+
+	for _, vm := range csInstances.VirtualMachines {
+		match, err := applyFilters(vm, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			instances = append(instances, vm)
+		}
+	}
+
+	if len(instances) == 0 && !d.Get("allow_empty").(bool) {
+		return fmt.Errorf("No instance is matching with the specified regex")
+	}
+
+	if err := sortObjects(instances, d.Get("sort_by").(string), d.Get("sort_order").(string)); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(instances))
+	list := make([]map[string]interface{}, 0, len(instances))
+
+	for _, vm := range instances {
+		tags := make(map[string]interface{})
+		for _, tag := range vm.Tags {
+			tags[tag.Key] = tag.Value
+		}
+
+		instance := map[string]interface{}{
+			"id":               vm.Id,
+			"name":             vm.Name,
+			"display_name":     vm.Displayname,
+			"state":            vm.State,
+			"group":            vm.Group,
+			"created":          vm.Created,
+			"zone":             vm.Zonename,
+			"template":         vm.Templatename,
+			"service_offering": vm.Serviceofferingname,
+			"host_name":        vm.Hostname,
+			"tags":             tags,
+		}
+
+		for _, n := range vm.Nic {
+			if n.Isdefault {
+				instance["ip_address"] = n.Ipaddress
+				instance["network_id"] = n.Networkid
+			}
+		}
+
+		ids = append(ids, vm.Id)
+		list = append(list, instance)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("instances", list)
+
+	return nil
+}
@@ -0,0 +1,228 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackNetworkOffering() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackNetworkOfferingRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			// Computed values
+			"network_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"guest_ip_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"traffic_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"availability": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_tags": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"service_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_rate": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_connections": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"for_vpc": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_default": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_persistent": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"conserve_mode": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"egress_default_policy": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"specify_vlan": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"specify_ip_ranges": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"supports_public_access": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"supports_streched_l2_subnet": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"details": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"services": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceCloudstackNetworkOfferingRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.NetworkOffering.NewListNetworkOfferingsParams()
+
+	l, err := cs.NetworkOffering.ListNetworkOfferings(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list network offerings: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var networkOfferings []*cloudstack.NetworkOffering
+
+	for _, o := range l.NetworkOfferings {
+		match, err := applyFilters(o, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			networkOfferings = append(networkOfferings, o)
+		}
+	}
+
+	if len(networkOfferings) == 0 {
+		return fmt.Errorf("No network offering is matching with the specified regex")
+	}
+
+	if len(networkOfferings) > 1 {
+		return fmt.Errorf(
+			"%d network offerings are matching with the specified regex, please use a more specific filter", len(networkOfferings))
+	}
+
+	networkOffering := networkOfferings[0]
+	log.Printf("[DEBUG] Selected network offering: %s\n", networkOffering.Name)
+
+	return networkOfferingDescriptionAttributes(d, networkOffering)
+}
+
+func networkOfferingDescriptionAttributes(d *schema.ResourceData, networkOffering *cloudstack.NetworkOffering) error {
+	d.SetId(networkOffering.Id)
+	d.Set("network_offering_id", networkOffering.Id)
+	d.Set("name", networkOffering.Name)
+	d.Set("display_text", networkOffering.Displaytext)
+	d.Set("guest_ip_type", networkOffering.Guestiptype)
+	d.Set("traffic_type", networkOffering.Traffictype)
+	d.Set("state", networkOffering.State)
+	d.Set("availability", networkOffering.Availability)
+	d.Set("network_tags", networkOffering.Tags)
+	d.Set("service_offering_id", networkOffering.Serviceofferingid)
+	d.Set("network_rate", networkOffering.Networkrate)
+	d.Set("max_connections", networkOffering.Maxconnections)
+	d.Set("for_vpc", networkOffering.Forvpc)
+	d.Set("is_default", networkOffering.Isdefault)
+	d.Set("is_persistent", networkOffering.Ispersistent)
+	d.Set("conserve_mode", networkOffering.Conservemode)
+	d.Set("egress_default_policy", networkOffering.Egressdefaultpolicy)
+	d.Set("specify_vlan", networkOffering.Specifyvlan)
+	d.Set("specify_ip_ranges", networkOffering.Specifyipranges)
+	d.Set("supports_public_access", networkOffering.Supportspublicaccess)
+	d.Set("supports_streched_l2_subnet", networkOffering.Supportsstrechedl2subnet)
+	d.Set("created", networkOffering.Created)
+	d.Set("details", networkOffering.Details)
+
+	var services []string
+	for _, s := range networkOffering.Service {
+		services = append(services, s.Name)
+	}
+	d.Set("services", services)
+
+	return nil
+}
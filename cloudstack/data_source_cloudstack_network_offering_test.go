@@ -0,0 +1,59 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackNetworkOfferingDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkOfferingDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_network_offering.foo", "name", "DefaultIsolatedNetworkOfferingForVpcNetworks"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_network_offering.foo", "for_vpc", "true"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCloudStackNetworkOfferingDataSource_basic = `
+data "cloudstack_network_offering" "foo" {
+  filter {
+    name = "guestiptype"
+    value = "Isolated"
+  }
+
+  filter {
+    name = "forvpc"
+    value = "true"
+  }
+}`
@@ -0,0 +1,208 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackNetworks() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"filter": dataSourceOptionalFiltersSchema(),
+
+		"project": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+
+		"tags": {
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		// Computed values
+		"ids": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		"networks": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"display_text": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"cidr": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"gateway": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"network_domain": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"network_offering": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"state": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"vpc_id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"acl_id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"zone": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"tags": {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+
+	for k, v := range dataSourceSortSchema(cloudstack.Network{}, "name") {
+		s[k] = v
+	}
+
+	return &schema.Resource{
+		Read:   dataSourceCloudstackNetworksRead,
+		Schema: s,
+	}
+}
+
+func dataSourceCloudstackNetworksRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.Network.NewListNetworksParams()
+	p.SetListall(true)
+
+	// If there is a project supplied, we retrieve and set the project id
+	if err := setProjectid(p, cs, d); err != nil {
+		return err
+	}
+
+	// Only list networks with all the given tags
+	if tags := tagsFromSchema(d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		p.SetTags(tags)
+	}
+
+	csNetworks, err := cs.Network.ListNetworks(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list networks: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var networks []*cloudstack.Network
+
+	for _, n := range csNetworks.Networks {
+		match, err := applyFilters(n, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			networks = append(networks, n)
+		}
+	}
+
+	if len(networks) == 0 && !d.Get("allow_empty").(bool) {
+		return fmt.Errorf("No network is matching with the specified regex")
+	}
+
+	if err := sortObjects(networks, d.Get("sort_by").(string), d.Get("sort_order").(string)); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(networks))
+	list := make([]map[string]interface{}, 0, len(networks))
+
+	for _, n := range networks {
+		tags := make(map[string]interface{})
+		for _, tag := range n.Tags {
+			tags[tag.Key] = tag.Value
+		}
+
+		ids = append(ids, n.Id)
+		list = append(list, map[string]interface{}{
+			"id":               n.Id,
+			"name":             n.Name,
+			"display_text":     n.Displaytext,
+			"cidr":             n.Cidr,
+			"gateway":          n.Gateway,
+			"network_domain":   n.Networkdomain,
+			"network_offering": n.Networkofferingname,
+			"state":            n.State,
+			"vpc_id":           n.Vpcid,
+			"acl_id":           n.Aclid,
+			"zone":             n.Zonename,
+			"tags":             tags,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("networks", list)
+
+	return nil
+}
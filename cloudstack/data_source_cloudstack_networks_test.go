@@ -0,0 +1,77 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackNetworksDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworksDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_networks.foo", "ids.#", "2"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_networks.foo", "networks.0.name", "terraform-network-2"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_networks.foo", "networks.0.cidr", "10.1.2.0/24"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_networks.foo", "networks.1.name", "terraform-network-1"),
+					resource.TestCheckResourceAttrPair(
+						"data.cloudstack_networks.foo", "ids.1",
+						"cloudstack_network.foo", "id"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCloudStackNetworksDataSource_basic = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network-1"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network" "bar" {
+  name = "terraform-network-2"
+  cidr = "10.1.2.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+data "cloudstack_networks" "foo" {
+  filter {
+    name = "name"
+    value = "^(${cloudstack_network.foo.name}|${cloudstack_network.bar.name})$"
+  }
+
+  sort_order = "desc"
+}`
@@ -0,0 +1,240 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackServiceOffering() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackServiceOfferingRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			// Computed values
+			"service_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"cpu_number": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"cpu_speed": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"memory": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"storage_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"storage_tags": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"host_tags": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"provisioning_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"deployment_planner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"system_vm_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_rate": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"min_iops": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"max_iops": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"is_customized": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_customized_iops": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_system": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"is_volatile": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"limit_cpu_use": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"offer_ha": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"default_use": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"details": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceCloudstackServiceOfferingRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.ServiceOffering.NewListServiceOfferingsParams()
+
+	l, err := cs.ServiceOffering.ListServiceOfferings(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list service offerings: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var serviceOfferings []*cloudstack.ServiceOffering
+
+	for _, o := range l.ServiceOfferings {
+		match, err := applyFilters(o, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			serviceOfferings = append(serviceOfferings, o)
+		}
+	}
+
+	if len(serviceOfferings) == 0 {
+		return fmt.Errorf("No service offering is matching with the specified regex")
+	}
+
+	if len(serviceOfferings) > 1 {
+		return fmt.Errorf(
+			"%d service offerings are matching with the specified regex, please use a more specific filter", len(serviceOfferings))
+	}
+
+	serviceOffering := serviceOfferings[0]
+	log.Printf("[DEBUG] Selected service offering: %s\n", serviceOffering.Name)
+
+	return serviceOfferingDescriptionAttributes(d, serviceOffering)
+}
+
+func serviceOfferingDescriptionAttributes(d *schema.ResourceData, serviceOffering *cloudstack.ServiceOffering) error {
+	d.SetId(serviceOffering.Id)
+	d.Set("service_offering_id", serviceOffering.Id)
+	d.Set("name", serviceOffering.Name)
+	d.Set("display_text", serviceOffering.Displaytext)
+	d.Set("cpu_number", serviceOffering.Cpunumber)
+	d.Set("cpu_speed", serviceOffering.Cpuspeed)
+	d.Set("memory", serviceOffering.Memory)
+	d.Set("storage_type", serviceOffering.Storagetype)
+	d.Set("storage_tags", serviceOffering.Tags)
+	d.Set("host_tags", serviceOffering.Hosttags)
+	d.Set("provisioning_type", serviceOffering.Provisioningtype)
+	d.Set("deployment_planner", serviceOffering.Deploymentplanner)
+	d.Set("system_vm_type", serviceOffering.Systemvmtype)
+	d.Set("network_rate", serviceOffering.Networkrate)
+	d.Set("min_iops", serviceOffering.Miniops)
+	d.Set("max_iops", serviceOffering.Maxiops)
+	d.Set("is_customized", serviceOffering.Iscustomized)
+	d.Set("is_customized_iops", serviceOffering.Iscustomizediops)
+	d.Set("is_system", serviceOffering.Issystem)
+	d.Set("is_volatile", serviceOffering.Isvolatile)
+	d.Set("limit_cpu_use", serviceOffering.Limitcpuuse)
+	d.Set("offer_ha", serviceOffering.Offerha)
+	d.Set("default_use", serviceOffering.Defaultuse)
+	d.Set("domain", serviceOffering.Domain)
+	d.Set("domain_id", serviceOffering.Domainid)
+	d.Set("created", serviceOffering.Created)
+	d.Set("details", serviceOffering.Serviceofferingdetails)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackServiceOfferingDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackServiceOfferingDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_service_offering.foo", "name", "Medium Instance"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_service_offering.foo", "cpu_speed", "1000"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackServiceOfferingDataSource_simulatorMultiple(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackServiceOfferingDataSource_multiple,
+				ExpectError: regexp.MustCompile("2 service offerings are matching"),
+			},
+		},
+	})
+}
+
+const testAccCloudStackServiceOfferingDataSource_basic = `
+data "cloudstack_service_offering" "foo" {
+  filter {
+    name = "cpunumber"
+    value = "^1$"
+  }
+
+  filter {
+    name = "memory"
+    value = "^1024$"
+  }
+}`
+
+const testAccCloudStackServiceOfferingDataSource_multiple = `
+data "cloudstack_service_offering" "foo" {
+  filter {
+    name = "name"
+    value = "Instance$"
+  }
+}`
@@ -20,10 +20,8 @@
 package cloudstack
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"regexp"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -88,7 +86,7 @@ func dataSourceCloudstackTemplate() *schema.Resource {
 }
 
 func dataSourceCloudstackTemplateRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	p := cloudstack.ListTemplatesParams{}
 	p.SetListall(true)
@@ -164,26 +162,3 @@ func latestTemplate(templates []*cloudstack.Template) (*cloudstack.Template, err
 
 	return template, nil
 }
-
-func applyFilters(template *cloudstack.Template, filters *schema.Set) (bool, error) {
-	var templateJSON map[string]interface{}
-	t, _ := json.Marshal(template)
-	json.Unmarshal(t, &templateJSON)
-
-	for _, f := range filters.List() {
-		m := f.(map[string]interface{})
-
-		r, err := regexp.Compile(m["value"].(string))
-		if err != nil {
-			return false, fmt.Errorf("Invalid regex: %s", err)
-		}
-
-		templateField := templateJSON[m["name"].(string)].(string)
-		if !r.MatchString(templateField) {
-			return false, nil
-		}
-
-	}
-
-	return true, nil
-}
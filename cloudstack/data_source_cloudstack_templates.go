@@ -0,0 +1,191 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackTemplates() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"filter": dataSourceOptionalFiltersSchema(),
+
+		"template_filter": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		// Computed values
+		"ids": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+
+		"templates": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"display_text": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"account": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"created": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"format": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"hypervisor": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"os_type": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"size": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+
+					"zone_id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+
+					"is_ready": {
+						Type:     schema.TypeBool,
+						Computed: true,
+					},
+
+					"tags": {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+
+	for k, v := range dataSourceSortSchema(cloudstack.Template{}, "created") {
+		s[k] = v
+	}
+
+	return &schema.Resource{
+		Read:   dataSourceCloudstackTemplatesRead,
+		Schema: s,
+	}
+}
+
+func dataSourceCloudstackTemplatesRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.Template.NewListTemplatesParams(d.Get("template_filter").(string))
+	p.SetListall(true)
+
+	csTemplates, err := cs.Template.ListTemplates(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list templates: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var templates []*cloudstack.Template
+
+	for _, t := range csTemplates.Templates {
+		match, err := applyFilters(t, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			templates = append(templates, t)
+		}
+	}
+
+	if len(templates) == 0 && !d.Get("allow_empty").(bool) {
+		return fmt.Errorf("No template is matching with the specified regex")
+	}
+
+	if err := sortObjects(templates, d.Get("sort_by").(string), d.Get("sort_order").(string)); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(templates))
+	list := make([]map[string]interface{}, 0, len(templates))
+
+	for _, t := range templates {
+		tags := make(map[string]interface{})
+		for _, tag := range t.Tags {
+			tags[tag.Key] = tag.Value
+		}
+
+		ids = append(ids, t.Id)
+		list = append(list, map[string]interface{}{
+			"id":           t.Id,
+			"name":         t.Name,
+			"display_text": t.Displaytext,
+			"account":      t.Account,
+			"created":      t.Created,
+			"format":       t.Format,
+			"hypervisor":   t.Hypervisor,
+			"os_type":      t.Ostypename,
+			"size":         int(t.Size),
+			"zone_id":      t.Zoneid,
+			"is_ready":     t.Isready,
+			"tags":         tags,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("templates", list)
+
+	return nil
+}
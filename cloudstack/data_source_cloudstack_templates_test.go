@@ -0,0 +1,135 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCloudStackTemplatesDataSource_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackTemplatesDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_templates.foo", "templates.#", "1"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_templates.foo", "templates.0.name", "CentOS 5.6 (64-bit) no GUI (Simulator)"),
+					resource.TestCheckResourceAttr(
+						"data.cloudstack_templates.foo", "templates.0.is_ready", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackTemplatesDataSource_simulatorEmpty(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackTemplatesDataSource_empty,
+				ExpectError: regexp.MustCompile("No template is matching"),
+			},
+		},
+	})
+}
+
+func TestCloudStackTemplatesDataSource_simulatorAll(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackTemplatesDataSource_all,
+				Check: resource.TestCheckResourceAttr(
+					"data.cloudstack_templates.foo", "templates.#", "1"),
+			},
+		},
+	})
+}
+
+func TestCloudStackTemplatesDataSource_simulatorInvalidSort(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackTemplatesDataSource_invalidSort,
+				ExpectError: regexp.MustCompile(`"does-not-exist" is not a valid field name`),
+			},
+		},
+	})
+}
+
+const testAccCloudStackTemplatesDataSource_basic = `
+data "cloudstack_templates" "foo" {
+  template_filter = "featured"
+
+  filter {
+    name = "hypervisor"
+    value = "Simulator"
+  }
+
+  sort_by = "size"
+}`
+
+const testAccCloudStackTemplatesDataSource_empty = `
+data "cloudstack_templates" "foo" {
+  template_filter = "featured"
+
+  filter {
+    name = "name"
+    value = "^does-not-exist$"
+  }
+}`
+
+const testAccCloudStackTemplatesDataSource_all = `
+data "cloudstack_templates" "foo" {
+  template_filter = "featured"
+}`
+
+const testAccCloudStackTemplatesDataSource_invalidSort = `
+data "cloudstack_templates" "foo" {
+  template_filter = "featured"
+
+  filter {
+    name = "name"
+    value = "^does-not-exist$"
+  }
+
+  sort_by = "does-not-exist"
+  allow_empty = true
+}`
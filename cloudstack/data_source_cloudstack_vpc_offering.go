@@ -0,0 +1,143 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackVPCOffering() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackVPCOfferingRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			// Computed values
+			"vpc_offering_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"is_default": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"distributed_vpc_router": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"supports_region_level_vpc": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"services": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceCloudstackVPCOfferingRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.VPC.NewListVPCOfferingsParams()
+
+	l, err := cs.VPC.ListVPCOfferings(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list VPC offerings: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var vpcOfferings []*cloudstack.VPCOffering
+
+	for _, o := range l.VPCOfferings {
+		match, err := applyFilters(o, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			vpcOfferings = append(vpcOfferings, o)
+		}
+	}
+
+	if len(vpcOfferings) == 0 {
+		return fmt.Errorf("No VPC offering is matching with the specified regex")
+	}
+
+	if len(vpcOfferings) > 1 {
+		return fmt.Errorf(
+			"%d VPC offerings are matching with the specified regex, please use a more specific filter", len(vpcOfferings))
+	}
+
+	vpcOffering := vpcOfferings[0]
+	log.Printf("[DEBUG] Selected VPC offering: %s\n", vpcOffering.Name)
+
+	return vpcOfferingDescriptionAttributes(d, vpcOffering)
+}
+
+func vpcOfferingDescriptionAttributes(d *schema.ResourceData, vpcOffering *cloudstack.VPCOffering) error {
+	d.SetId(vpcOffering.Id)
+	d.Set("vpc_offering_id", vpcOffering.Id)
+	d.Set("name", vpcOffering.Name)
+	d.Set("display_text", vpcOffering.Displaytext)
+	d.Set("state", vpcOffering.State)
+	d.Set("is_default", vpcOffering.Isdefault)
+	d.Set("distributed_vpc_router", vpcOffering.Distributedvpcrouter)
+	d.Set("supports_region_level_vpc", vpcOffering.SupportsregionLevelvpc)
+	d.Set("created", vpcOffering.Created)
+
+	var services []string
+	for _, s := range vpcOffering.Service {
+		services = append(services, s.Name)
+	}
+	d.Set("services", services)
+
+	return nil
+}
@@ -0,0 +1,198 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func dataSourceCloudstackZone() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCloudstackZoneRead,
+		Schema: map[string]*schema.Schema{
+			"filter": dataSourceFiltersSchema(),
+
+			// Computed values
+			"zone_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"allocation_state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dhcp_provider": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns1": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns2": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"internal_dns1": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"internal_dns2": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ip6_dns1": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ip6_dns2": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"guest_cidr_address": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"local_storage_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"security_groups_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func dataSourceCloudstackZoneRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	p := cs.Zone.NewListZonesParams()
+
+	csZones, err := cs.Zone.ListZones(p)
+	if err != nil {
+		return fmt.Errorf("Failed to list zones: %s", err)
+	}
+
+	filters := d.Get("filter")
+	var zones []*cloudstack.Zone
+
+	for _, z := range csZones.Zones {
+		match, err := applyFilters(z, filters.(*schema.Set))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			zones = append(zones, z)
+		}
+	}
+
+	if len(zones) == 0 {
+		return fmt.Errorf("No zone is matching with the specified regex")
+	}
+
+	if len(zones) > 1 {
+		return fmt.Errorf("%d zones are matching with the specified regex, please use a more specific filter", len(zones))
+	}
+
+	zone := zones[0]
+	log.Printf("[DEBUG] Selected zone: %s\n", zone.Name)
+
+	return zoneDescriptionAttributes(d, zone)
+}
+
+func zoneDescriptionAttributes(d *schema.ResourceData, zone *cloudstack.Zone) error {
+	d.SetId(zone.Id)
+	d.Set("zone_id", zone.Id)
+	d.Set("name", zone.Name)
+	d.Set("description", zone.Description)
+	d.Set("display_text", zone.Displaytext)
+	d.Set("network_type", zone.Networktype)
+	d.Set("allocation_state", zone.Allocationstate)
+	d.Set("dhcp_provider", zone.Dhcpprovider)
+	d.Set("dns1", zone.Dns1)
+	d.Set("dns2", zone.Dns2)
+	d.Set("internal_dns1", zone.Internaldns1)
+	d.Set("internal_dns2", zone.Internaldns2)
+	d.Set("ip6_dns1", zone.Ip6dns1)
+	d.Set("ip6_dns2", zone.Ip6dns2)
+	d.Set("guest_cidr_address", zone.Guestcidraddress)
+	d.Set("domain", zone.Domain)
+	d.Set("domain_id", zone.Domainid)
+	d.Set("local_storage_enabled", zone.Localstorageenabled)
+	d.Set("security_groups_enabled", zone.Securitygroupsenabled)
+
+	tags := make(map[string]interface{})
+	for _, tag := range zone.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	d.Set("tags", tags)
+
+	return nil
+}
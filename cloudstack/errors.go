@@ -0,0 +1,155 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"encoding/json"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CloudStack API error codes (see org.apache.cloudstack.api.ApiErrorCode)
+const (
+	errorCodeParamError          = 431
+	errorCodeAPILimitExceeded    = 429
+	errorCodeInternalError       = 530
+	errorCodeResourceUnavailable = 534
+)
+
+// CloudStack exception error codes (see com.cloud.utils.exception.CSExceptionErrorCode)
+const (
+	csErrorCodeConcurrentOperation = 4300
+)
+
+// csError contains the details of an error returned by the CloudStack API,
+// either directly or as the result of a failed async job.
+type csError struct {
+	ErrorCode   int    `json:"errorcode"`
+	CSErrorCode int    `json:"cserrorcode"`
+	ErrorText   string `json:"errortext"`
+}
+
+var csErrorMessage = regexp.MustCompile(
+	`^CloudStack API error (\d+) \(CSExceptionErrorCode: (\d+)\): ((?s).*)$`)
+
+// parseCSError returns the details of the given error. Errors which are not
+// returned by the CloudStack API only have their error text set.
+func parseCSError(err error) *csError {
+	msg := err.Error()
+
+	// Errors returned by the API itself
+	if m := csErrorMessage.FindStringSubmatch(msg); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		cscode, _ := strconv.Atoi(m[2])
+		return &csError{ErrorCode: code, CSErrorCode: cscode, ErrorText: m[3]}
+	}
+
+	// Errors returned as the (non-text) result of a failed async job
+	if strings.HasPrefix(msg, "Undefined error: ") {
+		e := &csError{}
+		if json.Unmarshal([]byte(strings.TrimPrefix(msg, "Undefined error: ")), e) == nil {
+			return e
+		}
+	}
+
+	return &csError{ErrorText: msg}
+}
+
+// Messages used by CloudStack (and the go-cloudstack helper functions) to
+// tell that the requested object does not exist
+var notFoundMessages = []string{
+	"does not exist",
+	"unable to find",
+	"no match found for",
+}
+
+// Messages used by CloudStack when an operation failed because of another
+// operation that is still running
+var transientMessages = []string{
+	"concurrent operation",
+	"resource busy",
+	"unable to acquire lock",
+	"please try again",
+}
+
+// isNotFound returns true if the given error means the object that was looked
+// up, updated or deleted does not exist (anymore).
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	e := parseCSError(err)
+
+	// Only parameter errors (and errors not returned by the API) can be
+	// caused by the missing object itself. Internal errors also use these
+	// messages when a related object (e.g. an offering, zone or template)
+	// could not be found, so don't even look at the message of other errors.
+	switch e.ErrorCode {
+	case 0, errorCodeParamError:
+	default:
+		return false
+	}
+
+	return containsAny(e.ErrorText, notFoundMessages)
+}
+
+// isRetryable returns true if the given error is transient, so the failed
+// call might succeed when it is retried.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Connection errors and timeouts
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	e := parseCSError(err)
+
+	if e.ErrorCode == errorCodeAPILimitExceeded {
+		return true
+	}
+
+	if e.CSErrorCode == csErrorCodeConcurrentOperation {
+		return true
+	}
+
+	switch e.ErrorCode {
+	case 0, errorCodeInternalError, errorCodeResourceUnavailable:
+		return containsAny(e.ErrorText, transientMessages)
+	}
+
+	return false
+}
+
+// containsAny returns true if s contains any of the given (lower case) substrings.
+func containsAny(s string, substrs []string) bool {
+	s = strings.ToLower(s)
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
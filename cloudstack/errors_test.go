@@ -0,0 +1,146 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIsNotFound(t *testing.T) {
+	cases := []struct {
+		Err      error
+		NotFound bool
+	}{
+		{
+			Err: errors.New("CloudStack API error 431 (CSExceptionErrorCode: 9999): Invalid parameter " +
+				"id value=1234 due to incorrect long value format, or entity does not exist"),
+			NotFound: true,
+		},
+		{
+			Err:      errors.New("CloudStack API error 431 (CSExceptionErrorCode: 4350): A key pair with name 'foo' does not exist for account admin"),
+			NotFound: true,
+		},
+		{
+			Err:      errors.New("Undefined error: {\"errorcode\":431,\"errortext\":\"Unable to find network by id\"}"),
+			NotFound: true,
+		},
+		{
+			Err:      errors.New("No match found for 1234: &{Count:0 VirtualMachines:[]}"),
+			NotFound: true,
+		},
+		{
+			Err:      errors.New("CloudStack API error 401 (CSExceptionErrorCode: 9999): unable to verify user credentials"),
+			NotFound: false,
+		},
+		{
+			Err:      errors.New("CloudStack API error 533 (CSExceptionErrorCode: 4250): Entity does not exist"),
+			NotFound: false,
+		},
+		{
+			Err:      errors.New("Undefined error: {\"errorcode\":530,\"errortext\":\"resource busy\"}"),
+			NotFound: false,
+		},
+		{
+			Err:      errors.New("Undefined error: {\"errorcode\":530,\"errortext\":\"Unable to find service offering by id\"}"),
+			NotFound: false,
+		},
+		{
+			Err:      errors.New("CloudStack API error 530 (CSExceptionErrorCode: 4250): Unable to find template with id 1234"),
+			NotFound: false,
+		},
+		{
+			Err:      nil,
+			NotFound: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if isNotFound(tc.Err) != tc.NotFound {
+			t.Fatalf("Expected isNotFound to return %t for: %v", tc.NotFound, tc.Err)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		Err       error
+		Retryable bool
+	}{
+		{
+			Err:       errors.New("CloudStack API error 530 (CSExceptionErrorCode: 4300): Failed due to a concurrent operation"),
+			Retryable: true,
+		},
+		{
+			Err:       errors.New("CloudStack API error 530 (CSExceptionErrorCode: 4250): Unable to acquire lock on network"),
+			Retryable: true,
+		},
+		{
+			Err:       errors.New("CloudStack API error 429 (CSExceptionErrorCode: 9999): The given command does not exist or it is not available for user"),
+			Retryable: true,
+		},
+		{
+			Err:       errors.New("Undefined error: {\"errorcode\":530,\"cserrorcode\":4250,\"errortext\":\"resource busy\"}"),
+			Retryable: true,
+		},
+		{
+			Err:       &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			Retryable: true,
+		},
+		{
+			Err:       errors.New("CloudStack API error 431 (CSExceptionErrorCode: 4350): resource busy"),
+			Retryable: false,
+		},
+		{
+			Err:       errors.New("CloudStack API error 530 (CSExceptionErrorCode: 4250): Failed to create firewall rule"),
+			Retryable: false,
+		},
+		{
+			Err:       nil,
+			Retryable: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if isRetryable(tc.Err) != tc.Retryable {
+			t.Fatalf("Expected isRetryable to return %t for: %v", tc.Retryable, tc.Err)
+		}
+	}
+}
+
+func TestParseCSError(t *testing.T) {
+	e := parseCSError(errors.New(
+		"CloudStack API error 431 (CSExceptionErrorCode: 4350): Unable to find\nthe object"))
+	if e.ErrorCode != 431 || e.CSErrorCode != 4350 || e.ErrorText != "Unable to find\nthe object" {
+		t.Fatalf("Unexpected result parsing an API error: %+v", e)
+	}
+
+	e = parseCSError(errors.New(
+		"Undefined error: {\"errorcode\":530,\"cserrorcode\":4300,\"errortext\":\"busy\"}"))
+	if e.ErrorCode != 530 || e.CSErrorCode != 4300 || e.ErrorText != "busy" {
+		t.Fatalf("Unexpected result parsing an async job error: %+v", e)
+	}
+
+	e = parseCSError(errors.New("Timeout while waiting for async job to finish"))
+	if e.ErrorCode != 0 || e.ErrorText != "Timeout while waiting for async job to finish" {
+		t.Fatalf("Unexpected result parsing another error: %+v", e)
+	}
+}
@@ -0,0 +1,262 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"log"
+	"sync"
+)
+
+// lookupKey identifies a name to ID lookup. The scope holds any parameters
+// the lookup depends on (like the zone of a template).
+type lookupKey struct {
+	kind  string
+	name  string
+	scope string
+
+	// all marks the key of a prefetch of all names of a kind and scope
+	all bool
+}
+
+// lookupEntry holds the result of a (possibly still running) lookup.
+type lookupEntry struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// lookupCache caches the IDs retrieved by retrieveID and retrieveTemplateID,
+// so every distinct name is only looked up once per provider. Concurrent
+// lookups of the same name wait for the first one to finish. Failed lookups
+// are not cached, so they are tried again the next time.
+type lookupCache struct {
+	// prefetch enables retrieving the IDs of all objects of a kind using a
+	// single API call, the first time a name of that kind is looked up
+	prefetch bool
+
+	mu      sync.Mutex
+	entries map[lookupKey]*lookupEntry
+}
+
+func newLookupCache(prefetch bool) *lookupCache {
+	return &lookupCache{
+		prefetch: prefetch,
+		entries:  make(map[lookupKey]*lookupEntry),
+	}
+}
+
+// get returns the cached ID of the given key, or calls lookup to retrieve it.
+func (c *lookupCache) get(cs *Client, key lookupKey, lookup func() (string, error)) (string, error) {
+	if c == nil {
+		return lookup()
+	}
+
+	if c.prefetch {
+		if list := prefetchers[key.kind]; list != nil {
+			all := lookupKey{kind: key.kind, scope: key.scope, all: true}
+			c.do(all, func() (string, error) {
+				log.Printf("[DEBUG] Prefetching IDs of all %ss", key.kind)
+
+				ids, err := list(cs, key.scope)
+				if err != nil {
+					// Fall back to looking up every name by itself
+					log.Printf("[WARN] Error prefetching IDs of all %ss: %s", key.kind, err)
+					return "", nil
+				}
+
+				for name, id := range ids {
+					// Ambiguous names are looked up by themselves, so they
+					// resolve the same way as without prefetching
+					if id == ambiguousID {
+						continue
+					}
+					c.set(lookupKey{kind: key.kind, name: name, scope: key.scope}, id)
+				}
+
+				return "", nil
+			})
+		}
+	}
+
+	return c.do(key, lookup)
+}
+
+// do returns the result of the lookup of the given key, calling the lookup
+// function only if the key is not cached or being looked up already.
+func (c *lookupCache) do(key lookupKey, lookup func() (string, error)) (string, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-e.done
+		return e.id, e.err
+	}
+
+	e := &lookupEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.id, e.err = lookup()
+
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	close(e.done)
+
+	return e.id, e.err
+}
+
+// set caches the given ID, unless the key is cached or being looked up already.
+func (c *lookupCache) set(key lookupKey, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		e := &lookupEntry{done: make(chan struct{}), id: id}
+		close(e.done)
+		c.entries[key] = e
+	}
+}
+
+// forget removes the given name from the cache (in all scopes), so the next
+// lookup retrieves the ID again. This is needed when the provider itself
+// creates or deletes an object that may have been looked up already.
+func (c *lookupCache) forget(kind, name string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.kind == kind && (key.name == name || key.all) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// prefetchers return the IDs of all objects of a kind by their names. Names
+// used by more than one object are marked as ambiguous, see addPrefetchedID.
+var prefetchers = map[string]func(cs *Client, scope string) (map[string]string, error){
+	"disk_offering": func(cs *Client, scope string) (map[string]string, error) {
+		l, err := cs.DiskOffering.ListDiskOfferings(cs.DiskOffering.NewListDiskOfferingsParams())
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.DiskOfferings))
+		for _, o := range l.DiskOfferings {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"network_offering": func(cs *Client, scope string) (map[string]string, error) {
+		l, err := cs.NetworkOffering.ListNetworkOfferings(cs.NetworkOffering.NewListNetworkOfferingsParams())
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.NetworkOfferings))
+		for _, o := range l.NetworkOfferings {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"project": func(cs *Client, scope string) (map[string]string, error) {
+		p := cs.Project.NewListProjectsParams()
+		p.SetListall(true)
+		l, err := cs.Project.ListProjects(p)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.Projects))
+		for _, o := range l.Projects {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"service_offering": func(cs *Client, scope string) (map[string]string, error) {
+		l, err := cs.ServiceOffering.ListServiceOfferings(cs.ServiceOffering.NewListServiceOfferingsParams())
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.ServiceOfferings))
+		for _, o := range l.ServiceOfferings {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"template": func(cs *Client, scope string) (map[string]string, error) {
+		p := cs.Template.NewListTemplatesParams("executable")
+		if scope != "" {
+			p.SetZoneid(scope)
+		}
+		l, err := cs.Template.ListTemplates(p)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.Templates))
+		for _, o := range l.Templates {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"vpc_offering": func(cs *Client, scope string) (map[string]string, error) {
+		l, err := cs.VPC.ListVPCOfferings(cs.VPC.NewListVPCOfferingsParams())
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.VPCOfferings))
+		for _, o := range l.VPCOfferings {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+
+	"zone": func(cs *Client, scope string) (map[string]string, error) {
+		l, err := cs.Zone.ListZones(cs.Zone.NewListZonesParams())
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(l.Zones))
+		for _, o := range l.Zones {
+			addPrefetchedID(ids, o.Name, o.Id)
+		}
+		return ids, nil
+	},
+}
+
+// ambiguousID marks a prefetched name that is used by more than one object.
+const ambiguousID = "<ambiguous>"
+
+// addPrefetchedID adds the ID of a prefetched object, or marks the name as
+// ambiguous if another object with the same name was prefetched already.
+func addPrefetchedID(ids map[string]string, name, id string) {
+	if existing, ok := ids[name]; ok && existing != id {
+		ids[name] = ambiguousID
+		return
+	}
+	ids[name] = id
+}
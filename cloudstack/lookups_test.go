@@ -0,0 +1,236 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLookupCache_concurrent(t *testing.T) {
+	c := newLookupCache(false)
+	key := lookupKey{kind: "zone", name: "foo"}
+
+	var calls int32
+	lookup := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "1234", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := c.get(nil, key, lookup); err != nil || id != "1234" {
+				t.Errorf("Expected ID 1234, got %q (%v)", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected 1 lookup, got %d", calls)
+	}
+
+	// Names in another scope are looked up again
+	if _, err := c.get(nil, lookupKey{kind: "zone", name: "foo", scope: "bar"}, lookup); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected 2 lookups, got %d", calls)
+	}
+}
+
+func TestLookupCache_errors(t *testing.T) {
+	c := newLookupCache(false)
+	key := lookupKey{kind: "zone", name: "foo"}
+
+	calls := 0
+	lookup := func() (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.New("No match found for foo")
+		}
+		return "1234", nil
+	}
+
+	if _, err := c.get(nil, key, lookup); err == nil {
+		t.Fatal("Expected the first lookup to fail")
+	}
+
+	for i := 0; i < 2; i++ {
+		if id, err := c.get(nil, key, lookup); err != nil || id != "1234" {
+			t.Fatalf("Expected ID 1234, got %q (%v)", id, err)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("Expected 2 lookups, got %d", calls)
+	}
+
+	c.forget("zone", "foo")
+	if _, err := c.get(nil, key, lookup); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if calls != 3 {
+		t.Fatalf("Expected 3 lookups after forgetting the name, got %d", calls)
+	}
+}
+
+func TestLookupCache_simulator(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		sim := newSimulator(t)
+
+		cfg := Config{
+			APIURL:          sim.URL + "/client/api",
+			APIKey:          testSimAPIKey,
+			SecretKey:       testSimSecretKey,
+			Timeout:         10,
+			PrefetchLookups: prefetch,
+		}
+
+		cs, err := cfg.NewClient()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				zoneid, e := retrieveID(cs, "zone", "Sandbox-simulator")
+				if e != nil {
+					t.Error(e.Error())
+					return
+				}
+
+				for _, offering := range []string{"Small", "Medium"} {
+					if _, e := retrieveID(cs, "disk_offering", offering); e != nil {
+						t.Error(e.Error())
+					}
+				}
+
+				if _, e := retrieveTemplateID(cs, zoneid, "CentOS 5.6 (64-bit) no GUI (Simulator)"); e != nil {
+					t.Error(e.Error())
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Without prefetching, every distinct name is looked up once
+		offerings := 2
+		if prefetch {
+			offerings = 1
+		}
+
+		for command, n := range map[string]int{
+			"listZones":         1,
+			"listDiskOfferings": offerings,
+			"listTemplates":     1,
+		} {
+			if c := sim.count(command); c != n {
+				t.Fatalf("Expected %d calls of %s (prefetch = %t), got %d", n, command, prefetch, c)
+			}
+		}
+
+		sim.Close()
+	}
+}
+
+func TestLookupCache_simulatorAmbiguous(t *testing.T) {
+	ids := make(map[bool]string)
+
+	for _, prefetch := range []bool{false, true} {
+		sim := newSimulator(t)
+
+		// Add a second disk offering with the same name
+		sim.add("diskoffering", simObject{
+			"name": "Small", "displaytext": "Another small disk", "disksize": 5,
+		})
+
+		cfg := Config{
+			APIURL:          sim.URL + "/client/api",
+			APIKey:          testSimAPIKey,
+			SecretKey:       testSimSecretKey,
+			Timeout:         10,
+			PrefetchLookups: prefetch,
+		}
+
+		cs, err := cfg.NewClient()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		id, e := retrieveID(cs, "disk_offering", "Small")
+		if e != nil {
+			ids[prefetch] = e.Error().Error()
+		} else {
+			ids[prefetch] = id
+		}
+
+		// The ambiguous name is looked up by itself after prefetching
+		calls := 1
+		if prefetch {
+			calls = 2
+		}
+		if c := sim.count("listDiskOfferings"); c != calls {
+			t.Fatalf("Expected %d calls of listDiskOfferings (prefetch = %t), got %d", calls, prefetch, c)
+		}
+
+		// Unambiguous names are still resolved using the prefetched IDs
+		if _, e := retrieveID(cs, "disk_offering", "Medium"); e != nil {
+			t.Fatal(e.Error())
+		}
+		if !prefetch {
+			calls++
+		}
+		if c := sim.count("listDiskOfferings"); c != calls {
+			t.Fatalf("Expected %d calls of listDiskOfferings (prefetch = %t), got %d", calls, prefetch, c)
+		}
+
+		sim.Close()
+	}
+
+	if ids[false] != ids[true] {
+		t.Fatalf("Expected the same result with and without prefetching, got %s and %s", ids[false], ids[true])
+	}
+}
+
+func TestAddPrefetchedID(t *testing.T) {
+	ids := make(map[string]string)
+	addPrefetchedID(ids, "foo", "1")
+	addPrefetchedID(ids, "bar", "2")
+	addPrefetchedID(ids, "bar", "3")
+	addPrefetchedID(ids, "bar", "4")
+
+	if ids["foo"] != "1" {
+		t.Fatalf("Expected foo to have ID 1, got %s", ids["foo"])
+	}
+	if ids["bar"] != ambiguousID {
+		t.Fatalf("Expected bar to be ambiguous, got %s", ids["bar"])
+	}
+}
@@ -23,7 +23,6 @@ import (
 	"log"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 // metadataSchema returns the schema to use for metadata
@@ -37,7 +36,7 @@ func metadataSchema() *schema.Schema {
 
 // setMetadata is a helper to set the metadata for a resource. It expects the
 // metadata field to be named "metadata"
-func setMetadata(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourceType string) error {
+func setMetadata(cs *Client, d *schema.ResourceData, resourceType string) error {
 	if metadata, ok := d.GetOk("metadata"); ok {
 		p := cs.Resourcemetadata.NewAddResourceDetailParams(
 			tagsFromSchema(metadata.(map[string]interface{})),
@@ -52,7 +51,7 @@ func setMetadata(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resour
 	return nil
 }
 
-func getMetadata(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourceType string) (map[string]interface{}, error) {
+func getMetadata(cs *Client, d *schema.ResourceData, resourceType string) (map[string]interface{}, error) {
 	p := cs.Resourcemetadata.NewListResourceDetailsParams(resourceType)
 	p.SetResourceid(d.Id())
 	response, err := cs.Resourcemetadata.ListResourceDetails(p)
@@ -75,7 +74,7 @@ func getMetadata(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resour
 
 // updateMetadata is a helper to update only when metadata field change metadata
 // field to be named "metadata"
-func updateMetadata(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourceType string) error {
+func updateMetadata(cs *Client, d *schema.ResourceData, resourceType string) error {
 	oraw, nraw := d.GetChange("metadata")
 	o := oraw.(map[string]interface{})
 	n := nraw.(map[string]interface{})
@@ -0,0 +1,144 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// setPassword stores the password of an instance. When a PGP key is configured
+// only the encrypted password and the fingerprint of the key are stored.
+func setPassword(d *schema.ResourceData, password string) error {
+	pgpKey := d.Get("pgp_key").(string)
+	if pgpKey == "" || password == "" {
+		d.Set("password", password)
+		d.Set("encrypted_password", "")
+		d.Set("key_fingerprint", "")
+		return nil
+	}
+
+	fingerprint, encrypted, err := encryptPassword(pgpKey, password)
+	if err != nil {
+		return err
+	}
+
+	d.Set("password", "")
+	d.Set("encrypted_password", encrypted)
+	d.Set("key_fingerprint", fingerprint)
+
+	return nil
+}
+
+// encryptPassword encrypts the password using a base64 encoded PGP public key.
+// It returns the fingerprint of the key and the base64 encoded encrypted
+// password, which can be decrypted using `base64 -d | gpg -d`.
+func encryptPassword(pgpKey, password string) (string, string, error) {
+	key, err := base64.StdEncoding.DecodeString(pgpKey)
+	if err != nil {
+		return "", "", fmt.Errorf("Error decoding the PGP key: %s", err)
+	}
+
+	entity, err := openpgp.ReadEntity(packet.NewReader(bytes.NewReader(key)))
+	if err != nil {
+		return "", "", fmt.Errorf("Error reading the PGP key: %s", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("Error encrypting the password: %s", err)
+	}
+	if _, err := w.Write([]byte(password)); err != nil {
+		return "", "", fmt.Errorf("Error encrypting the password: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", "", fmt.Errorf("Error encrypting the password: %s", err)
+	}
+
+	fingerprint := hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])
+	return fingerprint, base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decryptPassword decrypts a password returned by getVMPassword. CloudStack
+// encrypts the password using the RSA public key of the SSH key pair of the
+// instance, so it is decrypted using the PEM encoded private key of the pair.
+func decryptPassword(privateKey, encrypted string) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", fmt.Errorf("Error decoding the private key: no PEM data found")
+	}
+
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("Error decoding the private key: not an RSA key")
+		}
+		key = rsaKey
+	} else {
+		return "", fmt.Errorf("Error decoding the private key: %s", err)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("Error decoding the encrypted password: %s", err)
+	}
+
+	password, err := rsa.DecryptPKCS1v15(rand.Reader, key, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("Error decrypting the password: %s", err)
+	}
+
+	return string(password), nil
+}
+
+// getVMPassword retrieves the password of an instance using a key pair, and
+// decrypts it using the private key of the pair. The getVMPassword call of
+// go-cloudstack does not decode the nested password object of the response,
+// so the call is made using a custom request.
+func getVMPassword(cs *Client, id, privateKey string) (string, error) {
+	p := &cloudstack.CustomServiceParams{}
+	p.SetParam("id", id)
+
+	var r struct {
+		Password struct {
+			Encryptedpassword string `json:"encryptedpassword"`
+		} `json:"password"`
+	}
+	if err := cs.Custom.CustomRequest("getVMPassword", p, &r); err != nil {
+		return "", err
+	}
+
+	return decryptPassword(privateKey, r.Password.Encryptedpassword)
+}
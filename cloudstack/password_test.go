@@ -0,0 +1,137 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// testPGPKey returns a new PGP entity and its base64 encoded public key.
+func testPGPKey(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("terraform", "", "terraform@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Prefer SHA256 like keys generated by GnuPG, as the default RIPEMD160
+	// hash is not compiled in
+	for _, id := range entity.Identities {
+		id.SelfSignature.PreferredHash = []uint8{8}
+		if err := id.SelfSignature.SignUserId(id.UserId.Id, entity.PrimaryKey, entity.PrivateKey, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := entity.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return entity, base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// testPGPDecrypt decrypts a base64 encoded message using the PGP entity.
+func testPGPDecrypt(entity *openpgp.Entity, encrypted string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ioutil.ReadAll(md.UnverifiedBody)
+	return string(b), err
+}
+
+func TestEncryptPassword(t *testing.T) {
+	entity, pgpKey := testPGPKey(t)
+
+	fingerprint, encrypted, err := encryptPassword(pgpKey, "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if expected := hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]); fingerprint != expected {
+		t.Fatalf("Expected fingerprint %s, got %s", expected, fingerprint)
+	}
+
+	password, err := testPGPDecrypt(entity, encrypted)
+	if err != nil {
+		t.Fatalf("Error decrypting the password: %s", err)
+	}
+	if password != "secret" {
+		t.Fatalf("Expected password secret, got %s", password)
+	}
+
+	if _, _, err := encryptPassword("not a key", "secret"); err == nil {
+		t.Fatal("Expected an error for an invalid PGP key")
+	}
+}
+
+func TestDecryptPassword(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := base64.StdEncoding.EncodeToString(ciphertext)
+
+	cases := map[string]string{
+		"PKCS1": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"PKCS8": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	}
+
+	for name, privateKey := range cases {
+		password, err := decryptPassword(privateKey, encrypted)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if password != "secret" {
+			t.Fatalf("%s: expected password secret, got %s", name, password)
+		}
+	}
+
+	if _, err := decryptPassword("not a key", encrypted); err == nil {
+		t.Fatal("Expected an error for an invalid private key")
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-ini/ini"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -42,26 +43,48 @@ func Provider() terraform.ResourceProvider {
 				Type:          schema.TypeString,
 				Optional:      true,
 				DefaultFunc:   schema.EnvDefaultFunc("CLOUDSTACK_API_KEY", nil),
-				ConflictsWith: []string{"config", "profile"},
+				ConflictsWith: []string{"config", "profile", "username", "password"},
 			},
 
 			"secret_key": {
 				Type:          schema.TypeString,
 				Optional:      true,
 				DefaultFunc:   schema.EnvDefaultFunc("CLOUDSTACK_SECRET_KEY", nil),
-				ConflictsWith: []string{"config", "profile"},
+				ConflictsWith: []string{"config", "profile", "username", "password"},
+			},
+
+			"username": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CLOUDSTACK_USERNAME", nil),
+				ConflictsWith: []string{"config", "profile", "api_key", "secret_key"},
+			},
+
+			"password": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				DefaultFunc:   schema.EnvDefaultFunc("CLOUDSTACK_PASSWORD", nil),
+				ConflictsWith: []string{"config", "profile", "api_key", "secret_key"},
+			},
+
+			"domain": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CLOUDSTACK_DOMAIN", nil),
+				ConflictsWith: []string{"config", "profile", "api_key", "secret_key"},
 			},
 
 			"config": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"api_url", "api_key", "secret_key"},
+				ConflictsWith: []string{"api_url", "api_key", "secret_key", "username", "password", "domain"},
 			},
 
 			"profile": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"api_url", "api_key", "secret_key"},
+				ConflictsWith: []string{"api_url", "api_key", "secret_key", "username", "password", "domain"},
 			},
 
 			"http_get_only": {
@@ -75,10 +98,100 @@ func Provider() terraform.ResourceProvider {
 				Required:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_TIMEOUT", 900),
 			},
+
+			"verify_ssl": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_VERIFY_SSL", true),
+			},
+
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_CA_FILE", nil),
+			},
+
+			"client_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_CLIENT_CERT_FILE", nil),
+			},
+
+			"client_key_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_CLIENT_KEY_FILE", nil),
+			},
+
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_PROXY_URL", nil),
+			},
+
+			"headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDSTACK_MAX_RETRIES", 3),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"retry_min_wait": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDSTACK_RETRY_MIN_WAIT", 1),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"retry_max_wait": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CLOUDSTACK_RETRY_MAX_WAIT", 30),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"default_zone": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_DEFAULT_ZONE", nil),
+			},
+
+			"default_project": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_DEFAULT_PROJECT", nil),
+			},
+
+			"default_tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"prefetch_lookups": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CLOUDSTACK_PREFETCH_LOOKUPS", false),
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"cloudstack_template": dataSourceCloudstackTemplate(),
+			"cloudstack_disk_offering":    dataSourceCloudstackDiskOffering(),
+			"cloudstack_instance":         dataSourceCloudstackInstance(),
+			"cloudstack_instances":        dataSourceCloudstackInstances(),
+			"cloudstack_network_offering": dataSourceCloudstackNetworkOffering(),
+			"cloudstack_networks":         dataSourceCloudstackNetworks(),
+			"cloudstack_service_offering": dataSourceCloudstackServiceOffering(),
+			"cloudstack_template":         dataSourceCloudstackTemplate(),
+			"cloudstack_templates":        dataSourceCloudstackTemplates(),
+			"cloudstack_vpc_offering":     dataSourceCloudstackVPCOffering(),
+			"cloudstack_zone":             dataSourceCloudstackZone(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -86,12 +199,16 @@ func Provider() terraform.ResourceProvider {
 			"cloudstack_autoscale_vm_profile": resourceCloudStackAutoScaleVMProfile(),
 			"cloudstack_disk":                 resourceCloudStackDisk(),
 			"cloudstack_egress_firewall":      resourceCloudStackEgressFirewall(),
+			"cloudstack_egress_firewall_rule": resourceCloudStackEgressFirewallRule(),
 			"cloudstack_firewall":             resourceCloudStackFirewall(),
+			"cloudstack_firewall_rule":        resourceCloudStackFirewallRule(),
 			"cloudstack_instance":             resourceCloudStackInstance(),
 			"cloudstack_ipaddress":            resourceCloudStackIPAddress(),
+			"cloudstack_iso":                  resourceCloudStackISO(),
 			"cloudstack_loadbalancer_rule":    resourceCloudStackLoadBalancerRule(),
 			"cloudstack_network":              resourceCloudStackNetwork(),
 			"cloudstack_network_acl":          resourceCloudStackNetworkACL(),
+			"cloudstack_network_acl_item":     resourceCloudStackNetworkACLItem(),
 			"cloudstack_network_acl_rule":     resourceCloudStackNetworkACLRule(),
 			"cloudstack_nic":                  resourceCloudStackNIC(),
 			"cloudstack_port_forward":         resourceCloudStackPortForward(),
@@ -117,10 +234,17 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	apiURL, apiURLOK := d.GetOk("api_url")
 	apiKey, apiKeyOK := d.GetOk("api_key")
 	secretKey, secretKeyOK := d.GetOk("secret_key")
+	username, usernameOK := d.GetOk("username")
+	password, passwordOK := d.GetOk("password")
+	domain := d.Get("domain")
 	config, configOK := d.GetOk("config")
 	profile, profileOK := d.GetOk("profile")
 
 	switch {
+	case usernameOK, passwordOK:
+		if !(apiURLOK && usernameOK && passwordOK) {
+			return nil, errors.New("'api_url', 'username' and 'password' should all have values")
+		}
 	case apiURLOK, apiKeyOK, secretKeyOK:
 		if !(apiURLOK && apiKeyOK && secretKeyOK) {
 			return nil, errors.New("'api_url', 'api_key' and 'secret_key' should all have values")
@@ -130,8 +254,8 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 			return nil, errors.New("'config' and 'profile' should both have a value")
 		}
 	default:
-		return nil, errors.New(
-			"either 'api_url', 'api_key' and 'secret_key' or 'config' and 'profile' should have values")
+		return nil, errors.New("either 'api_url', 'api_key' and 'secret_key', or 'api_url', " +
+			"'username' and 'password', or 'config' and 'profile' should have values")
 	}
 
 	if configOK && profileOK {
@@ -148,14 +272,46 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		apiURL = section.Key("url").String()
 		apiKey = section.Key("apikey").String()
 		secretKey = section.Key("secretkey").String()
+
+		// Profiles without API keys use the username and password instead
+		if apiKey == "" {
+			username = section.Key("username").String()
+			password = section.Key("password").String()
+			domain = section.Key("domain").String()
+		}
+	}
+
+	retryMinWait := d.Get("retry_min_wait").(int)
+	retryMaxWait := d.Get("retry_max_wait").(int)
+	if retryMinWait > retryMaxWait {
+		return nil, errors.New("'retry_min_wait' should not be greater than 'retry_max_wait'")
 	}
 
 	cfg := Config{
-		APIURL:      apiURL.(string),
-		APIKey:      apiKey.(string),
-		SecretKey:   secretKey.(string),
-		HTTPGETOnly: d.Get("http_get_only").(bool),
-		Timeout:     int64(d.Get("timeout").(int)),
+		APIURL:       apiURL.(string),
+		APIKey:       apiKey.(string),
+		SecretKey:    secretKey.(string),
+		Username:     username.(string),
+		Password:     password.(string),
+		Domain:       domain.(string),
+		HTTPGETOnly:  d.Get("http_get_only").(bool),
+		Timeout:      int64(d.Get("timeout").(int)),
+		MaxRetries:   d.Get("max_retries").(int),
+		RetryMinWait: int64(retryMinWait),
+		RetryMaxWait: int64(retryMaxWait),
+
+		VerifySSL:      d.Get("verify_ssl").(bool),
+		CAFile:         d.Get("ca_file").(string),
+		ClientCertFile: d.Get("client_cert_file").(string),
+		ClientKeyFile:  d.Get("client_key_file").(string),
+		ProxyURL:       d.Get("proxy_url").(string),
+		Headers:        tagsFromSchema(d.Get("headers").(map[string]interface{})),
+
+		DefaultZone:    d.Get("default_zone").(string),
+		DefaultProject: d.Get("default_project").(string),
+		DefaultTags:    tagsFromSchema(d.Get("default_tags").(map[string]interface{})),
+
+		PrefetchLookups: d.Get("prefetch_lookups").(bool),
 	}
 
 	return cfg.NewClient()
@@ -31,6 +31,7 @@ var testAccProviders map[string]terraform.ResourceProvider
 var testAccProvider *schema.Provider
 
 var cloudStackTemplateURL = os.Getenv("CLOUDSTACK_TEMPLATE_URL")
+var cloudStackISOURL = os.Getenv("CLOUDSTACK_ISO_URL")
 
 func init() {
 	testAccProvider = Provider().(*schema.Provider)
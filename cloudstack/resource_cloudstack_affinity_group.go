@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -62,12 +61,25 @@ func resourceCloudStackAffinityGroup() *schema.Resource {
 				Optional: true,
 				ForceNew: true,
 			},
+
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
 func resourceCloudStackAffinityGroupCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	name := d.Get("name").(string)
 	affinityGroupType := d.Get("type").(string)
@@ -87,6 +99,11 @@ func resourceCloudStackAffinityGroupCreate(d *schema.ResourceData, meta interfac
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	log.Printf("[DEBUG] Creating affinity group %s", name)
 	r, err := cs.AffinityGroup.CreateAffinityGroup(p)
 	if err != nil {
@@ -100,17 +117,18 @@ func resourceCloudStackAffinityGroupCreate(d *schema.ResourceData, meta interfac
 }
 
 func resourceCloudStackAffinityGroupRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	log.Printf("[DEBUG] Rerieving affinity group %s", d.Get("name").(string))
 
 	// Get the affinity group details
-	ag, count, err := cs.AffinityGroup.GetAffinityGroupByID(
+	ag, _, err := cs.AffinityGroup.GetAffinityGroupByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Affinity group %s does not longer exist", d.Get("name").(string))
 			d.SetId("")
 			return nil
@@ -128,7 +146,7 @@ func resourceCloudStackAffinityGroupRead(d *schema.ResourceData, meta interface{
 }
 
 func resourceCloudStackAffinityGroupDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.AffinityGroup.NewDeleteAffinityGroupParams()
@@ -139,13 +157,16 @@ func resourceCloudStackAffinityGroupDelete(d *schema.ResourceData, meta interfac
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Delete the affinity group
 	_, err := cs.AffinityGroup.DeleteAffinityGroup(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
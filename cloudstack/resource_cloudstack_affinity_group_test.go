@@ -66,6 +66,27 @@ func TestAccCloudStackAffinityGroup_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackAffinityGroup_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackAffinityGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackAffinityGroup,
+			},
+
+			{
+				ResourceName:      "cloudstack_affinity_group.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackAffinityGroupExists(
 	n string, affinityGroup *cloudstack.AffinityGroup) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -78,7 +99,7 @@ func testAccCheckCloudStackAffinityGroupExists(
 			return fmt.Errorf("No affinity group ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		ag, _, err := cs.AffinityGroup.GetAffinityGroupByID(rs.Primary.ID)
 
 		if err != nil {
@@ -116,7 +137,7 @@ func testAccCheckCloudStackAffinityGroupAttributes(
 }
 
 func testAccCheckCloudStackAffinityGroupDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_affinity_group" {
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"log"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -51,7 +50,8 @@ func resourceCloudStackAutoScaleVMProfile() *schema.Resource {
 
 			"zone": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 
@@ -74,7 +74,7 @@ func resourceCloudStackAutoScaleVMProfile() *schema.Resource {
 }
 
 func resourceCloudStackAutoScaleVMProfileCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Retrieve the service_offering ID
 	serviceofferingid, e := retrieveID(cs, "service_offering", d.Get("service_offering").(string))
@@ -83,7 +83,7 @@ func resourceCloudStackAutoScaleVMProfileCreate(d *schema.ResourceData, meta int
 	}
 
 	// Retrieve the zone ID
-	zoneid, e := retrieveID(cs, "zone", d.Get("zone").(string))
+	zoneid, e := retrieveZoneID(cs, d)
 	if e != nil {
 		return e.Error()
 	}
@@ -130,12 +130,12 @@ func resourceCloudStackAutoScaleVMProfileCreate(d *schema.ResourceData, meta int
 }
 
 func resourceCloudStackAutoScaleVMProfileRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
-	p, count, err := cs.AutoScale.GetAutoScaleVmProfileByID(d.Id())
+	p, _, err := cs.AutoScale.GetAutoScaleVmProfileByID(d.Id())
 
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] AutoScaleVmProfile %s no longer exists", d.Id())
 			d.SetId("")
@@ -189,7 +189,7 @@ func resourceCloudStackAutoScaleVMProfileRead(d *schema.ResourceData, meta inter
 }
 
 func resourceCloudStackAutoScaleVMProfileUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.AutoScale.NewUpdateAutoScaleVmProfileParams(d.Id())
@@ -229,7 +229,7 @@ func resourceCloudStackAutoScaleVMProfileUpdate(d *schema.ResourceData, meta int
 }
 
 func resourceCloudStackAutoScaleVMProfileDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.AutoScale.NewDeleteAutoScaleVmProfileParams(d.Id())
@@ -238,10 +238,8 @@ func resourceCloudStackAutoScaleVMProfileDelete(d *schema.ResourceData, meta int
 	log.Printf("[INFO] Deleting AutoScaleVmProfile: %s", d.Id())
 	_, err := cs.AutoScale.DeleteAutoScaleVmProfile(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
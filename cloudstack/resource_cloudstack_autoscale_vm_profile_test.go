@@ -80,9 +80,41 @@ func TestAccCloudStackAutoscaleVMProfile_update(t *testing.T) {
 	})
 }
 
+func TestCloudStackAutoscaleVMProfile_simulator(t *testing.T) {
+	var vmProfile cloudstack.AutoScaleVmProfile
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackAutoscaleVMProfileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackAutoscaleVMProfile_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackAutoscaleVMProfileExists("cloudstack_autoscale_vm_profile.foo", &vmProfile),
+					testAccCheckCloudStackAutoscaleVMProfileBasicAttributes(&vmProfile),
+				),
+			},
+
+			{
+				Config: testAccCloudStackAutoscaleVMProfile_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackAutoscaleVMProfileExists(
+						"cloudstack_autoscale_vm_profile.foo", &vmProfile),
+					testAccCheckCloudStackAutoscaleVMProfileUpdatedAttributes(&vmProfile),
+					resource.TestCheckResourceAttr(
+						"cloudstack_autoscale_vm_profile.foo", "zone", "Sandbox-simulator"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckResourceMetadata(vmProfile *cloudstack.AutoScaleVmProfile) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		p := cs.Resourcemetadata.NewListResourceDetailsParams("AutoScaleVmProfile")
 		p.SetResourceid(vmProfile.Id)
 		response, err := cs.Resourcemetadata.ListResourceDetails(p)
@@ -109,7 +141,7 @@ func testAccCheckCloudStackAutoscaleVMProfileExists(
 			return fmt.Errorf("No vmProfile ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		avp, _, err := cs.AutoScale.GetAutoScaleVmProfileByID(rs.Primary.ID)
 
 		if err != nil {
@@ -129,7 +161,7 @@ func testAccCheckCloudStackAutoscaleVMProfileExists(
 func testAccCheckCloudStackAutoscaleVMProfileBasicAttributes(
 	vmProfile *cloudstack.AutoScaleVmProfile) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 
 		serviceofferingid, e := retrieveID(cs, "service_offering", "Small Instance")
 		if e != nil {
@@ -179,7 +211,7 @@ func testAccCheckCloudStackAutoscaleVMProfileUpdatedAttributes(
 }
 
 func testAccCheckCloudStackAutoscaleVMProfileDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_autoscale_vm_profile" {
@@ -21,7 +21,6 @@ package cloudstack
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -37,6 +36,12 @@ func resourceCloudStackDisk() *schema.Resource {
 			State: importStatePassthrough,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -85,9 +90,25 @@ func resourceCloudStackDisk() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
 			"zone": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 
@@ -97,7 +118,7 @@ func resourceCloudStackDisk() *schema.Resource {
 }
 
 func resourceCloudStackDiskCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 	d.Partial(true)
 
 	name := d.Get("name").(string)
@@ -124,8 +145,13 @@ func resourceCloudStackDiskCreate(d *schema.ResourceData, meta interface{}) erro
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Retrieve the zone ID
-	zoneid, e := retrieveID(cs, "zone", d.Get("zone").(string))
+	zoneid, e := retrieveZoneID(cs, d)
 	if e != nil {
 		return e.Error()
 	}
@@ -157,7 +183,7 @@ func resourceCloudStackDiskCreate(d *schema.ResourceData, meta interface{}) erro
 	d.SetPartial("tags")
 
 	if d.Get("attach").(bool) {
-		if err := resourceCloudStackDiskAttach(d, meta); err != nil {
+		if err := resourceCloudStackDiskAttach(cs, d); err != nil {
 			return fmt.Errorf("Error attaching the new disk %s to virtual machine: %s", name, err)
 		}
 
@@ -170,15 +196,16 @@ func resourceCloudStackDiskCreate(d *schema.ResourceData, meta interface{}) erro
 }
 
 func resourceCloudStackDiskRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the volume details
-	v, count, err := cs.Volume.GetVolumeByID(
+	v, _, err := cs.Volume.GetVolumeByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		}
@@ -190,14 +217,11 @@ func resourceCloudStackDiskRead(d *schema.ResourceData, meta interface{}) error
 	d.Set("attach", v.Virtualmachineid != "")   // If attached this contains a virtual machine ID
 	d.Set("size", int(v.Size/(1024*1024*1024))) // Needed to get GB's again
 
-	tags := make(map[string]interface{})
-	for _, tag := range v.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, v.Tags)
 
 	setValueOrID(d, "disk_offering", v.Diskofferingname, v.Diskofferingid)
 	setValueOrID(d, "project", v.Project, v.Projectid)
+	readAccount(d, v.Account, v.Domain, v.Domainid, v.Projectid)
 	setValueOrID(d, "zone", v.Zonename, v.Zoneid)
 
 	if v.Virtualmachineid != "" {
@@ -209,14 +233,14 @@ func resourceCloudStackDiskRead(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceCloudStackDiskUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 	d.Partial(true)
 
 	name := d.Get("name").(string)
 
 	if d.HasChange("disk_offering") || d.HasChange("size") {
 		// Detach the volume (re-attach is done at the end of this function)
-		if err := resourceCloudStackDiskDetach(d, meta); err != nil {
+		if err := resourceCloudStackDiskDetach(cs, d); err != nil {
 			return fmt.Errorf("Error detaching disk %s from virtual machine: %s", name, err)
 		}
 
@@ -256,14 +280,14 @@ func resourceCloudStackDiskUpdate(d *schema.ResourceData, meta interface{}) erro
 	// volume at the end of this function
 	if d.HasChange("device_id") || d.HasChange("virtual_machine") {
 		// Detach the volume
-		if err := resourceCloudStackDiskDetach(d, meta); err != nil {
+		if err := resourceCloudStackDiskDetach(cs, d); err != nil {
 			return fmt.Errorf("Error detaching disk %s from virtual machine: %s", name, err)
 		}
 	}
 
 	if d.Get("attach").(bool) {
 		// Attach the volume
-		err := resourceCloudStackDiskAttach(d, meta)
+		err := resourceCloudStackDiskAttach(cs, d)
 		if err != nil {
 			return fmt.Errorf("Error attaching disk %s to virtual machine: %s", name, err)
 		}
@@ -274,7 +298,7 @@ func resourceCloudStackDiskUpdate(d *schema.ResourceData, meta interface{}) erro
 		d.SetPartial("virtual_machine_id")
 	} else {
 		// Detach the volume
-		if err := resourceCloudStackDiskDetach(d, meta); err != nil {
+		if err := resourceCloudStackDiskDetach(cs, d); err != nil {
 			return fmt.Errorf("Error detaching disk %s from virtual machine: %s", name, err)
 		}
 	}
@@ -294,10 +318,10 @@ func resourceCloudStackDiskUpdate(d *schema.ResourceData, meta interface{}) erro
 }
 
 func resourceCloudStackDiskDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Detach the volume
-	if err := resourceCloudStackDiskDetach(d, meta); err != nil {
+	if err := resourceCloudStackDiskDetach(cs, d); err != nil {
 		return err
 	}
 
@@ -306,10 +330,8 @@ func resourceCloudStackDiskDelete(d *schema.ResourceData, meta interface{}) erro
 
 	// Delete the voluem
 	if _, err := cs.Volume.DeleteVolume(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -319,12 +341,10 @@ func resourceCloudStackDiskDelete(d *schema.ResourceData, meta interface{}) erro
 	return nil
 }
 
-func resourceCloudStackDiskAttach(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-
+func resourceCloudStackDiskAttach(cs *Client, d *schema.ResourceData) error {
 	if virtualmachineid, ok := d.GetOk("virtual_machine_id"); ok {
 		// First check if the disk isn't already attached
-		if attached, err := isAttached(d, meta); err != nil || attached {
+		if attached, err := isAttached(cs, d); err != nil || attached {
 			return err
 		}
 
@@ -347,11 +367,9 @@ func resourceCloudStackDiskAttach(d *schema.ResourceData, meta interface{}) erro
 	return nil
 }
 
-func resourceCloudStackDiskDetach(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-
+func resourceCloudStackDiskDetach(cs *Client, d *schema.ResourceData) error {
 	// Check if the volume is actually attached, before detaching
-	if attached, err := isAttached(d, meta); err != nil || !attached {
+	if attached, err := isAttached(cs, d); err != nil || !attached {
 		return err
 	}
 
@@ -391,13 +409,12 @@ func resourceCloudStackDiskDetach(d *schema.ResourceData, meta interface{}) erro
 	return err
 }
 
-func isAttached(d *schema.ResourceData, meta interface{}) (bool, error) {
-	cs := meta.(*cloudstack.CloudStackClient)
-
+func isAttached(cs *Client, d *schema.ResourceData) (bool, error) {
 	// Get the volume details
 	v, _, err := cs.Volume.GetVolumeByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
 		return false, err
@@ -407,7 +424,7 @@ func isAttached(d *schema.ResourceData, meta interface{}) (bool, error) {
 }
 
 func retryableAttachVolumeFunc(
-	cs *cloudstack.CloudStackClient,
+	cs *Client,
 	p *cloudstack.AttachVolumeParams) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		r, err := cs.Volume.AttachVolume(p)
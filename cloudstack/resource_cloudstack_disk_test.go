@@ -21,6 +21,7 @@ package cloudstack
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -122,6 +123,143 @@ func TestAccCloudStackDisk_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackDisk_simulator(t *testing.T) {
+	var disk cloudstack.Volume
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackDisk_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackDiskExists(
+						"cloudstack_disk.foo", &disk),
+					testAccCheckCloudStackDiskAttributes(&disk),
+				),
+			},
+
+			{
+				Config: testAccCloudStackDisk_resize,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackDiskExists(
+						"cloudstack_disk.foo", &disk),
+					testAccCheckCloudStackDiskResized(&disk),
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "disk_offering", "Medium"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackDisk_simulatorTimeout(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.stall("createVolume")
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackDisk_timeout,
+				ExpectError: regexp.MustCompile("Timeout while waiting for async job to finish"),
+			},
+		},
+	})
+}
+
+func TestCloudStackDisk_simulatorDisappears(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:             testAccCloudStackDisk_update,
+				Check:              testAccCheckSimulatorRemove(sim, "volume", "cloudstack_disk.foo"),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackDisk_simulatorProviderTimeout(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	// Without a timeouts block the provider timeout is used instead of a
+	// default timeout of the resource
+	sim.provider = `
+  timeout = 1`
+	sim.stall("createVolume")
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackDisk_basic,
+				ExpectError: regexp.MustCompile("Timeout while waiting for async job to finish"),
+			},
+		},
+	})
+}
+
+func TestCloudStackDisk_simulatorDefaults(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.provider = `
+  default_zone    = "Sandbox-simulator"
+  default_project = "terraform"
+  default_tags = {
+    owner = "terraform"
+  }`
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackDiskDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackDisk_defaults,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "zone", "Sandbox-simulator"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "project", "terraform"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "tags.%", "1"),
+					testAccCheckSimulatorTags(sim, "cloudstack_disk.foo", map[string]string{
+						"owner":         "terraform",
+						"terraform-tag": "true",
+					}),
+				),
+			},
+
+			{
+				Config: testAccCloudStackDisk_defaultsUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "tags.%", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_disk.foo", "tags.owner", "me"),
+					testAccCheckSimulatorTags(sim, "cloudstack_disk.foo", map[string]string{
+						"owner": "me",
+					}),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackDiskExists(
 	n string, disk *cloudstack.Volume) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -134,7 +272,7 @@ func testAccCheckCloudStackDiskExists(
 			return fmt.Errorf("No disk ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		volume, _, err := cs.Volume.GetVolumeByID(rs.Primary.ID)
 
 		if err != nil {
@@ -180,7 +318,7 @@ func testAccCheckCloudStackDiskResized(
 }
 
 func testAccCheckCloudStackDiskDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_disk" {
@@ -211,6 +349,26 @@ resource "cloudstack_disk" "foo" {
   }
 }`
 
+const testAccCloudStackDisk_defaults = `
+resource "cloudstack_disk" "foo" {
+  name = "terraform-disk"
+  attach = false
+  disk_offering = "Small"
+  tags = {
+    terraform-tag = "true"
+  }
+}`
+
+const testAccCloudStackDisk_defaultsUpdate = `
+resource "cloudstack_disk" "foo" {
+  name = "terraform-disk"
+  attach = false
+  disk_offering = "Small"
+  tags = {
+    owner = "me"
+  }
+}`
+
 const testAccCloudStackDisk_update = `
 resource "cloudstack_disk" "foo" {
   name = "terraform-disk"
@@ -251,3 +409,15 @@ resource "cloudstack_disk" "foo" {
   virtual_machine_id = "${cloudstack_instance.foobar.id}"
   zone = "${cloudstack_instance.foobar.zone}"
 }`
+
+const testAccCloudStackDisk_timeout = `
+resource "cloudstack_disk" "foo" {
+  name = "terraform-disk"
+  attach = false
+  disk_offering = "Small"
+  zone = "Sandbox-simulator"
+
+  timeouts {
+    create = "1s"
+  }
+}`
@@ -21,13 +21,11 @@ package cloudstack
 
 import (
 	"fmt"
-	"strconv"
+	"log"
 	"strings"
-	"sync"
-	"time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -37,6 +35,11 @@ func resourceCloudStackEgressFirewall() *schema.Resource {
 		Read:   resourceCloudStackEgressFirewallRead,
 		Update: resourceCloudStackEgressFirewallUpdate,
 		Delete: resourceCloudStackEgressFirewallDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackEgressFirewallImport,
+		},
+
+		CustomizeDiff: verifyRulesDiff("rule", verifyRuleProtocolParams),
 
 		Schema: map[string]*schema.Schema{
 			"network_id": {
@@ -59,32 +62,41 @@ func resourceCloudStackEgressFirewall() *schema.Resource {
 						"cidr_list": {
 							Type:     schema.TypeSet,
 							Required: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateCIDR,
+							},
+							Set: schema.HashString,
 						},
 
 						"protocol": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateProtocol(false, "tcp", "udp", "icmp"),
 						},
 
 						"icmp_type": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"icmp_code": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"ports": {
 							Type:     schema.TypeSet,
 							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validatePorts,
+							},
+							Set: schema.HashString,
 						},
 
 						"uuids": {
@@ -118,7 +130,7 @@ func resourceCloudStackEgressFirewallCreate(d *schema.ResourceData, meta interfa
 		// Create an empty schema.Set to hold all rules
 		rules := resourceCloudStackEgressFirewall().Schema["rule"].ZeroValue().(*schema.Set)
 
-		err := createEgressFirewallRules(d, meta, rules, nrs)
+		err := createRules(egressFirewallRuleHandler(d, meta), rules, nrs)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -131,52 +143,35 @@ func resourceCloudStackEgressFirewallCreate(d *schema.ResourceData, meta interfa
 	return resourceCloudStackEgressFirewallRead(d, meta)
 }
 
-func createEgressFirewallRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, nrs *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(nrs.Len())
-
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range nrs.List() {
-		// Put in a tiny sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Create a single rule
-			err := createEgressFirewallRule(d, meta, rule)
-
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
-			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
+// egressFirewallRuleHandler returns the handler used to create and delete the
+// rules of the egress firewall.
+func egressFirewallRuleHandler(d *schema.ResourceData, meta interface{}) *ruleHandler {
+	cs := meta.(*Client)
+	networkid := d.Id()
+
+	return &ruleHandler{
+		parallelism: d.Get("parallelism").(int),
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
+			// Make sure all required rule parameters are there
+			if err := verifyEgressFirewallRuleParams(d, rule); err != nil {
+				return nil, err
 			}
-
-			<-sem
-		}(rule.(map[string]interface{}))
+			return protocolRuleItems(rule), nil
+		},
+		create: func(rule map[string]interface{}, item ruleItem) (string, error) {
+			return createEgressFirewallRule(cs, networkid, rule, item)
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			p := cs.Firewall.NewDeleteEgressFirewallRuleParams(id)
+			_, err := cs.Firewall.DeleteEgressFirewallRule(p)
+			return err
+		},
 	}
-
-	wg.Wait()
-
-	return errs.ErrorOrNil()
 }
-func createEgressFirewallRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
-
-	// Make sure all required rule parameters are there
-	if err := verifyEgressFirewallRuleParams(d, rule); err != nil {
-		return err
-	}
 
+func createEgressFirewallRule(cs *Client, networkid string, rule map[string]interface{}, item ruleItem) (string, error) {
 	// Create a new parameter struct
-	p := cs.Firewall.NewCreateEgressFirewallRuleParams(d.Id(), rule["protocol"].(string))
+	p := cs.Firewall.NewCreateEgressFirewallRuleParams(networkid, rule["protocol"].(string))
 
 	// Set the CIDR list
 	var cidrList []string
@@ -189,66 +184,29 @@ func createEgressFirewallRule(d *schema.ResourceData, meta interface{}, rule map
 	if rule["protocol"].(string) == "icmp" {
 		p.SetIcmptype(rule["icmp_type"].(int))
 		p.SetIcmpcode(rule["icmp_code"].(int))
+	}
 
-		r, err := cs.Firewall.CreateEgressFirewallRule(p)
+	// If the item has a port, set the port range
+	if item.port != "" {
+		startPort, endPort, err := splitPortRange(item.port)
 		if err != nil {
-			return err
+			return "", err
 		}
-		uuids["icmp"] = r.Id
-		rule["uuids"] = uuids
-	}
-
-	// If protocol is not ICMP, loop through all ports
-	if rule["protocol"].(string) != "icmp" {
-		if ps := rule["ports"].(*schema.Set); ps.Len() > 0 {
-
-			// Create an empty schema.Set to hold all processed ports
-			ports := &schema.Set{F: schema.HashString}
-
-			for _, port := range ps.List() {
-				if _, ok := uuids[port.(string)]; ok {
-					ports.Add(port)
-					rule["ports"] = ports
-					continue
-				}
-
-				m := splitPorts.FindStringSubmatch(port.(string))
 
-				startPort, err := strconv.Atoi(m[1])
-				if err != nil {
-					return err
-				}
-
-				endPort := startPort
-				if m[2] != "" {
-					endPort, err = strconv.Atoi(m[2])
-					if err != nil {
-						return err
-					}
-				}
-
-				p.SetStartport(startPort)
-				p.SetEndport(endPort)
-
-				r, err := cs.Firewall.CreateEgressFirewallRule(p)
-				if err != nil {
-					return err
-				}
-
-				ports.Add(port)
-				rule["ports"] = ports
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
+	}
 
-				uuids[port.(string)] = r.Id
-				rule["uuids"] = uuids
-			}
-		}
+	r, err := cs.Firewall.CreateEgressFirewallRule(p)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return r.Id, nil
 }
 
 func resourceCloudStackEgressFirewallRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get all the rules from the running environment
 	p := cs.Firewall.NewListEgressFirewallRulesParams()
@@ -257,6 +215,12 @@ func resourceCloudStackEgressFirewallRead(d *schema.ResourceData, meta interface
 
 	l, err := cs.Firewall.ListEgressFirewallRules(p)
 	if err != nil {
+		if isNotFound(err) {
+			log.Printf("[DEBUG] Network %s does no longer exist", d.Id())
+			d.SetId("")
+			return nil
+		}
+
 		return err
 	}
 
@@ -389,38 +353,8 @@ func resourceCloudStackEgressFirewallUpdate(d *schema.ResourceData, meta interfa
 
 	// Check if the rule set as a whole has changed
 	if d.HasChange("rule") {
-		o, n := d.GetChange("rule")
-		ors := o.(*schema.Set).Difference(n.(*schema.Set))
-		nrs := n.(*schema.Set).Difference(o.(*schema.Set))
-
-		// We need to start with a rule set containing all the rules we
-		// already have and want to keep. Any rules that are not deleted
-		// correctly and any newly created rules, will be added to this
-		// set to make sure we end up in a consistent state
-		rules := o.(*schema.Set).Intersection(n.(*schema.Set))
-
-		// First loop through all the old rules and delete them
-		if ors.Len() > 0 {
-			err := deleteEgressFirewallRules(d, meta, rules, ors)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		// Then loop through all the new rules and create them
-		if nrs.Len() > 0 {
-			err := createEgressFirewallRules(d, meta, rules, nrs)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
+		if err := reconcileRules(d, "rule", egressFirewallRuleHandler(d, meta)); err != nil {
+			return err
 		}
 	}
 
@@ -434,7 +368,7 @@ func resourceCloudStackEgressFirewallDelete(d *schema.ResourceData, meta interfa
 
 	// Delete all rules
 	if ors := d.Get("rule").(*schema.Set); ors.Len() > 0 {
-		err := deleteEgressFirewallRules(d, meta, rules, ors)
+		err := deleteRules(egressFirewallRuleHandler(d, meta), rules, ors)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -447,75 +381,45 @@ func resourceCloudStackEgressFirewallDelete(d *schema.ResourceData, meta interfa
 	return nil
 }
 
-func deleteEgressFirewallRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, ors *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(ors.Len())
+func resourceCloudStackEgressFirewallImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
 
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range ors.List() {
-		// Put a sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Delete a single rule
-			err := deleteEgressFirewallRule(d, meta, rule)
-
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
-			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			}
+	// Get all the rules from the running environment
+	p := cs.Firewall.NewListEgressFirewallRulesParams()
+	p.SetNetworkid(d.Id())
+	p.SetListall(true)
 
-			<-sem
-		}(rule.(map[string]interface{}))
+	l, err := cs.Firewall.ListEgressFirewallRules(p)
+	if err != nil {
+		return nil, err
 	}
 
-	wg.Wait()
-
-	return errs.ErrorOrNil()
-}
-
-func deleteEgressFirewallRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
+	// Rebuild the rules, grouping all rules that only differ in their ports
+	rules := newImportedRules()
+	for _, r := range l.EgressFirewallRules {
+		cidrs, key := cidrSet(r.Cidrlist)
 
-	for k, id := range uuids {
-		// We don't care about the count here, so just continue
-		if k == "%" {
-			continue
+		rule := map[string]interface{}{
+			"cidr_list": cidrs,
+			"protocol":  r.Protocol,
 		}
 
-		// Create the parameter struct
-		p := cs.Firewall.NewDeleteEgressFirewallRuleParams(id.(string))
-
-		// Delete the rule
-		if _, err := cs.Firewall.DeleteEgressFirewallRule(p); err != nil {
-
-			// This is a very poor way to be told the ID does no longer exist :(
-			if strings.Contains(err.Error(), fmt.Sprintf(
-				"Invalid parameter id value=%s due to incorrect long value format, "+
-					"or entity does not exist", id.(string))) {
-				delete(uuids, k)
-				continue
-			}
-
-			return err
+		if r.Protocol == "icmp" {
+			rule["icmp_type"] = r.Icmptype
+			rule["icmp_code"] = r.Icmpcode
+			rules.add(r.Id, rule, "", r.Id)
+			continue
 		}
 
-		// Delete the UUID of this rule
-		delete(uuids, k)
-		rule["uuids"] = uuids
+		rules.add(r.Protocol+"/"+key, rule, portRange(r.Startport, r.Endport), r.Id)
 	}
 
-	return nil
+	d.Set("network_id", d.Id())
+	d.Set("managed", false)
+	d.Set("parallelism", 2)
+	d.Set("rule", rules.list())
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func verifyEgressFirewallParams(d *schema.ResourceData) error {
@@ -549,10 +453,8 @@ func verifyEgressFirewallRuleParams(d *schema.ResourceData, rule map[string]inte
 	} else {
 		if ports, ok := rule["ports"].(*schema.Set); ok {
 			for _, port := range ports.List() {
-				m := splitPorts.FindStringSubmatch(port.(string))
-				if m == nil {
-					return fmt.Errorf(
-						"%q is not a valid port value. Valid options are '80' or '80-90'", port.(string))
+				if _, _, err := splitPortRange(port.(string)); err != nil {
+					return err
 				}
 			}
 		} else {
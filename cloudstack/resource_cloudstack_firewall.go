@@ -21,13 +21,11 @@ package cloudstack
 
 import (
 	"fmt"
-	"strconv"
+	"log"
 	"strings"
-	"sync"
-	"time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -37,6 +35,11 @@ func resourceCloudStackFirewall() *schema.Resource {
 		Read:   resourceCloudStackFirewallRead,
 		Update: resourceCloudStackFirewallUpdate,
 		Delete: resourceCloudStackFirewallDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackFirewallImport,
+		},
+
+		CustomizeDiff: verifyRulesDiff("rule", verifyRuleProtocolParams),
 
 		Schema: map[string]*schema.Schema{
 			"ip_address_id": {
@@ -59,32 +62,41 @@ func resourceCloudStackFirewall() *schema.Resource {
 						"cidr_list": {
 							Type:     schema.TypeSet,
 							Required: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateCIDR,
+							},
+							Set: schema.HashString,
 						},
 
 						"protocol": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateProtocol(false, "tcp", "udp", "icmp"),
 						},
 
 						"icmp_type": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"icmp_code": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"ports": {
 							Type:     schema.TypeSet,
 							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validatePorts,
+							},
+							Set: schema.HashString,
 						},
 
 						"uuids": {
@@ -118,7 +130,7 @@ func resourceCloudStackFirewallCreate(d *schema.ResourceData, meta interface{})
 		// Create an empty schema.Set to hold all rules
 		rules := resourceCloudStackFirewall().Schema["rule"].ZeroValue().(*schema.Set)
 
-		err := createFirewallRules(d, meta, rules, nrs)
+		err := createRules(firewallRuleHandler(d, meta), rules, nrs)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -130,53 +142,36 @@ func resourceCloudStackFirewallCreate(d *schema.ResourceData, meta interface{})
 
 	return resourceCloudStackFirewallRead(d, meta)
 }
-func createFirewallRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, nrs *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(nrs.Len())
-
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range nrs.List() {
-		// Put in a tiny sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
 
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Create a single rule
-			err := createFirewallRule(d, meta, rule)
-
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
+// firewallRuleHandler returns the handler used to create and delete the
+// rules of the firewall.
+func firewallRuleHandler(d *schema.ResourceData, meta interface{}) *ruleHandler {
+	cs := meta.(*Client)
+	ipaddressid := d.Id()
+
+	return &ruleHandler{
+		parallelism: d.Get("parallelism").(int),
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
+			// Make sure all required rule parameters are there
+			if err := verifyFirewallRuleParams(d, rule); err != nil {
+				return nil, err
 			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			}
-
-			<-sem
-		}(rule.(map[string]interface{}))
+			return protocolRuleItems(rule), nil
+		},
+		create: func(rule map[string]interface{}, item ruleItem) (string, error) {
+			return createFirewallRule(cs, ipaddressid, rule, item)
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			p := cs.Firewall.NewDeleteFirewallRuleParams(id)
+			_, err := cs.Firewall.DeleteFirewallRule(p)
+			return err
+		},
 	}
-
-	wg.Wait()
-
-	return errs.ErrorOrNil()
 }
 
-func createFirewallRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
-
-	// Make sure all required rule parameters are there
-	if err := verifyFirewallRuleParams(d, rule); err != nil {
-		return err
-	}
-
+func createFirewallRule(cs *Client, ipaddressid string, rule map[string]interface{}, item ruleItem) (string, error) {
 	// Create a new parameter struct
-	p := cs.Firewall.NewCreateFirewallRuleParams(d.Id(), rule["protocol"].(string))
+	p := cs.Firewall.NewCreateFirewallRuleParams(ipaddressid, rule["protocol"].(string))
 
 	// Set the CIDR list
 	var cidrList []string
@@ -189,67 +184,29 @@ func createFirewallRule(d *schema.ResourceData, meta interface{}, rule map[strin
 	if rule["protocol"].(string) == "icmp" {
 		p.SetIcmptype(rule["icmp_type"].(int))
 		p.SetIcmpcode(rule["icmp_code"].(int))
+	}
 
-		r, err := cs.Firewall.CreateFirewallRule(p)
+	// If the item has a port, set the port range
+	if item.port != "" {
+		startPort, endPort, err := splitPortRange(item.port)
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		uuids["icmp"] = r.Id
-		rule["uuids"] = uuids
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
 	}
 
-	// If protocol is not ICMP, loop through all ports
-	if rule["protocol"].(string) != "icmp" {
-		if ps := rule["ports"].(*schema.Set); ps.Len() > 0 {
-
-			// Create an empty schema.Set to hold all processed ports
-			ports := &schema.Set{F: schema.HashString}
-
-			for _, port := range ps.List() {
-				if _, ok := uuids[port.(string)]; ok {
-					ports.Add(port)
-					rule["ports"] = ports
-					continue
-				}
-
-				m := splitPorts.FindStringSubmatch(port.(string))
-
-				startPort, err := strconv.Atoi(m[1])
-				if err != nil {
-					return err
-				}
-
-				endPort := startPort
-				if m[2] != "" {
-					endPort, err = strconv.Atoi(m[2])
-					if err != nil {
-						return err
-					}
-				}
-
-				p.SetStartport(startPort)
-				p.SetEndport(endPort)
-
-				r, err := cs.Firewall.CreateFirewallRule(p)
-				if err != nil {
-					return err
-				}
-
-				ports.Add(port)
-				rule["ports"] = ports
-
-				uuids[port.(string)] = r.Id
-				rule["uuids"] = uuids
-			}
-		}
+	r, err := cs.Firewall.CreateFirewallRule(p)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return r.Id, nil
 }
 
 func resourceCloudStackFirewallRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get all the rules from the running environment
 	p := cs.Firewall.NewListFirewallRulesParams()
@@ -258,6 +215,12 @@ func resourceCloudStackFirewallRead(d *schema.ResourceData, meta interface{}) er
 
 	l, err := cs.Firewall.ListFirewallRules(p)
 	if err != nil {
+		if isNotFound(err) {
+			log.Printf("[DEBUG] IP address with ID %s is no longer associated", d.Id())
+			d.SetId("")
+			return nil
+		}
+
 		return err
 	}
 
@@ -390,38 +353,8 @@ func resourceCloudStackFirewallUpdate(d *schema.ResourceData, meta interface{})
 
 	// Check if the rule set as a whole has changed
 	if d.HasChange("rule") {
-		o, n := d.GetChange("rule")
-		ors := o.(*schema.Set).Difference(n.(*schema.Set))
-		nrs := n.(*schema.Set).Difference(o.(*schema.Set))
-
-		// We need to start with a rule set containing all the rules we
-		// already have and want to keep. Any rules that are not deleted
-		// correctly and any newly created rules, will be added to this
-		// set to make sure we end up in a consistent state
-		rules := o.(*schema.Set).Intersection(n.(*schema.Set))
-
-		// First loop through all the old rules and delete them
-		if ors.Len() > 0 {
-			err := deleteFirewallRules(d, meta, rules, ors)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		// Then loop through all the new rules and create them
-		if nrs.Len() > 0 {
-			err := createFirewallRules(d, meta, rules, nrs)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
+		if err := reconcileRules(d, "rule", firewallRuleHandler(d, meta)); err != nil {
+			return err
 		}
 	}
 
@@ -435,7 +368,7 @@ func resourceCloudStackFirewallDelete(d *schema.ResourceData, meta interface{})
 
 	// Delete all rules
 	if ors := d.Get("rule").(*schema.Set); ors.Len() > 0 {
-		err := deleteFirewallRules(d, meta, rules, ors)
+		err := deleteRules(firewallRuleHandler(d, meta), rules, ors)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -448,75 +381,45 @@ func resourceCloudStackFirewallDelete(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
-func deleteFirewallRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, ors *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(ors.Len())
-
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range ors.List() {
-		// Put a sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Delete a single rule
-			err := deleteFirewallRule(d, meta, rule)
-
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
-			}
+func resourceCloudStackFirewallImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
 
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			}
+	// Get all the rules from the running environment
+	p := cs.Firewall.NewListFirewallRulesParams()
+	p.SetIpaddressid(d.Id())
+	p.SetListall(true)
 
-			<-sem
-		}(rule.(map[string]interface{}))
+	l, err := cs.Firewall.ListFirewallRules(p)
+	if err != nil {
+		return nil, err
 	}
 
-	wg.Wait()
-
-	return errs.ErrorOrNil()
-}
-
-func deleteFirewallRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
+	// Rebuild the rules, grouping all rules that only differ in their ports
+	rules := newImportedRules()
+	for _, r := range l.FirewallRules {
+		cidrs, key := cidrSet(r.Cidrlist)
 
-	for k, id := range uuids {
-		// We don't care about the count here, so just continue
-		if k == "%" {
-			continue
+		rule := map[string]interface{}{
+			"cidr_list": cidrs,
+			"protocol":  r.Protocol,
 		}
 
-		// Create the parameter struct
-		p := cs.Firewall.NewDeleteFirewallRuleParams(id.(string))
-
-		// Delete the rule
-		if _, err := cs.Firewall.DeleteFirewallRule(p); err != nil {
-
-			// This is a very poor way to be told the ID does no longer exist :(
-			if strings.Contains(err.Error(), fmt.Sprintf(
-				"Invalid parameter id value=%s due to incorrect long value format, "+
-					"or entity does not exist", id.(string))) {
-				delete(uuids, k)
-				continue
-			}
-
-			return err
+		if r.Protocol == "icmp" {
+			rule["icmp_type"] = r.Icmptype
+			rule["icmp_code"] = r.Icmpcode
+			rules.add(r.Id, rule, "", r.Id)
+			continue
 		}
 
-		// Delete the UUID of this rule
-		delete(uuids, k)
-		rule["uuids"] = uuids
+		rules.add(r.Protocol+"/"+key, rule, portRange(r.Startport, r.Endport), r.Id)
 	}
 
-	return nil
+	d.Set("ip_address_id", d.Id())
+	d.Set("managed", false)
+	d.Set("parallelism", 2)
+	d.Set("rule", rules.list())
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func verifyFirewallParams(d *schema.ResourceData) error {
@@ -550,10 +453,8 @@ func verifyFirewallRuleParams(d *schema.ResourceData, rule map[string]interface{
 	} else {
 		if ports, ok := rule["ports"].(*schema.Set); ok {
 			for _, port := range ports.List() {
-				m := splitPorts.FindStringSubmatch(port.(string))
-				if m == nil {
-					return fmt.Errorf(
-						"%q is not a valid port value. Valid options are '80' or '80-90'", port.(string))
+				if _, _, err := splitPortRange(port.(string)); err != nil {
+					return err
 				}
 			}
 		} else {
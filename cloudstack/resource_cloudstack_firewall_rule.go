@@ -0,0 +1,197 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func resourceCloudStackFirewallRule() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceCloudStackFirewallRuleCreate,
+		Read:   resourceCloudStackFirewallRuleRead,
+		Delete: resourceCloudStackFirewallRuleDelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
+
+		CustomizeDiff: verifyRuleDiff,
+
+		Schema: map[string]*schema.Schema{
+			"ip_address_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"cidr_list": {
+				Type:     schema.TypeSet,
+				Required: true,
+				ForceNew: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCIDR,
+				},
+				Set: schema.HashString,
+			},
+
+			"protocol": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateProtocol(false, "tcp", "udp", "icmp"),
+			},
+
+			"icmp_type": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"icmp_code": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"port": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePorts,
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceCloudStackFirewallRuleCreate(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Create a new parameter struct
+	p := cs.Firewall.NewCreateFirewallRuleParams(
+		d.Get("ip_address_id").(string),
+		d.Get("protocol").(string),
+	)
+
+	// Set the CIDR list
+	var cidrList []string
+	for _, cidr := range d.Get("cidr_list").(*schema.Set).List() {
+		cidrList = append(cidrList, cidr.(string))
+	}
+	p.SetCidrlist(cidrList)
+
+	// If the protocol is ICMP set the needed ICMP parameters
+	if d.Get("protocol").(string) == "icmp" {
+		p.SetIcmptype(d.Get("icmp_type").(int))
+		p.SetIcmpcode(d.Get("icmp_code").(int))
+	}
+
+	// If a port is configured, set the port range
+	if port, ok := d.GetOk("port"); ok {
+		startPort, endPort, err := splitPortRange(port.(string))
+		if err != nil {
+			return err
+		}
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
+	}
+
+	r, err := cs.Firewall.CreateFirewallRule(p)
+	if err != nil {
+		return fmt.Errorf("Error creating firewall rule: %s", err)
+	}
+
+	d.SetId(r.Id)
+
+	return resourceCloudStackFirewallRuleRead(d, meta)
+}
+
+func resourceCloudStackFirewallRuleRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Get the firewall rule details
+	r, _, err := cs.Firewall.GetFirewallRuleByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		if isNotFound(err) {
+			log.Printf("[DEBUG] Firewall rule %s does no longer exist", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		return err
+	}
+
+	d.Set("ip_address_id", r.Ipaddressid)
+	d.Set("cidr_list", strings.Split(r.Cidrlist, ","))
+	d.Set("protocol", r.Protocol)
+
+	if r.Protocol == "icmp" {
+		d.Set("icmp_type", r.Icmptype)
+		d.Set("icmp_code", r.Icmpcode)
+	} else {
+		d.Set("icmp_type", -1)
+		d.Set("icmp_code", -1)
+	}
+
+	if r.Startport > 0 {
+		d.Set("port", portRange(r.Startport, r.Endport))
+	}
+
+	return nil
+}
+
+func resourceCloudStackFirewallRuleDelete(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Create a new parameter struct
+	p := cs.Firewall.NewDeleteFirewallRuleParams(d.Id())
+
+	// Delete the firewall rule
+	_, err := cs.Firewall.DeleteFirewallRule(p)
+	if err != nil {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("Error deleting firewall rule %s: %s", d.Id(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func TestAccCloudStackFirewallRule_basic(t *testing.T) {
+	var rule cloudstack.FirewallRule
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewallRule_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRuleExists(
+						"cloudstack_firewall_rule.foo", &rule),
+					testAccCheckCloudStackFirewallRuleAttributes(&rule),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall_rule.foo", "port", "1000-2000"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall_rule.bar", "icmp_type", "8"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudStackFirewallRule_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewallRule_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_firewall_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackFirewallRule_simulator(t *testing.T) {
+	var rule cloudstack.FirewallRule
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewallRule_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRuleExists(
+						"cloudstack_firewall_rule.foo", &rule),
+					testAccCheckCloudStackFirewallRuleAttributes(&rule),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall_rule.foo", "port", "1000-2000"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall_rule.bar", "icmp_type", "8"),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_firewall_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+
+			{
+				ResourceName:      "cloudstack_firewall_rule.bar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+
+			{
+				Config: testAccCloudStackFirewallRule_unmanaged,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRuleExists(
+						"cloudstack_firewall_rule.foo", &rule),
+					testAccCheckCloudStackFirewallRulesExist("cloudstack_firewall.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckCloudStackFirewallRuleExists(
+	n string, rule *cloudstack.FirewallRule) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No firewall rule ID is set")
+		}
+
+		cs := testAccProvider.Meta().(*Client)
+		r, _, err := cs.Firewall.GetFirewallRuleByID(rs.Primary.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if r.Id != rs.Primary.ID {
+			return fmt.Errorf("Firewall rule not found")
+		}
+
+		*rule = *r
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackFirewallRuleAttributes(
+	rule *cloudstack.FirewallRule) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if rule.Protocol != "tcp" {
+			return fmt.Errorf("Bad protocol: %s", rule.Protocol)
+		}
+
+		if rule.Startport != 1000 || rule.Endport != 2000 {
+			return fmt.Errorf("Bad port range: %d-%d", rule.Startport, rule.Endport)
+		}
+
+		if rule.Cidrlist != "10.0.0.0/24" {
+			return fmt.Errorf("Bad CIDR list: %s", rule.Cidrlist)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackFirewallRuleDestroy(s *terraform.State) error {
+	cs := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cloudstack_firewall_rule" {
+			continue
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No firewall rule ID is set")
+		}
+
+		_, _, err := cs.Firewall.GetFirewallRuleByID(rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Firewall rule %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+const testAccCloudStackFirewallRule_basic = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  source_nat_ip = true
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_firewall_rule" "foo" {
+  ip_address_id = "${cloudstack_network.foo.source_nat_ip_id}"
+  cidr_list = ["10.0.0.0/24"]
+  protocol = "tcp"
+  port = "1000-2000"
+}
+
+resource "cloudstack_firewall_rule" "bar" {
+  ip_address_id = "${cloudstack_network.foo.source_nat_ip_id}"
+  cidr_list = ["10.0.0.0/24"]
+  protocol = "icmp"
+  icmp_type = 8
+  icmp_code = 0
+}`
+
+const testAccCloudStackFirewallRule_unmanaged = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  source_nat_ip = true
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_firewall_rule" "foo" {
+  ip_address_id = "${cloudstack_network.foo.source_nat_ip_id}"
+  cidr_list = ["10.0.0.0/24"]
+  protocol = "tcp"
+  port = "1000-2000"
+}
+
+resource "cloudstack_firewall" "foo" {
+  ip_address_id = "${cloudstack_network.foo.source_nat_ip_id}"
+  managed = false
+
+  rule {
+    cidr_list = ["10.0.0.0/24"]
+    protocol = "tcp"
+    ports = ["80", "443"]
+  }
+}`
@@ -21,12 +21,12 @@ package cloudstack
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func TestAccCloudStackFirewall_basic(t *testing.T) {
@@ -126,6 +126,127 @@ func TestAccCloudStackFirewall_update(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackFirewall_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewall_update,
+			},
+
+			{
+				ResourceName:      "cloudstack_firewall.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackFirewall_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewall_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRulesExist("cloudstack_firewall.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.3782201428.ports.1209010669", "1000-2000"),
+				),
+			},
+
+			{
+				Config: testAccCloudStackFirewall_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRulesExist("cloudstack_firewall.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.#", "3"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.4160426500.ports.3638101695", "443"),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_firewall.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackFirewall_simulatorTransient(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.fail("createFirewallRule", 530, "Failed due to a concurrent operation, please try again")
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackFirewallDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackFirewall_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackFirewallRulesExist("cloudstack_firewall.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_firewall.foo", "rule.#", "2"),
+					testAccCheckSimulatorCount(sim, "createFirewallRule", 4),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackFirewall_simulatorInvalid(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testAccCloudStackFirewall_invalid, "10.0.0.0/24", "tcp", `ports = ["80-"]`),
+				ExpectError: regexp.MustCompile(`"80-" is not a valid port value`),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackFirewall_invalid, "10.0.0.0/24", "tpc", `ports = ["80"]`),
+				ExpectError: regexp.MustCompile(`"tpc" is not a valid protocol`),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackFirewall_invalid, "10.0.0.0/33", "tcp", `ports = ["80"]`),
+				ExpectError: regexp.MustCompile(`"10.0.0.0/33" is not a valid CIDR`),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackFirewall_invalid, "10.0.0.0/24", "tcp", ""),
+				ExpectError: regexp.MustCompile("Parameter ports is a required parameter"),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackFirewall_invalid, "10.0.0.0/24", "icmp", "icmp_type = -1\n    icmp_code = 3"),
+				ExpectError: regexp.MustCompile("Parameter icmp_code 3 requires a specific icmp_type"),
+			},
+		},
+	})
+
+	// All errors should be reported during plan, before anything is created
+	if n := sim.count("createNetwork"); n != 0 {
+		t.Fatalf("Expected no calls of createNetwork, got %d", n)
+	}
+}
+
 func testAccCheckCloudStackFirewallRulesExist(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -142,7 +263,7 @@ func testAccCheckCloudStackFirewallRulesExist(n string) resource.TestCheckFunc {
 				continue
 			}
 
-			cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+			cs := testAccProvider.Meta().(*Client)
 			_, count, err := cs.Firewall.GetFirewallRuleByID(id)
 
 			if err != nil {
@@ -159,7 +280,7 @@ func testAccCheckCloudStackFirewallRulesExist(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckCloudStackFirewallDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_firewall" {
@@ -240,3 +361,22 @@ resource "cloudstack_firewall" "foo" {
     ports = ["80", "443"]
   }
 }`
+
+const testAccCloudStackFirewall_invalid = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  source_nat_ip = true
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_firewall" "foo" {
+  ip_address_id = "${cloudstack_network.foo.source_nat_ip_id}"
+
+  rule {
+    cidr_list = ["%s"]
+    protocol = "%s"
+    %s
+  }
+}`
@@ -23,11 +23,17 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform/helper/customdiff"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -41,6 +47,19 @@ func resourceCloudStackInstance() *schema.Resource {
 			State: resourceCloudStackInstanceImport,
 		},
 
+		CustomizeDiff: customdiff.All(
+			customdiff.ForceNewIfChange("root_disk_size", rootDiskShrinks),
+			customdiff.ForceNewIf("template", templateForcesNew),
+			verifyInstanceStopDiff,
+			verifyInstanceISODiff,
+		),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -59,31 +78,152 @@ func resourceCloudStackInstance() *schema.Resource {
 				Required: true,
 			},
 
+			"cpu_number": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
+			"cpu_speed": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
+			"memory": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
 			"network_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"network"},
+			},
+
+			"ip_address": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"network"},
+			},
+
+			"network": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"network_id", "ip_address"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"network_id": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"ip_address": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"ipv6_address": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"mac_address": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"default": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+
+			"nic": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"network_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ipv6_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"mac_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"default": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"template": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Computed: true,
-				ForceNew: true,
 			},
 
-			"ip_address": {
+			"iso": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"hypervisor": {
 				Type:     schema.TypeString,
 				Optional: true,
 				Computed: true,
 				ForceNew: true,
 			},
 
-			"template": {
+			"root_disk_offering": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 				ForceNew: true,
 			},
 
+			"reinstall_on_template_change": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
 			"root_disk_size": {
 				Type:     schema.TypeInt,
 				Optional: true,
 				Computed: true,
-				ForceNew: true,
 			},
 
 			"group": {
@@ -133,9 +273,25 @@ func resourceCloudStackInstance() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
 			"zone": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 
@@ -144,6 +300,38 @@ func resourceCloudStackInstance() *schema.Resource {
 				Optional: true,
 			},
 
+			"private_key": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+
+			"pgp_key": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"password_reset_trigger": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"password": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+
+			"encrypted_password": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"key_fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
 			"start_vm": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -151,6 +339,25 @@ func resourceCloudStackInstance() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"Running", "Stopped"}, false),
+			},
+
+			"allow_stopping_for_update": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"force_stop": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
 			"user_data": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -177,7 +384,7 @@ func resourceCloudStackInstance() *schema.Resource {
 }
 
 func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 
 	// Retrieve the service_offering ID
 	serviceofferingid, e := retrieveID(cs, "service_offering", d.Get("service_offering").(string))
@@ -186,7 +393,7 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 	}
 
 	// Retrieve the zone ID
-	zoneid, e := retrieveID(cs, "zone", d.Get("zone").(string))
+	zoneid, e := retrieveZoneID(cs, d)
 	if e != nil {
 		return e.Error()
 	}
@@ -197,47 +404,100 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
-	// Retrieve the template ID
-	templateid, e := retrieveTemplateID(cs, zone.Id, d.Get("template").(string))
-	if e != nil {
-		return e.Error()
+	// Retrieve the ID of the ISO to attach or to deploy from, if any
+	var isoid string
+	if iso, ok := d.GetOk("iso"); ok {
+		isoid, e = retrieveISOID(cs, zone.Id, iso.(string))
+		if e != nil {
+			return e.Error()
+		}
+	}
+
+	// Retrieve the template ID, or use the ISO when there is no template
+	templateid := isoid
+	if template, ok := d.GetOk("template"); ok {
+		templateid, e = retrieveTemplateID(cs, zone.Id, template.(string))
+		if e != nil {
+			return e.Error()
+		}
+	}
+
+	if templateid == "" {
+		return fmt.Errorf(
+			"Either a template or an ISO is required to deploy instance %s", d.Get("name").(string))
 	}
 
 	// Create a new parameter struct
-	p := cs.VirtualMachine.NewDeployVirtualMachineParams(serviceofferingid, templateid, zone.Id)
-	p.SetStartvm(d.Get("start_vm").(bool))
+	p := newDeployParams(serviceofferingid, templateid, zone.Id)
+
+	// When deploying from an ISO, the root disk is created using a disk offering
+	if v, ok := d.GetOk("root_disk_offering"); ok {
+		if templateid != isoid {
+			return fmt.Errorf("A root_disk_offering can only be used when deploying from an ISO")
+		}
+
+		diskofferingid, e := retrieveID(cs, "disk_offering", v.(string))
+		if e != nil {
+			return e.Error()
+		}
+		p.SetParam("diskofferingid", diskofferingid)
+	}
+
+	// If there is a hypervisor supplied, add it to the parameter struct
+	if hypervisor, ok := d.GetOk("hypervisor"); ok {
+		p.SetParam("hypervisor", hypervisor.(string))
+	}
+
+	// Deploy the instance in the configured state, if any
+	startvm := d.Get("start_vm").(bool)
+	if state, ok := d.GetOk("state"); ok {
+		startvm = state.(string) == "Running"
+	}
+	p.SetParam("startvm", startvm)
 
 	// Set the name
 	name, hasName := d.GetOk("name")
 	if hasName {
-		p.SetName(name.(string))
+		p.SetParam("name", name.(string))
 	}
 
 	// Set the display name
 	if displayname, ok := d.GetOk("display_name"); ok {
-		p.SetDisplayname(displayname.(string))
+		p.SetParam("displayname", displayname.(string))
 	} else if hasName {
-		p.SetDisplayname(name.(string))
+		p.SetParam("displayname", name.(string))
 	}
 
 	// If there is a root_disk_size supplied, add it to the parameter struct
 	if rootdisksize, ok := d.GetOk("root_disk_size"); ok {
-		p.SetRootdisksize(int64(rootdisksize.(int)))
+		p.SetParam("rootdisksize", int64(rootdisksize.(int)))
+	}
+
+	// Set the CPU and memory used with custom service offerings
+	for key, detail := range instanceDetails {
+		if v, ok := d.GetOk(key); ok {
+			p.SetParam("details[0]."+detail, strconv.Itoa(v.(int)))
+		}
 	}
 
-	if zone.Networktype == "Advanced" {
+	if networks := d.Get("network").([]interface{}); len(networks) > 0 {
+		// Set the networks together with their addresses
+		if err := p.SetNetworks(networks); err != nil {
+			return err
+		}
+	} else if zone.Networktype == "Advanced" {
 		// Set the default network ID
-		p.SetNetworkids([]string{d.Get("network_id").(string)})
+		p.SetList("networkids", []string{d.Get("network_id").(string)})
 	}
 
 	// If there is a ipaddres supplied, add it to the parameter struct
 	if ipaddress, ok := d.GetOk("ip_address"); ok {
-		p.SetIpaddress(ipaddress.(string))
+		p.SetParam("ipaddress", ipaddress.(string))
 	}
 
 	// If there is a group supplied, add it to the parameter struct
 	if group, ok := d.GetOk("group"); ok {
-		p.SetGroup(group.(string))
+		p.SetParam("group", group.(string))
 	}
 
 	// If there are affinity group IDs supplied, add them to the parameter struct
@@ -246,7 +506,7 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		for _, group := range agIDs.List() {
 			groups = append(groups, group.(string))
 		}
-		p.SetAffinitygroupids(groups)
+		p.SetList("affinitygroupids", groups)
 	}
 
 	// If there are affinity group names supplied, add them to the parameter struct
@@ -255,7 +515,7 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		for _, group := range agNames.List() {
 			groups = append(groups, group.(string))
 		}
-		p.SetAffinitygroupnames(groups)
+		p.SetList("affinitygroupnames", groups)
 	}
 
 	// If there are security group IDs supplied, add them to the parameter struct
@@ -264,7 +524,7 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		for _, group := range sgIDs.List() {
 			groups = append(groups, group.(string))
 		}
-		p.SetSecuritygroupids(groups)
+		p.SetList("securitygroupids", groups)
 	}
 
 	// If there are security group names supplied, add them to the parameter struct
@@ -273,7 +533,7 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		for _, group := range sgNames.List() {
 			groups = append(groups, group.(string))
 		}
-		p.SetSecuritygroupnames(groups)
+		p.SetList("securitygroupnames", groups)
 	}
 
 	// If there is a project supplied, we retrieve and set the project id
@@ -281,9 +541,14 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// If a keypair is supplied, add it to the parameter struct
 	if keypair, ok := d.GetOk("keypair"); ok {
-		p.SetKeypair(keypair.(string))
+		p.SetParam("keypair", keypair.(string))
 	}
 
 	if userData, ok := d.GetOk("user_data"); ok {
@@ -291,41 +556,69 @@ func resourceCloudStackInstanceCreate(d *schema.ResourceData, meta interface{})
 		if err != nil {
 			return err
 		}
-		p.SetUserdata(ud)
+		p.SetParam("userdata", ud)
 	}
 
 	// Create the new instance
-	r, err := cs.VirtualMachine.DeployVirtualMachine(p)
+	r, err := deployVirtualMachine(cs, p, resourceTimeout(cs, d.Timeout(schema.TimeoutCreate)))
 	if err != nil {
 		return fmt.Errorf("Error creating the new instance %s: %s", name, err)
 	}
 
 	d.SetId(r.Id)
 
+	// An instance deployed from an ISO reports the ISO as its template, so use
+	// the configured ISO to read back the template the same way as the ISO
+	if templateid == isoid {
+		d.Set("template", d.Get("iso").(string))
+	}
+
 	// Set tags if necessary
 	if err = setTags(cs, d, "userVm"); err != nil {
 		return fmt.Errorf("Error setting tags on the new instance %s: %s", name, err)
 	}
 
+	// Attach the ISO if the instance is deployed from a template
+	if isoid != "" && templateid != isoid {
+		p := cs.ISO.NewAttachIsoParams(isoid, r.Id)
+		if _, err := cs.ISO.AttachIso(p); err != nil {
+			return fmt.Errorf("Error attaching ISO %s to instance %s: %s", d.Get("iso").(string), name, err)
+		}
+	}
+
+	// Retrieve the password when it's encrypted using the key pair
+	password := r.Password
+	if privateKey, ok := d.GetOk("private_key"); ok && r.Passwordenabled {
+		password, err = getVMPassword(cs, r.Id, privateKey.(string))
+		if err != nil {
+			return fmt.Errorf("Error retrieving the password of instance %s: %s", name, err)
+		}
+	}
+
+	if err := setPassword(d, password); err != nil {
+		return fmt.Errorf("Error setting the password of instance %s: %s", name, err)
+	}
+
 	// Set the connection info for any configured provisioners
 	d.SetConnInfo(map[string]string{
 		"host":     r.Nic[0].Ipaddress,
-		"password": r.Password,
+		"password": password,
 	})
 
 	return resourceCloudStackInstanceRead(d, meta)
 }
 
 func resourceCloudStackInstanceRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the virtual machine details
-	vm, count, err := cs.VirtualMachine.GetVirtualMachineByID(
+	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Instance %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
 			return nil
@@ -338,6 +631,21 @@ func resourceCloudStackInstanceRead(d *schema.ResourceData, meta interface{}) er
 	d.Set("name", vm.Name)
 	d.Set("display_name", vm.Displayname)
 	d.Set("group", vm.Group)
+	d.Set("state", vm.State)
+	d.Set("hypervisor", vm.Hypervisor)
+
+	// The CPU and memory are only read back when they are configured, so the
+	// sizes of the current offering are never passed as details when changing
+	// to a custom offering
+	for key, value := range map[string]int{
+		"cpu_number": vm.Cpunumber,
+		"cpu_speed":  vm.Cpuspeed,
+		"memory":     vm.Memory,
+	} {
+		if _, ok := d.GetOk(key); ok {
+			d.Set(key, value)
+		}
+	}
 
 	// In some rare cases (when destroying a machine failes) it can happen that
 	// an instance does not have any attached NIC anymore.
@@ -346,22 +654,24 @@ func resourceCloudStackInstanceRead(d *schema.ResourceData, meta interface{}) er
 		d.Set("ip_address", vm.Nic[0].Ipaddress)
 	}
 
-	// Create a new param struct.
-	p := cs.Volume.NewListVolumesParams()
-	p.SetType("ROOT")
-	p.SetVirtualmachineid(d.Id())
+	// The networks only contain the NICs of the configured networks, so NICs
+	// added using cloudstack_nic resources don't force a new instance. All NICs
+	// are exported using the nic attribute.
+	nics := sortNics(d, vm.Nic)
+	d.Set("network", flattenNics(configuredNics(d, nics)))
+	d.Set("nic", flattenNics(nics))
 
 	// Get the root disk of the instance.
-	l, err := cs.Volume.ListVolumes(p)
+	root, err := getRootVolume(cs, d)
 	if err != nil {
 		return err
 	}
 
 	// If we found the root disk, then update its size.
-	if len(l.Volumes) != 1 {
+	if root == nil {
 		log.Printf("[DEBUG] Failed to find root disk of instance: %s", vm.Name)
 	} else {
-		d.Set("root_disk_size", l.Volumes[0].Size>>30) // B to GiB
+		d.Set("root_disk_size", root.Size>>30) // B to GiB
 	}
 
 	if _, ok := d.GetOk("affinity_group_ids"); ok {
@@ -396,22 +706,20 @@ func resourceCloudStackInstanceRead(d *schema.ResourceData, meta interface{}) er
 		d.Set("security_group_names", groups)
 	}
 
-	tags := make(map[string]interface{})
-	for _, tag := range vm.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, vm.Tags)
 
 	setValueOrID(d, "service_offering", vm.Serviceofferingname, vm.Serviceofferingid)
 	setValueOrID(d, "template", vm.Templatename, vm.Templateid)
+	setValueOrID(d, "iso", vm.Isoname, vm.Isoid)
 	setValueOrID(d, "project", vm.Project, vm.Projectid)
+	readAccount(d, vm.Account, vm.Domain, vm.Domainid, vm.Projectid)
 	setValueOrID(d, "zone", vm.Zonename, vm.Zoneid)
 
 	return nil
 }
 
 func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 	d.Partial(true)
 
 	name := d.Get("name").(string)
@@ -456,15 +764,85 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 		d.SetPartial("group")
 	}
 
-	// Attributes that require reboot to update
-	if d.HasChange("name") || d.HasChange("service_offering") || d.HasChange("affinity_group_ids") ||
-		d.HasChange("affinity_group_names") || d.HasChange("keypair") || d.HasChange("user_data") {
-		// Before we can actually make these changes, the virtual machine must be stopped
-		_, err := cs.VirtualMachine.StopVirtualMachine(
-			cs.VirtualMachine.NewStopVirtualMachineParams(d.Id()))
+	// Check if the ISO has changed and if so, detach the old ISO and attach the
+	// new ISO. ISOs can be attached and detached while the instance is running,
+	// so this doesn't require the instance to be stopped.
+	if d.HasChange("iso") {
+		log.Printf("[DEBUG] ISO changed for %s, starting update", name)
+
+		if err := updateInstanceISO(cs, d); err != nil {
+			return err
+		}
+		d.SetPartial("iso")
+	}
+
+	// Check if the service offering, CPU or memory have changed and if so, try
+	// to scale the instance without stopping it
+	scale := d.HasChange("service_offering") || d.HasChange("cpu_number") ||
+		d.HasChange("cpu_speed") || d.HasChange("memory")
+	if scale {
+		live, err := scaleInstance(cs, d)
 		if err != nil {
-			return fmt.Errorf(
-				"Error stopping instance %s before making changes: %s", name, err)
+			return fmt.Errorf("Error scaling instance %s: %s", name, err)
+		}
+
+		if live {
+			d.SetPartial("service_offering")
+			d.SetPartial("cpu_number")
+			d.SetPartial("cpu_speed")
+			d.SetPartial("memory")
+			scale = false
+		}
+	}
+
+	// Attributes that require reboot to update. All of these are changed while
+	// the instance is stopped once, so the instance is only rebooted once.
+	stop := scale
+	for _, key := range instanceStopAttributes {
+		if d.HasChange(key) {
+			stop = true
+		}
+	}
+
+	// Reinstalling the instance or resizing its root disk reboots a running
+	// instance, so when the instance is stopped anyway these changes are made
+	// while it is stopped
+	rootDisk := d.HasChange("template") || d.HasChange("root_disk_size")
+	if rootDisk && d.HasChange("state") && d.Get("state").(string) == "Stopped" {
+		stop = true
+	}
+
+	if rootDisk && !stop {
+		if err := updateRootDisk(cs, d); err != nil {
+			return err
+		}
+	}
+
+	stopped := false
+	if stop {
+		vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
+			d.Id(),
+			cloudstack.WithProject(getProject(cs, d)),
+			withAccount(cs, d),
+		)
+		if err != nil {
+			return fmt.Errorf("Error retrieving instance %s: %s", name, err)
+		}
+
+		// Before we can actually make these changes, the virtual machine must be
+		// stopped, unless it is stopped already
+		if vm.State != "Stopped" {
+			if err := stopInstance(cs, d); err != nil {
+				return fmt.Errorf(
+					"Error stopping instance %s before making changes: %s", name, err)
+			}
+			stopped = true
+		}
+
+		if rootDisk {
+			if err := updateRootDisk(cs, d); err != nil {
+				return err
+			}
 		}
 
 		// Check if the name has changed and if so, update the name
@@ -487,18 +865,22 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 			d.SetPartial("name")
 		}
 
-		// Check if the service offering is changed and if so, update the offering
-		if d.HasChange("service_offering") {
+		// Check if the service offering could not be scaled live and if so,
+		// update the offering
+		if scale {
 			log.Printf("[DEBUG] Service offering changed for %s, starting update", name)
 
-			// Retrieve the service_offering ID
-			serviceofferingid, e := retrieveID(cs, "service_offering", d.Get("service_offering").(string))
-			if e != nil {
-				return e.Error()
+			// Retrieve the service offering and its details
+			offering, details, err := instanceOffering(cs, d)
+			if err != nil {
+				return err
 			}
 
 			// Create a new parameter struct
-			p := cs.VirtualMachine.NewChangeServiceForVirtualMachineParams(d.Id(), serviceofferingid)
+			p := cs.VirtualMachine.NewChangeServiceForVirtualMachineParams(d.Id(), offering.Id)
+			if len(details) > 0 {
+				p.SetDetails(details)
+			}
 
 			// Change the service offering
 			_, err = cs.VirtualMachine.ChangeServiceForVirtualMachine(p)
@@ -507,6 +889,9 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 					"Error changing the service offering for instance %s: %s", name, err)
 			}
 			d.SetPartial("service_offering")
+			d.SetPartial("cpu_number")
+			d.SetPartial("cpu_speed")
+			d.SetPartial("memory")
 		}
 
 		// Check if the affinity group IDs have changed and if so, update the IDs
@@ -561,6 +946,11 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 
 			p := cs.SSH.NewResetSSHKeyForVirtualMachineParams(d.Id(), d.Get("keypair").(string))
 
+			// The keypair belongs to the account of the instance
+			if err := setAccount(p, cs, d); err != nil {
+				return err
+			}
+
 			// Change the ssh keypair
 			_, err = cs.SSH.ResetSSHKeyForVirtualMachine(p)
 			if err != nil {
@@ -589,13 +979,85 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 			d.SetPartial("user_data")
 		}
 
-		// Start the virtual machine again
-		_, err = cs.VirtualMachine.StartVirtualMachine(
-			cs.VirtualMachine.NewStartVirtualMachineParams(d.Id()))
-		if err != nil {
+		// Check if the password reset trigger has changed and if so, reset the password
+		if d.HasChange("password_reset_trigger") {
+			log.Printf("[DEBUG] Password reset triggered for %s, starting reset", name)
+
+			p := cs.VirtualMachine.NewResetPasswordForVirtualMachineParams(d.Id())
+			r, err := cs.VirtualMachine.ResetPasswordForVirtualMachine(p)
+			if err != nil {
+				return fmt.Errorf(
+					"Error resetting the password for instance %s: %s", name, err)
+			}
+
+			// Retrieve the password when it's encrypted using the key pair
+			password := r.Password
+			if privateKey, ok := d.GetOk("private_key"); ok {
+				password, err = getVMPassword(cs, d.Id(), privateKey.(string))
+				if err != nil {
+					return fmt.Errorf(
+						"Error retrieving the password of instance %s: %s", name, err)
+				}
+			}
+
+			if err := setPassword(d, password); err != nil {
+				return fmt.Errorf(
+					"Error setting the password of instance %s: %s", name, err)
+			}
+			d.SetPartial("password_reset_trigger")
+			d.SetPartial("pgp_key")
+			d.SetPartial("password")
+			d.SetPartial("encrypted_password")
+			d.SetPartial("key_fingerprint")
+		}
+	}
+
+	// Start the virtual machine again after making changes, unless it should be
+	// stopped, and start or stop it if the state has changed
+	state := d.Get("state").(string)
+	switch {
+	case stopped && state != "Stopped":
+		if err := startInstance(cs, d); err != nil {
+			return fmt.Errorf(
+				"Error starting instance %s after making changes: %s", name, err)
+		}
+	case d.HasChange("state") && state == "Running":
+		log.Printf("[DEBUG] Starting instance %s", name)
+		if err := startInstance(cs, d); err != nil {
+			return fmt.Errorf("Error starting instance %s: %s", name, err)
+		}
+	case d.HasChange("state") && state == "Stopped" && !stopped:
+		log.Printf("[DEBUG] Stopping instance %s", name)
+		if err := stopInstance(cs, d); err != nil {
+			return fmt.Errorf("Error stopping instance %s: %s", name, err)
+		}
+	}
+	d.SetPartial("state")
+
+	// Check if the PGP key has changed and if so, store the current password
+	// encrypted using the new key (or unencrypted when the key is removed)
+	if d.HasChange("pgp_key") && !d.HasChange("password_reset_trigger") {
+		password := d.Get("password").(string)
+		if privateKey, ok := d.GetOk("private_key"); ok && password == "" {
+			var err error
+			password, err = getVMPassword(cs, d.Id(), privateKey.(string))
+			if err != nil {
+				return fmt.Errorf(
+					"Error retrieving the password of instance %s: %s", name, err)
+			}
+		}
+
+		// When the password is not known, the encrypted password and fingerprint
+		// of the old key are cleared, as the password cannot be encrypted using
+		// the new key
+		if err := setPassword(d, password); err != nil {
 			return fmt.Errorf(
-				"Error starting instance %s after making changes", name)
+				"Error setting the password of instance %s: %s", name, err)
 		}
+		d.SetPartial("pgp_key")
+		d.SetPartial("password")
+		d.SetPartial("encrypted_password")
+		d.SetPartial("key_fingerprint")
 	}
 
 	// Check is the tags have changed and if so, update the tags
@@ -612,7 +1074,7 @@ func resourceCloudStackInstanceUpdate(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackInstanceDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.VirtualMachine.NewDestroyVirtualMachineParams(d.Id())
@@ -623,10 +1085,8 @@ func resourceCloudStackInstanceDelete(d *schema.ResourceData, meta interface{})
 
 	log.Printf("[INFO] Destroying instance: %s", d.Get("name").(string))
 	if _, err := cs.VirtualMachine.DestroyVirtualMachine(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -639,7 +1099,610 @@ func resourceCloudStackInstanceImport(d *schema.ResourceData, meta interface{})
 	// We set start_vm to true as that matches the default and we assume that
 	// when you need to import an instance it means it is already running.
 	d.Set("start_vm", true)
-	return importStatePassthrough(d, meta)
+	d.Set("force_stop", false)
+	d.Set("allow_stopping_for_update", false)
+	d.Set("reinstall_on_template_change", false)
+
+	// The password of an imported instance is not known
+	d.Set("password", "")
+	d.Set("encrypted_password", "")
+	d.Set("key_fingerprint", "")
+
+	results, err := importStatePassthrough(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// The CPU and memory are only read back when they are configured, so set
+	// the custom CPU and memory of the imported instance
+	cs := meta.(*Client)
+	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, detail := range instanceDetails {
+		if v, err := strconv.Atoi(vm.Details[detail]); err == nil && v > 0 {
+			d.Set(key, v)
+		}
+	}
+
+	return results, nil
+}
+
+// rootDiskShrinks returns true if the root disk would shrink. The root disk can
+// only grow, so shrinking it requires a new instance.
+func rootDiskShrinks(old, new, meta interface{}) bool {
+	return new.(int) < old.(int)
+}
+
+// templateForcesNew returns true if a changed template requires a new instance,
+// as the template is only changed in place when explicitly allowed.
+func templateForcesNew(d *schema.ResourceDiff, meta interface{}) bool {
+	return !d.Get("reinstall_on_template_change").(bool)
+}
+
+// instanceStopAttributes are the attributes that can only be changed while the
+// instance is stopped.
+var instanceStopAttributes = []string{
+	"name",
+	"affinity_group_ids",
+	"affinity_group_names",
+	"keypair",
+	"user_data",
+	"password_reset_trigger",
+}
+
+// instanceReplaced returns true if any of the changes forces a new instance.
+func instanceReplaced(d *schema.ResourceDiff, meta interface{}) bool {
+	for key, s := range resourceCloudStackInstance().Schema {
+		if s.ForceNew && d.HasChange(key) {
+			return true
+		}
+	}
+	if d.HasChange("template") && templateForcesNew(d, meta) {
+		return true
+	}
+	old, new := d.GetChange("root_disk_size")
+	return rootDiskShrinks(old, new, meta)
+}
+
+// verifyInstanceISODiff fails the plan if the ISO of an instance that was
+// deployed from that ISO is changed or removed, as that would detach the boot
+// medium of the instance. CloudStack reports the ISO as the template of such
+// an instance.
+func verifyInstanceISODiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("iso") || d.HasChange("template") {
+		return nil
+	}
+
+	iso, _ := d.GetChange("iso")
+	if iso.(string) == "" || iso.(string) != d.Get("template").(string) {
+		return nil
+	}
+
+	if instanceReplaced(d, meta) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"Instance %s is deployed from ISO %s, so the ISO cannot be changed or removed "+
+			"without changing the template", d.Get("name").(string), iso.(string))
+}
+
+// verifyInstanceStopDiff fails the plan if the changes require the instance to
+// be stopped, while allow_stopping_for_update is not set.
+func verifyInstanceStopDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.Get("allow_stopping_for_update").(bool) {
+		return nil
+	}
+
+	// An instance that is or will be stopped doesn't need to be stopped
+	if old, new := d.GetChange("state"); old.(string) == "Stopped" || new.(string) == "Stopped" {
+		return nil
+	}
+
+	// A new instance is created when any of the changes forces a new instance
+	if instanceReplaced(d, meta) {
+		return nil
+	}
+
+	schemas := resourceCloudStackInstance().Schema
+	var changed []string
+	for _, key := range instanceStopAttributes {
+		// The new value of an attribute with a StateFunc is not yet passed
+		// through the StateFunc, so it is compared to the stored value here
+		if f := schemas[key].StateFunc; f != nil {
+			old, new := d.GetChange(key)
+			if new.(string) != "" {
+				new = f(new)
+			}
+			if old != new {
+				changed = append(changed, key)
+			}
+			continue
+		}
+
+		if d.HasChange(key) {
+			changed = append(changed, key)
+		}
+	}
+
+	// Changes of the service offering, CPU or memory only require the instance
+	// to be stopped when it cannot be scaled live
+	var scaled []string
+	for _, key := range []string{"service_offering", "cpu_number", "cpu_speed", "memory"} {
+		if d.HasChange(key) {
+			scaled = append(scaled, key)
+		}
+	}
+	if len(scaled) > 0 {
+		live := false
+		if d.NewValueKnown("service_offering") {
+			var err error
+			live, _, _, err = liveScaling(meta.(*Client), d.Id(), d)
+			if err != nil {
+				return err
+			}
+		}
+		if !live {
+			changed = append(changed, scaled...)
+		}
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf(
+			"Changing %s of instance %s requires stopping the instance, "+
+				"set allow_stopping_for_update to true to allow this",
+			strings.Join(changed, ", "), d.Get("name").(string))
+	}
+
+	return nil
+}
+
+// getRootVolume returns the root disk of the instance, or nil if the instance
+// does not have a root disk.
+func getRootVolume(cs *Client, d *schema.ResourceData) (*cloudstack.Volume, error) {
+	// Create a new param struct.
+	p := cs.Volume.NewListVolumesParams()
+	p.SetType("ROOT")
+	p.SetVirtualmachineid(d.Id())
+
+	// If there is a project supplied, we retrieve and set the project id
+	if err := setProjectid(p, cs, d); err != nil {
+		return nil, err
+	}
+
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return nil, err
+	}
+
+	l, err := cs.Volume.ListVolumes(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.Volumes) != 1 {
+		return nil, nil
+	}
+
+	return l.Volumes[0], nil
+}
+
+// updateInstanceISO detaches the old ISO and attaches the new ISO. When the new
+// ISO cannot be attached after the old ISO was detached, the instance is left
+// without an ISO, which is stored in the state.
+func updateInstanceISO(cs *Client, d *schema.ResourceData) error {
+	name := d.Get("name").(string)
+	o, n := d.GetChange("iso")
+
+	if o.(string) != "" {
+		p := cs.ISO.NewDetachIsoParams(d.Id())
+		if _, err := cs.ISO.DetachIso(p); err != nil {
+			return fmt.Errorf(
+				"Error detaching ISO %s from instance %s: %s", o.(string), name, err)
+		}
+	}
+
+	if n.(string) == "" {
+		return nil
+	}
+
+	err := attachInstanceISO(cs, d, n.(string))
+	if err != nil && o.(string) != "" {
+		d.Set("iso", "")
+		d.SetPartial("iso")
+	}
+
+	return err
+}
+
+// attachInstanceISO attaches the given ISO to the instance.
+func attachInstanceISO(cs *Client, d *schema.ResourceData, iso string) error {
+	// Retrieve the zone ID
+	zoneid, e := retrieveZoneID(cs, d)
+	if e != nil {
+		return e.Error()
+	}
+
+	// Retrieve the ISO ID
+	isoid, e := retrieveISOID(cs, zoneid, iso)
+	if e != nil {
+		return e.Error()
+	}
+
+	p := cs.ISO.NewAttachIsoParams(isoid, d.Id())
+	if _, err := cs.ISO.AttachIso(p); err != nil {
+		return fmt.Errorf(
+			"Error attaching ISO %s to instance %s: %s", iso, d.Get("name").(string), err)
+	}
+
+	return nil
+}
+
+// updateRootDisk reinstalls the instance when the template has changed, and
+// grows the root disk to the configured size when the size has changed or the
+// instance was reinstalled.
+func updateRootDisk(cs *Client, d *schema.ResourceData) error {
+	name := d.Get("name").(string)
+
+	if d.HasChange("template") {
+		log.Printf("[DEBUG] Template changed for %s, reinstalling the instance", name)
+
+		// Retrieve the zone ID
+		zoneid, e := retrieveZoneID(cs, d)
+		if e != nil {
+			return e.Error()
+		}
+
+		// Retrieve the template ID
+		templateid, e := retrieveTemplateID(cs, zoneid, d.Get("template").(string))
+		if e != nil {
+			return e.Error()
+		}
+
+		// Create a new parameter struct
+		p := cs.VirtualMachine.NewRestoreVirtualMachineParams(d.Id())
+		p.SetTemplateid(templateid)
+
+		// Reinstall the instance
+		_, err := cs.VirtualMachine.RestoreVirtualMachine(p)
+		if err != nil {
+			return fmt.Errorf(
+				"Error reinstalling instance %s using template %s: %s", name, d.Get("template").(string), err)
+		}
+		d.SetPartial("template")
+	}
+
+	if err := resizeRootVolume(cs, d); err != nil {
+		return fmt.Errorf("Error resizing the root disk of instance %s: %s", name, err)
+	}
+	d.SetPartial("root_disk_size")
+
+	return nil
+}
+
+// resizeRootVolume grows the root disk of the instance to the configured size,
+// if the root disk is smaller than the configured size.
+func resizeRootVolume(cs *Client, d *schema.ResourceData) error {
+	size, ok := d.GetOk("root_disk_size")
+	if !ok {
+		return nil
+	}
+
+	root, err := getRootVolume(cs, d)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return fmt.Errorf("Failed to find the root disk")
+	}
+
+	if int64(size.(int)) <= root.Size>>30 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Resizing root disk %s to %d GB", root.Id, size.(int))
+
+	p := cs.Volume.NewResizeVolumeParams(root.Id)
+	p.SetSize(int64(size.(int)))
+
+	_, err = cs.Volume.ResizeVolume(p)
+	return err
+}
+
+// instanceDetails maps the CPU and memory arguments to the details used to
+// pass them to CloudStack.
+var instanceDetails = map[string]string{
+	"cpu_number": "cpuNumber",
+	"cpu_speed":  "cpuSpeed",
+	"memory":     "memory",
+}
+
+// instanceOffering retrieves the configured service offering together with the
+// details to use with it. Only the CPU and memory that can be customized are
+// passed as details.
+func instanceOffering(cs *Client, d resourceGetter) (*cloudstack.ServiceOffering, map[string]string, error) {
+	serviceofferingid, e := retrieveID(cs, "service_offering", d.Get("service_offering").(string))
+	if e != nil {
+		return nil, nil, e.Error()
+	}
+
+	offering, _, err := cs.ServiceOffering.GetServiceOfferingByID(serviceofferingid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details := make(map[string]string)
+	if offering.Iscustomized {
+		for _, size := range []struct {
+			key   string
+			value int
+		}{
+			{"cpu_number", offering.Cpunumber},
+			{"cpu_speed", offering.Cpuspeed},
+			{"memory", offering.Memory},
+		} {
+			if size.value > 0 {
+				continue
+			}
+
+			v := d.Get(size.key).(int)
+			if v == 0 {
+				return nil, nil, fmt.Errorf(
+					"Service offering %s is a custom offering which requires %s to be set", offering.Name, size.key)
+			}
+			details[instanceDetails[size.key]] = strconv.Itoa(v)
+		}
+	}
+
+	return offering, details, nil
+}
+
+// liveScaling determines if the instance can be scaled to the configured
+// service offering, CPU and memory without stopping it. This is not possible
+// when the instance is not running, it is not dynamically scalable or when it
+// would be scaled down. It also returns the offering and details to scale to.
+func liveScaling(cs *Client, id string, d resourceGetter) (bool, *cloudstack.ServiceOffering, map[string]string, error) {
+	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
+		id,
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
+	)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	if vm.State != "Running" || !vm.Isdynamicallyscalable {
+		return false, nil, nil, nil
+	}
+
+	offering, details, err := instanceOffering(cs, d)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	// Determine the new size, using the details for the customized values
+	size := func(value int, key string) int {
+		if value == 0 {
+			return d.Get(key).(int)
+		}
+		return value
+	}
+	if size(offering.Cpunumber, "cpu_number") < vm.Cpunumber ||
+		size(offering.Cpuspeed, "cpu_speed") < vm.Cpuspeed ||
+		size(offering.Memory, "memory") < vm.Memory {
+		log.Printf("[DEBUG] Instance %s cannot be scaled down without stopping it", vm.Name)
+		return false, nil, nil, nil
+	}
+
+	return true, offering, details, nil
+}
+
+// scaleInstance changes the service offering, CPU and memory of a running
+// instance without stopping it. It returns false if the instance cannot be
+// scaled this way.
+func scaleInstance(cs *Client, d *schema.ResourceData) (bool, error) {
+	live, offering, details, err := liveScaling(cs, d.Id(), d)
+	if err != nil || !live {
+		return false, err
+	}
+
+	log.Printf("[DEBUG] Scaling instance %s without stopping it", d.Get("name").(string))
+
+	p := cs.VirtualMachine.NewScaleVirtualMachineParams(d.Id(), offering.Id)
+	if len(details) > 0 {
+		p.SetDetails(details)
+	}
+
+	if _, err := cs.VirtualMachine.ScaleVirtualMachine(p); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// startInstance starts the instance.
+func startInstance(cs *Client, d *schema.ResourceData) error {
+	p := cs.VirtualMachine.NewStartVirtualMachineParams(d.Id())
+	_, err := cs.VirtualMachine.StartVirtualMachine(p)
+	return err
+}
+
+// stopInstance stops the instance, forcing it to stop if force_stop is set.
+func stopInstance(cs *Client, d *schema.ResourceData) error {
+	p := cs.VirtualMachine.NewStopVirtualMachineParams(d.Id())
+	p.SetForced(d.Get("force_stop").(bool))
+	_, err := cs.VirtualMachine.StopVirtualMachine(p)
+	return err
+}
+
+// deployParams holds the parameters used to deploy a new instance. These are
+// used instead of the parameters of go-cloudstack, as those cannot pass the
+// addresses of multiple networks.
+type deployParams struct {
+	cloudstack.CustomServiceParams
+}
+
+func newDeployParams(serviceofferingid, templateid, zoneid string) *deployParams {
+	p := &deployParams{}
+	p.SetParam("serviceofferingid", serviceofferingid)
+	p.SetParam("templateid", templateid)
+	p.SetParam("zoneid", zoneid)
+	return p
+}
+
+func (p *deployParams) SetProjectid(v string) {
+	p.SetParam("projectid", v)
+}
+
+func (p *deployParams) SetAccount(v string) {
+	p.SetParam("account", v)
+}
+
+func (p *deployParams) SetDomainid(v string) {
+	p.SetParam("domainid", v)
+}
+
+// SetList sets a parameter holding a comma separated list of values.
+func (p *deployParams) SetList(param string, v []string) {
+	p.SetParam(param, strings.Join(v, ","))
+}
+
+// SetNetworks sets the iptonetworklist parameter using the configured network
+// blocks. CloudStack uses the first network as the default network, so the
+// network marked as default is moved to the front.
+func (p *deployParams) SetNetworks(networks []interface{}) error {
+	var list []map[string]interface{}
+	for _, n := range networks {
+		network := n.(map[string]interface{})
+		if network["default"].(bool) {
+			if len(list) > 0 && list[0]["default"].(bool) {
+				return fmt.Errorf("Only one network can be the default network")
+			}
+			list = append([]map[string]interface{}{network}, list...)
+			continue
+		}
+		list = append(list, network)
+	}
+
+	params := map[string]string{
+		"network_id":   "networkid",
+		"ip_address":   "ip",
+		"ipv6_address": "ipv6",
+		"mac_address":  "mac",
+	}
+
+	for i, network := range list {
+		for key, param := range params {
+			if v := network[key].(string); v != "" {
+				p.SetParam(fmt.Sprintf("iptonetworklist[%d].%s", i, param), v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// deployVirtualMachine deploys a new instance and waits until the instance is
+// deployed or the timeout is reached.
+func deployVirtualMachine(cs *Client, p *deployParams, timeout time.Duration) (*cloudstack.DeployVirtualMachineResponse, error) {
+	var r cloudstack.DeployVirtualMachineResponse
+	if err := cs.Custom.CustomRequest("deployVirtualMachine", &p.CustomServiceParams, &r); err != nil {
+		return nil, err
+	}
+
+	b, err := cs.GetAsyncJobResult(r.JobID, int64(timeout.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		VirtualMachine cloudstack.DeployVirtualMachineResponse `json:"virtualmachine"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+
+	return &result.VirtualMachine, nil
+}
+
+// sortNics returns the NICs of an instance ordered by their device ID. When
+// networks are configured, the NICs of those networks are returned first and
+// in the configured order, so moving the default network to the front when
+// deploying the instance does not cause a diff.
+func sortNics(d *schema.ResourceData, nics []cloudstack.Nic) []cloudstack.Nic {
+	sorted := make([]cloudstack.Nic, len(nics))
+	copy(sorted, nics)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := strconv.Atoi(sorted[i].Deviceid)
+		b, _ := strconv.Atoi(sorted[j].Deviceid)
+		return a < b
+	})
+
+	position := make(map[string]int)
+	for i, n := range d.Get("network").([]interface{}) {
+		position[n.(map[string]interface{})["network_id"].(string)] = i
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, ok := position[sorted[i].Networkid]
+		if !ok {
+			a = len(position)
+		}
+		b, ok := position[sorted[j].Networkid]
+		if !ok {
+			b = len(position)
+		}
+		return a < b
+	})
+
+	return sorted
+}
+
+// configuredNics returns the NICs of the networks configured using network
+// blocks. When no networks are configured yet, all NICs are returned.
+func configuredNics(d *schema.ResourceData, nics []cloudstack.Nic) []cloudstack.Nic {
+	networks := d.Get("network").([]interface{})
+	if len(networks) == 0 {
+		return nics
+	}
+
+	count := make(map[string]int)
+	for _, n := range networks {
+		count[n.(map[string]interface{})["network_id"].(string)]++
+	}
+
+	var configured []cloudstack.Nic
+	for _, nic := range nics {
+		if count[nic.Networkid] > 0 {
+			count[nic.Networkid]--
+			configured = append(configured, nic)
+		}
+	}
+
+	return configured
+}
+
+// flattenNics returns the given NICs as a list of network blocks.
+func flattenNics(nics []cloudstack.Nic) []interface{} {
+	networks := make([]interface{}, 0, len(nics))
+	for _, nic := range nics {
+		networks = append(networks, map[string]interface{}{
+			"network_id":   nic.Networkid,
+			"ip_address":   nic.Ipaddress,
+			"ipv6_address": nic.Ip6address,
+			"mac_address":  nic.Macaddress,
+			"default":      nic.Isdefault,
+		})
+	}
+	return networks
 }
 
 // getUserData returns the user data as a base64 encoded string
@@ -20,12 +20,21 @@
 package cloudstack
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestAccCloudStackInstance_basic(t *testing.T) {
@@ -212,6 +221,708 @@ func TestAccCloudStackInstance_importProject(t *testing.T) {
 	})
 }
 
+func TestCloudStackInstance_simulator(t *testing.T) {
+	var instance cloudstack.VirtualMachine
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackInstanceExists(
+						"cloudstack_instance.foobar", &instance),
+					testAccCheckCloudStackInstanceAttributes(&instance),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "user_data", "0cf3dcdc356ec8369494cb3991985ecd5296cdd5"),
+					testAccCheckResourceTags(&instance),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_instance.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"expunge", "user_data"},
+			},
+
+			{
+				Config: testAccCloudStackInstance_renameAndResize,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackInstanceExists(
+						"cloudstack_instance.foobar", &instance),
+					testAccCheckCloudStackInstanceRenamedAndResized(&instance),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "name", "terraform-updated"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "service_offering", "Medium Instance"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorProject(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_project,
+				Check: resource.TestCheckResourceAttr(
+					"cloudstack_instance.foobar", "project", "terraform"),
+			},
+
+			{
+				ResourceName:            "cloudstack_instance.foobar",
+				ImportState:             true,
+				ImportStateIdPrefix:     "terraform/",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"expunge", "user_data"},
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorNetworks(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_networks,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.#", "2"),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "network.0.network_id", "cloudstack_network.foo", "id"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.0.ip_address", "10.1.1.10"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.0.default", "false"),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "network.1.network_id", "cloudstack_network.bar", "id"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.1.mac_address", "02:00:00:0a:0b:0c"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.1.default", "true"),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "network_id", "cloudstack_network.bar", "id"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorNetworksNIC(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_networksNIC,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.#", "1"),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "network.0.network_id", "cloudstack_network.foo", "id"),
+					testAccCheckSimulatorCount(sim, "addNicToVirtualMachine", 1),
+				),
+			},
+
+			{
+				// The NIC added using cloudstack_nic doesn't force a new instance
+				Config: testAccCloudStackInstance_networksNIC,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "network.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "nic.#", "2"),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "nic.1.network_id", "cloudstack_network.bar", "id"),
+					testAccCheckSimulatorCount(sim, "deployVirtualMachine", 1),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorState(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_state, "Stopped"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "state", "Stopped"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Stopped"),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_state, "Running"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "state", "Running"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Running"),
+					testAccCheckSimulatorCount(sim, "startVirtualMachine", 1),
+					// Stop the instance outside of Terraform
+					testAccCheckSimulatorSetField(sim, "cloudstack_instance.foobar", "state", "Stopped"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_state, "Running"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Running"),
+					testAccCheckSimulatorCount(sim, "startVirtualMachine", 2),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_state, "Stopped"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Stopped"),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 1),
+				),
+			},
+
+			{
+				// A stopped instance is changed without stopping or starting it
+				Config: testAccCloudStackInstance_stateRenamed,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "name", "terraform-updated"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Stopped"),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "startVirtualMachine", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorScale(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.add("serviceoffering", simObject{
+		"name":         "Custom Instance",
+		"displaytext":  "Custom Instance",
+		"cpunumber":    0,
+		"cpuspeed":     0,
+		"memory":       0,
+		"storagetype":  "shared",
+		"iscustomized": true,
+		"issystem":     false,
+	})
+
+	template := simObject{}
+	for k, v := range sim.objects["template"][0] {
+		template[k] = v
+	}
+	delete(template, "id")
+	template["name"] = "CentOS 5.6 (64-bit) dynamically scalable (Simulator)"
+	template["isdynamicallyscalable"] = true
+	sim.add("template", template)
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_scale, 512, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "cpu_number", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "memory", "512"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "memory", "512"),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_scale, 1024, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "memory", "1024"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "memory", "1024"),
+					testAccCheckSimulatorCount(sim, "scaleVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 0),
+				),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackInstance_scale, 768, false),
+				ExpectError: regexp.MustCompile("Changing memory of instance terraform-test requires stopping the instance"),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_scale, 768, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "memory", "768"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "memory", "768"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Running"),
+					testAccCheckSimulatorCount(sim, "scaleVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "changeServiceForVirtualMachine", 1),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_instance.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"allow_stopping_for_update", "expunge"},
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorCustomOffering(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.add("serviceoffering", simObject{
+		"name":         "Custom Instance",
+		"displaytext":  "Custom Instance",
+		"cpunumber":    0,
+		"cpuspeed":     0,
+		"memory":       0,
+		"storagetype":  "shared",
+		"iscustomized": true,
+		"issystem":     false,
+	})
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_offering, "Small Instance"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckNoResourceAttr(
+						"cloudstack_instance.foobar", "cpu_number"),
+					resource.TestCheckNoResourceAttr(
+						"cloudstack_instance.foobar", "memory"),
+				),
+			},
+
+			{
+				// The CPU and memory of the current offering are not used for
+				// the custom offering
+				Config:      fmt.Sprintf(testAccCloudStackInstance_offering, "Custom Instance"),
+				ExpectError: regexp.MustCompile("Service offering Custom Instance is a custom offering which requires cpu_number to be set"),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorRootDisk(t *testing.T) {
+	var instance cloudstack.VirtualMachine
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	template := simObject{}
+	for k, v := range sim.objects["template"][0] {
+		template[k] = v
+	}
+	delete(template, "id")
+	template["name"] = "CentOS 5.6 (64-bit) updated (Simulator)"
+	sim.add("template", template)
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_rootDisk,
+					"CentOS 5.6 (64-bit) no GUI (Simulator)", 10),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackInstanceExists(
+						"cloudstack_instance.foobar", &instance),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "root_disk_size", "10"),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_rootDisk,
+					"CentOS 5.6 (64-bit) no GUI (Simulator)", 20),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_instance.foobar", "id", &instance.Id),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "root_disk_size", "20"),
+					testAccCheckSimulatorCount(sim, "resizeVolume", 1),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_rootDisk,
+					"CentOS 5.6 (64-bit) updated (Simulator)", 20),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_instance.foobar", "id", &instance.Id),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "template", "CentOS 5.6 (64-bit) updated (Simulator)"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "root_disk_size", "20"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"templatename", "CentOS 5.6 (64-bit) updated (Simulator)"),
+					testAccCheckSimulatorCount(sim, "restoreVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "rebootVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "resizeVolume", 2),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_rootDiskRenamed,
+					"CentOS 5.6 (64-bit) no GUI (Simulator)", 20),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_instance.foobar", "id", &instance.Id),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "name", "terraform-updated"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"templatename", "CentOS 5.6 (64-bit) no GUI (Simulator)"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"state", "Running"),
+					testAccCheckSimulatorCount(sim, "restoreVirtualMachine", 2),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "startVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "rebootVirtualMachine", 1),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_rootDisk,
+					"CentOS 5.6 (64-bit) updated (Simulator)", 15),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "root_disk_size", "15"),
+					testAccCheckSimulatorCount(sim, "deployVirtualMachine", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorISO(t *testing.T) {
+	var instance cloudstack.VirtualMachine
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_iso,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackInstanceExists(
+						"cloudstack_instance.foobar", &instance),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "iso", "terraform-iso"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "template", "terraform-iso"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "hypervisor", "Simulator"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "root_disk_size", "5"),
+					testAccCheckSimulatorCount(sim, "attachIso", 0),
+				),
+			},
+
+			{
+				Config:      testAccCloudStackInstance_isoRemoved,
+				ExpectError: regexp.MustCompile("is deployed from ISO terraform-iso"),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorAttachISO(t *testing.T) {
+	var instance cloudstack.VirtualMachine
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_attachISO, "${cloudstack_iso.foo.name}"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackInstanceExists(
+						"cloudstack_instance.foobar", &instance),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "iso", "terraform-iso"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "template", "CentOS 5.6 (64-bit) no GUI (Simulator)"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"isoname", "terraform-iso"),
+					testAccCheckSimulatorCount(sim, "attachIso", 1),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_attachISO, "${cloudstack_iso.bar.id}"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_instance.foobar", "id", &instance.Id),
+					resource.TestCheckResourceAttrPair(
+						"cloudstack_instance.foobar", "iso", "cloudstack_iso.bar", "id"),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"isoname", "terraform-iso-updated"),
+					testAccCheckSimulatorCount(sim, "detachIso", 1),
+					testAccCheckSimulatorCount(sim, "attachIso", 2),
+				),
+			},
+
+			{
+				PreConfig: func() {
+					sim.fail("attachIso", 530, "Failed to attach the ISO")
+				},
+				Config:      fmt.Sprintf(testAccCloudStackInstance_attachISO, "${cloudstack_iso.foo.name}"),
+				ExpectError: regexp.MustCompile("Failed to attach the ISO"),
+			},
+
+			{
+				// The ISO was detached before attaching the new ISO failed, so
+				// there is nothing left to detach
+				Config: fmt.Sprintf(testAccCloudStackInstance_attachISO, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_instance.foobar", "id", &instance.Id),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "iso", ""),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar",
+						"isoname", ""),
+					testAccCheckSimulatorCount(sim, "detachIso", 2),
+					testAccCheckSimulatorCount(sim, "attachIso", 3),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorPassword(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	template := simObject{}
+	for k, v := range sim.objects["template"][0] {
+		template[k] = v
+	}
+	delete(template, "id")
+	template["name"] = "CentOS 5.6 (64-bit) password enabled (Simulator)"
+	template["passwordenabled"] = true
+	sim.add("template", template)
+
+	entity, pgpKey := testPGPKey(t)
+	_, newPGPKey := testPGPKey(t)
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_password, "1", "", false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(
+						"cloudstack_instance.foobar", "password"),
+					testAccCheckSimulatorPassword(sim, "cloudstack_instance.foobar", nil),
+				),
+			},
+
+			{
+				Config:      fmt.Sprintf(testAccCloudStackInstance_password, "2", "", false),
+				ExpectError: regexp.MustCompile("Changing password_reset_trigger of instance terraform-test requires stopping the instance"),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_password, "2", "", true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckSimulatorPassword(sim, "cloudstack_instance.foobar", nil),
+					testAccCheckSimulatorField(sim, "cloudstack_instance.foobar", "state", "Running"),
+					testAccCheckSimulatorCount(sim, "resetPasswordForVirtualMachine", 1),
+					testAccCheckSimulatorCount(sim, "stopVirtualMachine", 1),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_password, "2", pgpKey, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "password", ""),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "key_fingerprint", hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])),
+					testAccCheckSimulatorPassword(sim, "cloudstack_instance.foobar", entity),
+					testAccCheckSimulatorCount(sim, "resetPasswordForVirtualMachine", 1),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_password, "3", pgpKey, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "password", ""),
+					testAccCheckSimulatorPassword(sim, "cloudstack_instance.foobar", entity),
+					testAccCheckSimulatorCount(sim, "resetPasswordForVirtualMachine", 2),
+				),
+			},
+
+			{
+				// The password encrypted using the old key cannot be decrypted,
+				// so it cannot be encrypted using the new key either
+				Config: fmt.Sprintf(testAccCloudStackInstance_password, "3", newPGPKey, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "password", ""),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "encrypted_password", ""),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "key_fingerprint", ""),
+					testAccCheckSimulatorCount(sim, "resetPasswordForVirtualMachine", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorPrivateKey(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	template := simObject{}
+	for k, v := range sim.objects["template"][0] {
+		template[k] = v
+	}
+	delete(template, "id")
+	template["name"] = "CentOS 5.6 (64-bit) password enabled (Simulator)"
+	template["passwordenabled"] = true
+	sim.add("template", template)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackInstance_privateKey,
+					strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))), privateKey),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckSimulatorPassword(sim, "cloudstack_instance.foobar", nil),
+					testAccCheckSimulatorCount(sim, "getVMPassword", 1),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackInstance_simulatorAccount(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	domain := sim.add("domain", simObject{"name": "customers", "path": "ROOT/customers", "level": 1})
+	sim.add("account", simObject{"name": "acme", "domain": domain["name"], "accounttype": 0})
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackInstanceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackInstance_account,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "account", "acme"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_instance.foobar", "domain", "customers"),
+					testAccCheckSimulatorOwner(sim, "cloudstack_ssh_keypair.foo", "acme", "customers"),
+					testAccCheckSimulatorOwner(sim, "cloudstack_network.foo", "acme", "customers"),
+					testAccCheckSimulatorOwner(sim, "cloudstack_instance.foobar", "acme", "customers"),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_instance.foobar",
+				ImportState:             true,
+				ImportStateIdPrefix:     "customers/acme/",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"expunge", "keypair", "user_data"},
+			},
+		},
+	})
+}
+
+// testAccCheckSimulatorPassword verifies that the password in the state is the
+// password of the instance in the simulator. When a PGP entity is given, the
+// encrypted password is decrypted using the entity first.
+func testAccCheckSimulatorPassword(sim *simulator, n string, entity *openpgp.Entity) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		password := rs.Primary.Attributes["password"]
+		if entity != nil {
+			var err error
+			password, err = testPGPDecrypt(entity, rs.Primary.Attributes["encrypted_password"])
+			if err != nil {
+				return fmt.Errorf("Error decrypting the password of %s: %s", n, err)
+			}
+		}
+
+		sim.mu.Lock()
+		defer sim.mu.Unlock()
+
+		vm := sim.lookup(rs.Primary.ID)
+		if vm == nil {
+			return fmt.Errorf("Object %s not found", rs.Primary.ID)
+		}
+
+		if password != vm["password"] {
+			return fmt.Errorf("Expected password of %s to be %s, got %s", n, vm["password"], password)
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckCloudStackInstanceExists(
 	n string, instance *cloudstack.VirtualMachine) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -220,96 +931,403 @@ func testAccCheckCloudStackInstanceExists(
 			return fmt.Errorf("Not found: %s", n)
 		}
 
-		if rs.Primary.ID == "" {
-			return fmt.Errorf("No instance ID is set")
-		}
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No instance ID is set")
+		}
+
+		cs := testAccProvider.Meta().(*Client)
+		vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
+			rs.Primary.ID,
+			cloudstack.WithProject(rs.Primary.Attributes["project"]),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if vm.Id != rs.Primary.ID {
+			return fmt.Errorf("Instance not found")
+		}
+
+		*instance = *vm
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackInstanceAttributes(
+	instance *cloudstack.VirtualMachine) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if instance.Name != "terraform-test" {
+			return fmt.Errorf("Bad name: %s", instance.Name)
+		}
+
+		if instance.Displayname != "terraform-test" {
+			return fmt.Errorf("Bad display name: %s", instance.Displayname)
+		}
+
+		if instance.Serviceofferingname != "Small Instance" {
+			return fmt.Errorf("Bad service offering: %s", instance.Serviceofferingname)
+		}
+
+		if instance.Templatename != "CentOS 5.6 (64-bit) no GUI (Simulator)" {
+			return fmt.Errorf("Bad template: %s", instance.Templatename)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackInstanceRenamedAndResized(
+	instance *cloudstack.VirtualMachine) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if instance.Name != "terraform-updated" {
+			return fmt.Errorf("Bad name: %s", instance.Name)
+		}
+
+		if instance.Displayname != "terraform-updated" {
+			return fmt.Errorf("Bad display name: %s", instance.Displayname)
+		}
+
+		if instance.Serviceofferingname != "Medium Instance" {
+			return fmt.Errorf("Bad service offering: %s", instance.Serviceofferingname)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackInstanceDestroy(s *terraform.State) error {
+	cs := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cloudstack_instance" {
+			continue
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No instance ID is set")
+		}
+
+		_, _, err := cs.VirtualMachine.GetVirtualMachineByID(rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Virtual Machine %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+const testAccCloudStackInstance_basic = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  user_data = "foobar\nfoo\nbar"
+  expunge = true
+  tags = {
+    terraform-tag = "true"
+  }
+}`
+
+const testAccCloudStackInstance_stopped = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  start_vm = false
+  expunge = true
+}`
+
+const testAccCloudStackInstance_renameAndResize = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-updated"
+  display_name = "terraform-updated"
+  service_offering= "Medium Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  user_data = "foobar\nfoo\nbar"
+  allow_stopping_for_update = true
+  expunge = true
+}`
+
+const testAccCloudStackInstance_fixedIP = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  ip_address = "10.1.1.123"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  expunge = true
+}`
+
+const testAccCloudStackInstance_keyPair = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_ssh_keypair" "foo" {
+  name = "terraform-test-keypair"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+	keypair = "${cloudstack_ssh_keypair.foo.name}"
+  expunge = true
+}`
+
+const testAccCloudStackInstance_project = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  project = "terraform"
+  zone = "Sandbox-simulator"
+}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
-		vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
-			rs.Primary.ID,
-			cloudstack.WithProject(rs.Primary.Attributes["project"]),
-		)
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  project = "terraform"
+  zone = "${cloudstack_network.foo.zone}"
+  expunge = true
+}`
 
-		if err != nil {
-			return err
-		}
+const testAccCloudStackInstance_password = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
 
-		if vm.Id != rs.Primary.ID {
-			return fmt.Errorf("Instance not found")
-		}
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) password enabled (Simulator)"
+  password_reset_trigger = "%s"
+  pgp_key = "%s"
+  allow_stopping_for_update = %t
+  zone = "Sandbox-simulator"
+  expunge = true
+}`
 
-		*instance = *vm
+const testAccCloudStackInstance_privateKey = `
+resource "cloudstack_ssh_keypair" "foo" {
+  name = "terraform-test-keypair"
+  public_key = "%s"
+}
 
-		return nil
-	}
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
 }
 
-func testAccCheckCloudStackInstanceAttributes(
-	instance *cloudstack.VirtualMachine) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) password enabled (Simulator)"
+  keypair = "${cloudstack_ssh_keypair.foo.name}"
+  private_key = <<EOF
+%sEOF
+  zone = "Sandbox-simulator"
+  expunge = true
+}`
 
-		if instance.Name != "terraform-test" {
-			return fmt.Errorf("Bad name: %s", instance.Name)
-		}
+const testAccCloudStackInstance_account = `
+resource "cloudstack_ssh_keypair" "foo" {
+  name = "terraform-test-keypair"
+  account = "acme"
+  domain = "customers"
+}
 
-		if instance.Displayname != "terraform-test" {
-			return fmt.Errorf("Bad display name: %s", instance.Displayname)
-		}
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  account = "acme"
+  domain = "customers"
+  zone = "Sandbox-simulator"
+}
 
-		if instance.Serviceofferingname != "Small Instance" {
-			return fmt.Errorf("Bad service offering: %s", instance.Serviceofferingname)
-		}
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  keypair = "${cloudstack_ssh_keypair.foo.name}"
+  account = "acme"
+  domain = "customers"
+  zone = "Sandbox-simulator"
+  expunge = true
+}`
 
-		if instance.Templatename != "CentOS 5.6 (64-bit) no GUI (Simulator)" {
-			return fmt.Errorf("Bad template: %s", instance.Templatename)
-		}
+const testAccCloudStackInstance_networks = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
 
-		return nil
-	}
+resource "cloudstack_network" "bar" {
+  name = "terraform-network-bar"
+  cidr = "10.1.2.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
 }
 
-func testAccCheckCloudStackInstanceRenamedAndResized(
-	instance *cloudstack.VirtualMachine) resource.TestCheckFunc {
-	return func(s *terraform.State) error {
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  expunge = true
 
-		if instance.Name != "terraform-updated" {
-			return fmt.Errorf("Bad name: %s", instance.Name)
-		}
+  network {
+    network_id = "${cloudstack_network.foo.id}"
+    ip_address = "10.1.1.10"
+  }
 
-		if instance.Displayname != "terraform-updated" {
-			return fmt.Errorf("Bad display name: %s", instance.Displayname)
-		}
+  network {
+    network_id = "${cloudstack_network.bar.id}"
+    mac_address = "02:00:00:0a:0b:0c"
+    default = true
+  }
+}`
 
-		if instance.Serviceofferingname != "Medium Instance" {
-			return fmt.Errorf("Bad service offering: %s", instance.Serviceofferingname)
-		}
+const testAccCloudStackInstance_networksNIC = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
 
-		return nil
-	}
+resource "cloudstack_network" "bar" {
+  name = "terraform-network-bar"
+  cidr = "10.1.2.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
 }
 
-func testAccCheckCloudStackInstanceDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  expunge = true
 
-	for _, rs := range s.RootModule().Resources {
-		if rs.Type != "cloudstack_instance" {
-			continue
-		}
+  network {
+    network_id = "${cloudstack_network.foo.id}"
+  }
+}
 
-		if rs.Primary.ID == "" {
-			return fmt.Errorf("No instance ID is set")
-		}
+resource "cloudstack_nic" "foo" {
+  network_id = "${cloudstack_network.bar.id}"
+  virtual_machine_id = "${cloudstack_instance.foobar.id}"
+}`
 
-		_, _, err := cs.VirtualMachine.GetVirtualMachineByID(rs.Primary.ID)
-		if err == nil {
-			return fmt.Errorf("Virtual Machine %s still exists", rs.Primary.ID)
-		}
-	}
+const testAccCloudStackInstance_state = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
 
-	return nil
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  state = "%s"
+  force_stop = true
+  expunge = true
+}`
+
+const testAccCloudStackInstance_stateRenamed = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
 }
 
-const testAccCloudStackInstance_basic = `
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-updated"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  zone = "Sandbox-simulator"
+  state = "Stopped"
+  force_stop = true
+  expunge = true
+}`
+
+const testAccCloudStackInstance_offering = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
@@ -320,18 +1338,15 @@ resource "cloudstack_network" "foo" {
 resource "cloudstack_instance" "foobar" {
   name = "terraform-test"
   display_name = "terraform-test"
-  service_offering= "Small Instance"
+  service_offering= "%s"
   network_id = "${cloudstack_network.foo.id}"
   template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
   zone = "Sandbox-simulator"
-  user_data = "foobar\nfoo\nbar"
+  allow_stopping_for_update = true
   expunge = true
-  tags = {
-    terraform-tag = "true"
-  }
 }`
 
-const testAccCloudStackInstance_stopped = `
+const testAccCloudStackInstance_rootDisk = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
@@ -344,13 +1359,14 @@ resource "cloudstack_instance" "foobar" {
   display_name = "terraform-test"
   service_offering= "Small Instance"
   network_id = "${cloudstack_network.foo.id}"
-  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  template = "%s"
+  reinstall_on_template_change = true
+  root_disk_size = %d
   zone = "Sandbox-simulator"
-  start_vm = false
   expunge = true
 }`
 
-const testAccCloudStackInstance_renameAndResize = `
+const testAccCloudStackInstance_rootDiskRenamed = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
@@ -360,16 +1376,18 @@ resource "cloudstack_network" "foo" {
 
 resource "cloudstack_instance" "foobar" {
   name = "terraform-updated"
-  display_name = "terraform-updated"
-  service_offering= "Medium Instance"
+  display_name = "terraform-test"
+  service_offering= "Small Instance"
   network_id = "${cloudstack_network.foo.id}"
-  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  template = "%s"
+  reinstall_on_template_change = true
+  root_disk_size = %d
+  allow_stopping_for_update = true
   zone = "Sandbox-simulator"
-  user_data = "foobar\nfoo\nbar"
   expunge = true
 }`
 
-const testAccCloudStackInstance_fixedIP = `
+const testAccCloudStackInstance_iso = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
@@ -377,18 +1395,26 @@ resource "cloudstack_network" "foo" {
   zone = "Sandbox-simulator"
 }
 
+resource "cloudstack_iso" "foo" {
+  name = "terraform-iso"
+  os_type = "Centos 5.6 (64-bit)"
+  url = "http://example.com/terraform.iso"
+  zone = "Sandbox-simulator"
+}
+
 resource "cloudstack_instance" "foobar" {
   name = "terraform-test"
   display_name = "terraform-test"
   service_offering= "Small Instance"
   network_id = "${cloudstack_network.foo.id}"
-  ip_address = "10.1.1.123"
-  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  iso = "${cloudstack_iso.foo.name}"
+  hypervisor = "Simulator"
+  root_disk_offering = "Small"
   zone = "Sandbox-simulator"
   expunge = true
 }`
 
-const testAccCloudStackInstance_keyPair = `
+const testAccCloudStackInstance_isoRemoved = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
@@ -396,8 +1422,11 @@ resource "cloudstack_network" "foo" {
   zone = "Sandbox-simulator"
 }
 
-resource "cloudstack_ssh_keypair" "foo" {
-  name = "terraform-test-keypair"
+resource "cloudstack_iso" "foo" {
+  name = "terraform-iso"
+  os_type = "Centos 5.6 (64-bit)"
+  url = "http://example.com/terraform.iso"
+  zone = "Sandbox-simulator"
 }
 
 resource "cloudstack_instance" "foobar" {
@@ -405,18 +1434,31 @@ resource "cloudstack_instance" "foobar" {
   display_name = "terraform-test"
   service_offering= "Small Instance"
   network_id = "${cloudstack_network.foo.id}"
-  template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
+  hypervisor = "Simulator"
+  root_disk_offering = "Small"
   zone = "Sandbox-simulator"
-	keypair = "${cloudstack_ssh_keypair.foo.name}"
   expunge = true
 }`
 
-const testAccCloudStackInstance_project = `
+const testAccCloudStackInstance_attachISO = `
 resource "cloudstack_network" "foo" {
   name = "terraform-network"
   cidr = "10.1.1.0/24"
   network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
-  project = "terraform"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_iso" "foo" {
+  name = "terraform-iso"
+  bootable = false
+  url = "http://example.com/terraform.iso"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_iso" "bar" {
+  name = "terraform-iso-updated"
+  bootable = false
+  url = "http://example.com/terraform-updated.iso"
   zone = "Sandbox-simulator"
 }
 
@@ -426,7 +1468,29 @@ resource "cloudstack_instance" "foobar" {
   service_offering= "Small Instance"
   network_id = "${cloudstack_network.foo.id}"
   template = "CentOS 5.6 (64-bit) no GUI (Simulator)"
-  project = "terraform"
-  zone = "${cloudstack_network.foo.zone}"
+  iso = "%s"
+  zone = "Sandbox-simulator"
+  expunge = true
+}`
+
+const testAccCloudStackInstance_scale = `
+resource "cloudstack_network" "foo" {
+  name = "terraform-network"
+  cidr = "10.1.1.0/24"
+  network_offering = "DefaultIsolatedNetworkOfferingWithSourceNatService"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_instance" "foobar" {
+  name = "terraform-test"
+  display_name = "terraform-test"
+  service_offering= "Custom Instance"
+  cpu_number = 2
+  cpu_speed = 1000
+  memory = %d
+  network_id = "${cloudstack_network.foo.id}"
+  template = "CentOS 5.6 (64-bit) dynamically scalable (Simulator)"
+  zone = "Sandbox-simulator"
+  allow_stopping_for_update = %t
   expunge = true
 }`
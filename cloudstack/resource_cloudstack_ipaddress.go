@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -33,6 +32,9 @@ func resourceCloudStackIPAddress() *schema.Resource {
 		Create: resourceCloudStackIPAddressCreate,
 		Read:   resourceCloudStackIPAddressRead,
 		Delete: resourceCloudStackIPAddressDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackIPAddressImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"is_portable": {
@@ -67,6 +69,21 @@ func resourceCloudStackIPAddress() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
 			"ip_address": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -78,7 +95,7 @@ func resourceCloudStackIPAddress() *schema.Resource {
 }
 
 func resourceCloudStackIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	if err := verifyIPAddressParams(d); err != nil {
 		return err
@@ -117,6 +134,11 @@ func resourceCloudStackIPAddressCreate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Associate a new IP address
 	r, err := cs.Address.AssociateIpAddress(p)
 	if err != nil {
@@ -135,15 +157,16 @@ func resourceCloudStackIPAddressCreate(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackIPAddressRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the IP address details
-	ip, count, err := cs.Address.GetPublicIpAddressByID(
+	ip, _, err := cs.Address.GetPublicIpAddressByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] IP address with ID %s is no longer associated", d.Id())
 			d.SetId("")
@@ -170,29 +193,24 @@ func resourceCloudStackIPAddressRead(d *schema.ResourceData, meta interface{}) e
 		setValueOrID(d, "zone", ip.Zonename, ip.Zoneid)
 	}
 
-	tags := make(map[string]interface{})
-	for _, tag := range ip.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, ip.Tags)
 
 	setValueOrID(d, "project", ip.Project, ip.Projectid)
+	readAccount(d, ip.Account, ip.Domain, ip.Domainid, ip.Projectid)
 
 	return nil
 }
 
 func resourceCloudStackIPAddressDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.Address.NewDisassociateIpAddressParams(d.Id())
 
 	// Disassociate the IP address
 	if _, err := cs.Address.DisassociateIpAddress(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -202,6 +220,36 @@ func resourceCloudStackIPAddressDelete(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
+func resourceCloudStackIPAddressImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
+
+	// Try to split the ID to extract the optional project name
+	if _, err := importStatePassthrough(d, meta); err != nil {
+		return nil, err
+	}
+
+	// Get the IP address details
+	ip, _, err := cs.Address.GetPublicIpAddressByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The network_id and vpc_id are only read when they are configured, so
+	// set the one the IP address was associated with. This is the VPC for
+	// IP addresses that belong to a VPC, and the network otherwise.
+	if ip.Vpcid != "" {
+		d.Set("vpc_id", ip.Vpcid)
+	} else {
+		d.Set("network_id", ip.Associatednetworkid)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func verifyIPAddressParams(d *schema.ResourceData) error {
 	_, portable := d.GetOk("is_portable")
 	_, network := d.GetOk("network_id")
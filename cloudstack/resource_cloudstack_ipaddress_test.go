@@ -67,6 +67,54 @@ func TestAccCloudStackIPAddress_vpc(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackIPAddress_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackIPAddress_vpc,
+			},
+
+			{
+				ResourceName:            "cloudstack_ipaddress.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"zone"},
+			},
+		},
+	})
+}
+
+func TestCloudStackIPAddress_simulator(t *testing.T) {
+	var ipaddr cloudstack.PublicIpAddress
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackIPAddress_vpc,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackIPAddressExists(
+						"cloudstack_ipaddress.foo", &ipaddr),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_ipaddress.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"zone"},
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackIPAddressExists(
 	n string, ipaddr *cloudstack.PublicIpAddress) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -79,7 +127,7 @@ func testAccCheckCloudStackIPAddressExists(
 			return fmt.Errorf("No IP address ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		pip, _, err := cs.Address.GetPublicIpAddressByID(rs.Primary.ID)
 
 		if err != nil {
@@ -97,7 +145,7 @@ func testAccCheckCloudStackIPAddressExists(
 }
 
 func testAccCheckCloudStackIPAddressDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_ipaddress" {
@@ -0,0 +1,338 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func resourceCloudStackISO() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceCloudStackISOCreate,
+		Read:   resourceCloudStackISORead,
+		Update: resourceCloudStackISOUpdate,
+		Delete: resourceCloudStackISODelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"display_text": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"url": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"os_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"bootable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"zone": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"is_extractable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"is_featured": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"is_public": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+
+			"is_ready": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func resourceCloudStackISOCreate(d *schema.ResourceData, meta interface{}) error {
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
+
+	name := d.Get("name").(string)
+
+	// Compute/set the display text
+	displaytext := d.Get("display_text").(string)
+	if displaytext == "" {
+		displaytext = name
+	}
+
+	// Retrieve the zone ID
+	zoneid, e := retrieveZoneID(cs, d)
+	if e != nil {
+		return e.Error()
+	}
+
+	// Create a new parameter struct
+	p := &isoParams{}
+	p.SetParam("name", name)
+	p.SetParam("displaytext", displaytext)
+	p.SetParam("url", d.Get("url").(string))
+	p.SetParam("zoneid", zoneid)
+	p.SetParam("bootable", d.Get("bootable").(bool))
+
+	// Retrieve the os_type ID, which is required for bootable ISOs
+	if v, ok := d.GetOk("os_type"); ok {
+		ostypeid, e := retrieveID(cs, "os_type", v.(string))
+		if e != nil {
+			return e.Error()
+		}
+		p.SetParam("ostypeid", ostypeid)
+	}
+
+	// Set optional parameters
+	if v, ok := d.GetOk("is_extractable"); ok {
+		p.SetParam("isextractable", v.(bool))
+	}
+
+	if v, ok := d.GetOk("is_featured"); ok {
+		p.SetParam("isfeatured", v.(bool))
+	}
+
+	if v, ok := d.GetOk("is_public"); ok {
+		p.SetParam("ispublic", v.(bool))
+	}
+
+	// If there is a project supplied, we retrieve and set the project id
+	if err := setProjectid(p, cs, d); err != nil {
+		return err
+	}
+
+	// Create the new ISO
+	r, err := registerISO(cs, p)
+	if err != nil {
+		return fmt.Errorf("Error creating ISO %s: %s", name, err)
+	}
+
+	d.SetId(r.Id)
+
+	// The ISO may have been looked up by name before it existed
+	cs.lookups.forget("iso", name)
+
+	// Set tags if necessary
+	if err = setTags(cs, d, "ISO"); err != nil {
+		return fmt.Errorf("Error setting tags on the ISO %s: %s", name, err)
+	}
+
+	// Wait until the ISO is ready to use, or timeout with an error...
+	return waitForReady(d, meta, "ISO", resourceCloudStackISORead, resourceTimeout(cs, d.Timeout(schema.TimeoutCreate)))
+}
+
+func resourceCloudStackISORead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Get the ISO details
+	iso, _, err := cs.ISO.GetIsoByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		if isNotFound(err) {
+			log.Printf(
+				"[DEBUG] ISO %s no longer exists", d.Get("name").(string))
+			d.SetId("")
+			return nil
+		}
+
+		return err
+	}
+
+	d.Set("name", iso.Name)
+	d.Set("display_text", iso.Displaytext)
+	d.Set("bootable", iso.Bootable)
+	d.Set("is_extractable", iso.Isextractable)
+	d.Set("is_featured", iso.Isfeatured)
+	d.Set("is_public", iso.Ispublic)
+	d.Set("is_ready", iso.Isready)
+
+	readTags(cs, d, iso.Tags)
+
+	setValueOrID(d, "os_type", iso.Ostypename, iso.Ostypeid)
+	setValueOrID(d, "project", iso.Project, iso.Projectid)
+	setValueOrID(d, "zone", iso.Zonename, iso.Zoneid)
+
+	return nil
+}
+
+func resourceCloudStackISOUpdate(d *schema.ResourceData, meta interface{}) error {
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
+	name := d.Get("name").(string)
+
+	if d.HasChange("name") || d.HasChange("display_text") ||
+		d.HasChange("os_type") || d.HasChange("bootable") {
+		// Create a new parameter struct
+		p := cs.ISO.NewUpdateIsoParams(d.Id())
+
+		if d.HasChange("name") {
+			p.SetName(name)
+		}
+
+		if d.HasChange("display_text") {
+			p.SetDisplaytext(d.Get("display_text").(string))
+		}
+
+		if d.HasChange("os_type") {
+			ostypeid, e := retrieveID(cs, "os_type", d.Get("os_type").(string))
+			if e != nil {
+				return e.Error()
+			}
+			p.SetOstypeid(ostypeid)
+		}
+
+		if d.HasChange("bootable") {
+			p.SetBootable(d.Get("bootable").(bool))
+		}
+
+		_, err := cs.ISO.UpdateIso(p)
+		if err != nil {
+			return fmt.Errorf("Error updating ISO %s: %s", name, err)
+		}
+	}
+
+	if d.HasChange("is_public") {
+		p := cs.ISO.NewUpdateIsoPermissionsParams(d.Id())
+		p.SetIspublic(d.Get("is_public").(bool))
+
+		_, err := cs.ISO.UpdateIsoPermissions(p)
+		if err != nil {
+			return fmt.Errorf("Error updating the permissions of ISO %s: %s", name, err)
+		}
+	}
+
+	if d.HasChange("name") {
+		o, n := d.GetChange("name")
+		cs.lookups.forget("iso", o.(string))
+		cs.lookups.forget("iso", n.(string))
+	}
+
+	if d.HasChange("tags") {
+		if err := updateTags(cs, d, "ISO"); err != nil {
+			return fmt.Errorf("Error updating tags on ISO %s: %s", name, err)
+		}
+	}
+
+	return resourceCloudStackISORead(d, meta)
+}
+
+func resourceCloudStackISODelete(d *schema.ResourceData, meta interface{}) error {
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
+
+	// Create a new parameter struct
+	p := cs.ISO.NewDeleteIsoParams(d.Id())
+
+	// Delete the ISO
+	log.Printf("[INFO] Deleting ISO: %s", d.Get("name").(string))
+	_, err := cs.ISO.DeleteIso(p)
+	if err != nil {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("Error deleting ISO %s: %s", d.Get("name").(string), err)
+	}
+
+	// Make sure a new ISO with the same name is looked up again
+	cs.lookups.forget("iso", d.Get("name").(string))
+
+	return nil
+}
+
+// isoParams holds the parameters used to register a new ISO.
+type isoParams struct {
+	cloudstack.CustomServiceParams
+}
+
+func (p *isoParams) SetProjectid(v string) {
+	p.SetParam("projectid", v)
+}
+
+// registerISO registers a new ISO. CloudStack returns a list holding the new
+// ISO, which the registerIso call of go-cloudstack does not decode, so the call
+// is made using a custom request.
+func registerISO(cs *Client, p *isoParams) (*cloudstack.Iso, error) {
+	var r struct {
+		Count int               `json:"count"`
+		Iso   []*cloudstack.Iso `json:"iso"`
+	}
+	if err := cs.Custom.CustomRequest("registerIso", &p.CustomServiceParams, &r); err != nil {
+		return nil, err
+	}
+
+	if len(r.Iso) == 0 {
+		return nil, fmt.Errorf("No ISO returned by CloudStack")
+	}
+
+	return r.Iso[0], nil
+}
@@ -0,0 +1,276 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func TestAccCloudStackISO_basic(t *testing.T) {
+	if cloudStackISOURL == "" {
+		t.Skip("This test requires an ISO URL")
+	}
+
+	var iso cloudstack.Iso
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackISODestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_basic, cloudStackISOURL),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackISOExists("cloudstack_iso.foo", &iso),
+					testAccCheckCloudStackISOBasicAttributes(&iso),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "display_text", "terraform-test"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "is_ready", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudStackISO_update(t *testing.T) {
+	if cloudStackISOURL == "" {
+		t.Skip("This test requires an ISO URL")
+	}
+
+	var iso cloudstack.Iso
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackISODestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_basic, cloudStackISOURL),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackISOExists("cloudstack_iso.foo", &iso),
+					testAccCheckCloudStackISOBasicAttributes(&iso),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_update, cloudStackISOURL),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackISOExists("cloudstack_iso.foo", &iso),
+					testAccCheckCloudStackISOUpdatedAttributes(&iso),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "display_text", "terraform-updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudStackISO_import(t *testing.T) {
+	if cloudStackISOURL == "" {
+		t.Skip("This test requires an ISO URL")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackISODestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_basic, cloudStackISOURL),
+			},
+
+			{
+				ResourceName:            "cloudstack_iso.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"url"},
+			},
+		},
+	})
+}
+
+func TestCloudStackISO_simulator(t *testing.T) {
+	var iso cloudstack.Iso
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackISODestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_basic, "http://example.com/terraform.iso"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackISOExists("cloudstack_iso.foo", &iso),
+					testAccCheckCloudStackISOBasicAttributes(&iso),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "bootable", "true"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "is_public", "false"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "is_ready", "true"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_iso.foo", "tags.terraform-tag", "true"),
+				),
+			},
+
+			{
+				Config: fmt.Sprintf(testAccCloudStackISO_update, "http://example.com/terraform.iso"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr(
+						"cloudstack_iso.foo", "id", &iso.Id),
+					testAccCheckCloudStackISOExists("cloudstack_iso.foo", &iso),
+					testAccCheckCloudStackISOUpdatedAttributes(&iso),
+					testAccCheckSimulatorCount(sim, "registerIso", 1),
+					testAccCheckSimulatorCount(sim, "updateIso", 1),
+					testAccCheckSimulatorCount(sim, "updateIsoPermissions", 1),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_iso.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"url"},
+			},
+		},
+	})
+}
+
+func testAccCheckCloudStackISOExists(
+	n string, iso *cloudstack.Iso) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ISO ID is set")
+		}
+
+		cs := testAccProvider.Meta().(*Client)
+		i, _, err := cs.ISO.GetIsoByID(rs.Primary.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if i.Id != rs.Primary.ID {
+			return fmt.Errorf("ISO not found")
+		}
+
+		*iso = *i
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackISOBasicAttributes(
+	iso *cloudstack.Iso) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if iso.Name != "terraform-test" {
+			return fmt.Errorf("Bad name: %s", iso.Name)
+		}
+
+		if !iso.Bootable {
+			return fmt.Errorf("Bad bootable: %t", iso.Bootable)
+		}
+
+		if iso.Ostypename != "Centos 5.6 (64-bit)" {
+			return fmt.Errorf("Bad os type: %s", iso.Ostypename)
+		}
+
+		if iso.Zonename != "Sandbox-simulator" {
+			return fmt.Errorf("Bad zone: %s", iso.Zonename)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackISOUpdatedAttributes(
+	iso *cloudstack.Iso) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if iso.Displaytext != "terraform-updated" {
+			return fmt.Errorf("Bad display text: %s", iso.Displaytext)
+		}
+
+		if !iso.Ispublic {
+			return fmt.Errorf("Bad is_public: %t", iso.Ispublic)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackISODestroy(s *terraform.State) error {
+	cs := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cloudstack_iso" {
+			continue
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ISO ID is set")
+		}
+
+		_, _, err := cs.ISO.GetIsoByID(rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("ISO %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+const testAccCloudStackISO_basic = `
+resource "cloudstack_iso" "foo" {
+  name = "terraform-test"
+  os_type = "Centos 5.6 (64-bit)"
+  url = "%s"
+  zone = "Sandbox-simulator"
+  tags = {
+    terraform-tag = "true"
+  }
+}`
+
+const testAccCloudStackISO_update = `
+resource "cloudstack_iso" "foo" {
+  name = "terraform-test"
+  display_text = "terraform-updated"
+  os_type = "Centos 5.6 (64-bit)"
+  url = "%s"
+  is_public = true
+  zone = "Sandbox-simulator"
+  tags = {
+    terraform-tag = "true"
+  }
+}`
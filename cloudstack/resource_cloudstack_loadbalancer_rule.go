@@ -22,7 +22,7 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
+	"strconv"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -34,6 +34,9 @@ func resourceCloudStackLoadBalancerRule() *schema.Resource {
 		Read:   resourceCloudStackLoadBalancerRuleRead,
 		Update: resourceCloudStackLoadBalancerRuleUpdate,
 		Delete: resourceCloudStackLoadBalancerRuleDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackLoadBalancerRuleImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -107,7 +110,7 @@ func resourceCloudStackLoadBalancerRule() *schema.Resource {
 }
 
 func resourceCloudStackLoadBalancerRuleCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Make sure all required parameters are there
 	if err := verifyLoadBalancerRule(d); err != nil {
@@ -195,15 +198,15 @@ func resourceCloudStackLoadBalancerRuleCreate(d *schema.ResourceData, meta inter
 }
 
 func resourceCloudStackLoadBalancerRuleRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the load balancer details
-	lb, count, err := cs.LoadBalancer.GetLoadBalancerRuleByID(
+	lb, _, err := cs.LoadBalancer.GetLoadBalancerRuleByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Load balancer rule %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
 			return nil
@@ -215,8 +218,19 @@ func resourceCloudStackLoadBalancerRuleRead(d *schema.ResourceData, meta interfa
 	d.Set("name", lb.Name)
 	d.Set("ip_address_id", lb.Publicipid)
 	d.Set("algorithm", lb.Algorithm)
-	d.Set("public_port", lb.Publicport)
-	d.Set("private_port", lb.Privateport)
+
+	pubPort, err := strconv.Atoi(lb.Publicport)
+	if err != nil {
+		return err
+	}
+	d.Set("public_port", pubPort)
+
+	privPort, err := strconv.Atoi(lb.Privateport)
+	if err != nil {
+		return err
+	}
+	d.Set("private_port", privPort)
+
 	d.Set("protocol", lb.Protocol)
 
 	// Only set network if user specified it to avoid spurious diffs
@@ -242,7 +256,7 @@ func resourceCloudStackLoadBalancerRuleRead(d *schema.ResourceData, meta interfa
 }
 
 func resourceCloudStackLoadBalancerRuleUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Make sure all required parameters are there
 	if err := verifyLoadBalancerRule(d); err != nil {
@@ -338,18 +352,50 @@ func resourceCloudStackLoadBalancerRuleUpdate(d *schema.ResourceData, meta inter
 	return resourceCloudStackLoadBalancerRuleRead(d, meta)
 }
 
+func resourceCloudStackLoadBalancerRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
+
+	// Try to split the ID to extract the optional project name
+	if _, err := importStatePassthrough(d, meta); err != nil {
+		return nil, err
+	}
+
+	// Get the load balancer details
+	lb, _, err := cs.LoadBalancer.GetLoadBalancerRuleByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The network_id is only read when it is configured, but it is required
+	// for load balancer rules in a VPC so set it for those rules.
+	ip, _, err := cs.Address.GetPublicIpAddressByID(
+		lb.Publicipid,
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if ip.Vpcid != "" {
+		d.Set("network_id", lb.Networkid)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceCloudStackLoadBalancerRuleDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.LoadBalancer.NewDeleteLoadBalancerRuleParams(d.Id())
 
 	log.Printf("[INFO] Deleting load balancer rule: %s", d.Get("name").(string))
 	if _, err := cs.LoadBalancer.DeleteLoadBalancerRule(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if !strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if !isNotFound(err) {
 			return err
 		}
 	}
@@ -24,8 +24,8 @@ import (
 	"log"
 	"net"
 	"strconv"
-	"strings"
 
+	"github.com/hashicorp/terraform/helper/customdiff"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
@@ -33,24 +33,6 @@ import (
 const none = "none"
 
 func resourceCloudStackNetwork() *schema.Resource {
-	aclidSchema := &schema.Schema{
-		Type:     schema.TypeString,
-		Optional: true,
-		Default:  none,
-	}
-
-	aclidSchema.StateFunc = func(v interface{}) string {
-		value := v.(string)
-
-		if value == none {
-			aclidSchema.ForceNew = true
-		} else {
-			aclidSchema.ForceNew = false
-		}
-
-		return value
-	}
-
 	return &schema.Resource{
 		Create: resourceCloudStackNetworkCreate,
 		Read:   resourceCloudStackNetworkRead,
@@ -60,6 +42,17 @@ func resourceCloudStackNetwork() *schema.Resource {
 			State: importStatePassthrough,
 		},
 
+		// Removing the ACL of a network requires a new network
+		CustomizeDiff: customdiff.ForceNewIfChange("acl_id", func(old, new, meta interface{}) bool {
+			return new.(string) == none
+		}),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -122,7 +115,11 @@ func resourceCloudStackNetwork() *schema.Resource {
 				ForceNew: true,
 			},
 
-			"acl_id": aclidSchema,
+			"acl_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  none,
+			},
 
 			"project": {
 				Type:     schema.TypeString,
@@ -131,6 +128,21 @@ func resourceCloudStackNetwork() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
 			"source_nat_ip": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -144,7 +156,8 @@ func resourceCloudStackNetwork() *schema.Resource {
 
 			"zone": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 
@@ -154,7 +167,7 @@ func resourceCloudStackNetwork() *schema.Resource {
 }
 
 func resourceCloudStackNetworkCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 	d.Partial(true)
 
 	name := d.Get("name").(string)
@@ -166,7 +179,7 @@ func resourceCloudStackNetworkCreate(d *schema.ResourceData, meta interface{}) e
 	}
 
 	// Retrieve the zone ID
-	zoneid, e := retrieveID(cs, "zone", d.Get("zone").(string))
+	zoneid, e := retrieveZoneID(cs, d)
 	if e != nil {
 		return e.Error()
 	}
@@ -231,6 +244,11 @@ func resourceCloudStackNetworkCreate(d *schema.ResourceData, meta interface{}) e
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Create the new network
 	r, err := cs.Network.CreateNetwork(p)
 	if err != nil {
@@ -277,6 +295,11 @@ func resourceCloudStackNetworkCreate(d *schema.ResourceData, meta interface{}) e
 			return err
 		}
 
+		// If there is an account supplied, we retrieve and set the account and domain id
+		if err := setAccount(p, cs, d); err != nil {
+			return err
+		}
+
 		// Associate a new IP address
 		ip, err := cs.Address.AssociateIpAddress(p)
 		if err != nil {
@@ -294,15 +317,16 @@ func resourceCloudStackNetworkCreate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceCloudStackNetworkRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the virtual machine details
-	n, count, err := cs.Network.GetNetworkByID(
+	n, _, err := cs.Network.GetNetworkByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] Network %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
@@ -324,23 +348,21 @@ func resourceCloudStackNetworkRead(d *schema.ResourceData, meta interface{}) err
 	}
 	d.Set("acl_id", n.Aclid)
 
-	tags := make(map[string]interface{})
-	for _, tag := range n.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, n.Tags)
 
 	setValueOrID(d, "network_offering", n.Networkofferingname, n.Networkofferingid)
 	setValueOrID(d, "project", n.Project, n.Projectid)
+	readAccount(d, n.Account, n.Domain, n.Domainid, n.Projectid)
 	setValueOrID(d, "zone", n.Zonename, n.Zoneid)
 
 	if d.Get("source_nat_ip").(bool) {
-		ip, count, err := cs.Address.GetPublicIpAddressByID(
+		ip, _, err := cs.Address.GetPublicIpAddressByID(
 			d.Get("source_nat_ip_id").(string),
-			cloudstack.WithProject(d.Get("project").(string)),
+			cloudstack.WithProject(getProject(cs, d)),
+			withAccount(cs, d),
 		)
 		if err != nil {
-			if count == 0 {
+			if isNotFound(err) {
 				log.Printf(
 					"[DEBUG] Source NAT IP with ID %s is no longer associated", d.Id())
 				d.Set("source_nat_ip", false)
@@ -361,7 +383,7 @@ func resourceCloudStackNetworkRead(d *schema.ResourceData, meta interface{}) err
 }
 
 func resourceCloudStackNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 	name := d.Get("name").(string)
 
 	// Create a new parameter struct
@@ -429,7 +451,7 @@ func resourceCloudStackNetworkUpdate(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceCloudStackNetworkDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.Network.NewDeleteNetworkParams(d.Id())
@@ -437,10 +459,8 @@ func resourceCloudStackNetworkDelete(d *schema.ResourceData, meta interface{}) e
 	// Delete the network
 	_, err := cs.Network.DeleteNetwork(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -67,7 +66,7 @@ func resourceCloudStackNetworkACL() *schema.Resource {
 }
 
 func resourceCloudStackNetworkACLCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	name := d.Get("name").(string)
 
@@ -93,15 +92,15 @@ func resourceCloudStackNetworkACLCreate(d *schema.ResourceData, meta interface{}
 }
 
 func resourceCloudStackNetworkACLRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the network ACL list details
-	f, count, err := cs.NetworkACL.GetNetworkACLListByID(
+	f, _, err := cs.NetworkACL.GetNetworkACLListByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] Network ACL list %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
@@ -119,7 +118,7 @@ func resourceCloudStackNetworkACLRead(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackNetworkACLDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.NetworkACL.NewDeleteNetworkACLListParams(d.Id())
@@ -129,10 +128,8 @@ func resourceCloudStackNetworkACLDelete(d *schema.ResourceData, meta interface{}
 		return cs.NetworkACL.DeleteNetworkACLList(p)
 	})
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -0,0 +1,277 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func resourceCloudStackNetworkACLItem() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceCloudStackNetworkACLItemCreate,
+		Read:   resourceCloudStackNetworkACLItemRead,
+		Update: resourceCloudStackNetworkACLItemUpdate,
+		Delete: resourceCloudStackNetworkACLItemDelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
+
+		CustomizeDiff: verifyRuleDiff,
+
+		Schema: map[string]*schema.Schema{
+			"acl_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"rule_number": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+
+			"action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "allow",
+				ValidateFunc: validation.StringInSlice([]string{"allow", "deny"}, false),
+			},
+
+			"cidr_list": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCIDR,
+				},
+				Set: schema.HashString,
+			},
+
+			"protocol": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateProtocol(true, "tcp", "udp", "icmp", "all"),
+			},
+
+			"icmp_type": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"icmp_code": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"port": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePorts,
+			},
+
+			"traffic_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "ingress",
+				ValidateFunc: validation.StringInSlice([]string{"ingress", "egress"}, false),
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceCloudStackNetworkACLItemCreate(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Create a new parameter struct
+	p := cs.NetworkACL.NewCreateNetworkACLParams(d.Get("protocol").(string))
+
+	// Set the acl ID
+	p.SetAclid(d.Get("acl_id").(string))
+
+	// Set the action
+	p.SetAction(d.Get("action").(string))
+
+	// Set the CIDR list
+	var cidrList []string
+	for _, cidr := range d.Get("cidr_list").(*schema.Set).List() {
+		cidrList = append(cidrList, cidr.(string))
+	}
+	p.SetCidrlist(cidrList)
+
+	// Set the traffic type
+	p.SetTraffictype(d.Get("traffic_type").(string))
+
+	// Set the rule number
+	if number, ok := d.GetOk("rule_number"); ok {
+		p.SetNumber(number.(int))
+	}
+
+	// Set the description
+	if description, ok := d.GetOk("description"); ok {
+		p.SetReason(description.(string))
+	}
+
+	// If the protocol is ICMP set the needed ICMP parameters
+	if d.Get("protocol").(string) == "icmp" {
+		p.SetIcmptype(d.Get("icmp_type").(int))
+		p.SetIcmpcode(d.Get("icmp_code").(int))
+	}
+
+	// If a port is configured, set the port range
+	if port, ok := d.GetOk("port"); ok {
+		startPort, endPort, err := splitPortRange(port.(string))
+		if err != nil {
+			return err
+		}
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
+	}
+
+	r, err := Retry(4, retryableACLCreationFunc(cs, p))
+	if err != nil {
+		return fmt.Errorf("Error creating network ACL item: %s", err)
+	}
+
+	d.SetId(r.(*cloudstack.CreateNetworkACLResponse).Id)
+
+	return resourceCloudStackNetworkACLItemRead(d, meta)
+}
+
+func resourceCloudStackNetworkACLItemRead(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Get the network ACL item details
+	r, _, err := cs.NetworkACL.GetNetworkACLByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		if isNotFound(err) {
+			log.Printf("[DEBUG] Network ACL item %s does no longer exist", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		return err
+	}
+
+	d.Set("acl_id", r.Aclid)
+	d.Set("rule_number", r.Number)
+	d.Set("action", strings.ToLower(r.Action))
+	d.Set("cidr_list", strings.Split(r.Cidrlist, ","))
+	d.Set("protocol", r.Protocol)
+	d.Set("traffic_type", strings.ToLower(r.Traffictype))
+	d.Set("description", r.Reason)
+
+	if r.Protocol == "icmp" {
+		d.Set("icmp_type", r.Icmptype)
+		d.Set("icmp_code", r.Icmpcode)
+	} else {
+		d.Set("icmp_type", -1)
+		d.Set("icmp_code", -1)
+	}
+
+	if r.Startport != "" {
+		if r.Startport == r.Endport {
+			d.Set("port", r.Startport)
+		} else {
+			d.Set("port", fmt.Sprintf("%s-%s", r.Startport, r.Endport))
+		}
+	}
+
+	return nil
+}
+
+func resourceCloudStackNetworkACLItemUpdate(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Create a new parameter struct
+	p := cs.NetworkACL.NewUpdateNetworkACLItemParams(d.Id())
+
+	// Set all fields that can be updated in place
+	p.SetAction(d.Get("action").(string))
+	p.SetTraffictype(d.Get("traffic_type").(string))
+	p.SetReason(d.Get("description").(string))
+
+	var cidrList []string
+	for _, cidr := range d.Get("cidr_list").(*schema.Set).List() {
+		cidrList = append(cidrList, cidr.(string))
+	}
+	p.SetCidrlist(cidrList)
+
+	if d.HasChange("rule_number") {
+		p.SetNumber(d.Get("rule_number").(int))
+	}
+
+	// Update the network ACL item
+	_, err := cs.NetworkACL.UpdateNetworkACLItem(p)
+	if err != nil {
+		return fmt.Errorf("Error updating network ACL item %s: %s", d.Id(), err)
+	}
+
+	return resourceCloudStackNetworkACLItemRead(d, meta)
+}
+
+func resourceCloudStackNetworkACLItemDelete(d *schema.ResourceData, meta interface{}) error {
+	cs := meta.(*Client)
+
+	// Create a new parameter struct
+	p := cs.NetworkACL.NewDeleteNetworkACLParams(d.Id())
+
+	// Delete the network ACL item
+	_, err := cs.NetworkACL.DeleteNetworkACL(p)
+	if err != nil {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("Error deleting network ACL item %s: %s", d.Id(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+func TestAccCloudStackNetworkACLItem_basic(t *testing.T) {
+	var item cloudstack.NetworkACL
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLItemDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACLItem_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLItemExists(
+						"cloudstack_network_acl_item.foo", &item),
+					testAccCheckCloudStackNetworkACLItemAttributes(&item),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "port", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.bar", "protocol", "all"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCloudStackNetworkACLItem_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLItemDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACLItem_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl_item.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackNetworkACLItem_simulator(t *testing.T) {
+	var item, updated cloudstack.NetworkACL
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLItemDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACLItem_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLItemExists(
+						"cloudstack_network_acl_item.foo", &item),
+					testAccCheckCloudStackNetworkACLItemAttributes(&item),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "port", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.bar", "protocol", "all"),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl_item.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl_item.bar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLItem_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLItemExists(
+						"cloudstack_network_acl_item.foo", &updated),
+					func(*terraform.State) error {
+						if updated.Id != item.Id {
+							return fmt.Errorf("Network ACL item was recreated")
+						}
+						return nil
+					},
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "action", "allow"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "cidr_list.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "rule_number", "5"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_item.foo", "description", "web traffic"),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLItem_unmanaged,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLItemExists(
+						"cloudstack_network_acl_item.foo", &item),
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl_rule.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckCloudStackNetworkACLItemExists(
+	n string, item *cloudstack.NetworkACL) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No network ACL item ID is set")
+		}
+
+		cs := testAccProvider.Meta().(*Client)
+		r, _, err := cs.NetworkACL.GetNetworkACLByID(rs.Primary.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if r.Id != rs.Primary.ID {
+			return fmt.Errorf("Network ACL item not found")
+		}
+
+		*item = *r
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackNetworkACLItemAttributes(
+	item *cloudstack.NetworkACL) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if item.Action != "Deny" {
+			return fmt.Errorf("Bad action: %s", item.Action)
+		}
+
+		if item.Protocol != "tcp" {
+			return fmt.Errorf("Bad protocol: %s", item.Protocol)
+		}
+
+		if item.Traffictype != "Egress" {
+			return fmt.Errorf("Bad traffic type: %s", item.Traffictype)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckCloudStackNetworkACLItemDestroy(s *terraform.State) error {
+	cs := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "cloudstack_network_acl_item" {
+			continue
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No network ACL item ID is set")
+		}
+
+		_, _, err := cs.NetworkACL.GetNetworkACLByID(rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Network ACL item %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+const testAccCloudStackNetworkACLItem_basic = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_item" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  action = "deny"
+  cidr_list = ["10.0.0.0/24"]
+  protocol = "tcp"
+  port = "80"
+  traffic_type = "egress"
+}
+
+resource "cloudstack_network_acl_item" "bar" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  cidr_list = ["172.18.100.0/24"]
+  protocol = "all"
+}`
+
+const testAccCloudStackNetworkACLItem_unmanaged = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_item" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  action = "deny"
+  cidr_list = ["10.0.0.0/24"]
+  protocol = "tcp"
+  port = "80"
+  traffic_type = "egress"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  managed = false
+
+  rule {
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["80", "443"]
+    traffic_type = "ingress"
+  }
+}`
+
+const testAccCloudStackNetworkACLItem_update = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_item" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  rule_number = 5
+  action = "allow"
+  cidr_list = ["10.0.0.0/24", "10.0.1.0/24"]
+  protocol = "tcp"
+  port = "80"
+  traffic_type = "egress"
+  description = "web traffic"
+}
+
+resource "cloudstack_network_acl_item" "bar" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+  cidr_list = ["172.18.100.0/24"]
+  protocol = "all"
+}`
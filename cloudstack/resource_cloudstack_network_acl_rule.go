@@ -22,13 +22,14 @@ package cloudstack
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/helper/customdiff"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -38,6 +39,14 @@ func resourceCloudStackNetworkACLRule() *schema.Resource {
 		Read:   resourceCloudStackNetworkACLRuleRead,
 		Update: resourceCloudStackNetworkACLRuleUpdate,
 		Delete: resourceCloudStackNetworkACLRuleDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackNetworkACLRuleImport,
+		},
+
+		CustomizeDiff: customdiff.All(
+			verifyRulesDiff("rule", verifyRuleProtocolParams),
+			verifyNetworkACLRuleNumbers,
+		),
 
 		Schema: map[string]*schema.Schema{
 			"acl_id": {
@@ -55,57 +64,8 @@ func resourceCloudStackNetworkACLRule() *schema.Resource {
 			"rule": {
 				Type:     schema.TypeSet,
 				Optional: true,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"action": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "allow",
-						},
-
-						"cidr_list": {
-							Type:     schema.TypeSet,
-							Required: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
-						},
-
-						"protocol": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"icmp_type": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
-						},
-
-						"icmp_code": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
-						},
-
-						"ports": {
-							Type:     schema.TypeSet,
-							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
-						},
-
-						"traffic_type": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "ingress",
-						},
-
-						"uuids": {
-							Type:     schema.TypeMap,
-							Computed: true,
-						},
-					},
-				},
+				Set:      networkACLRuleHash,
+				Elem:     networkACLRuleElem(),
 			},
 
 			"project": {
@@ -123,6 +83,83 @@ func resourceCloudStackNetworkACLRule() *schema.Resource {
 	}
 }
 
+// networkACLRuleElem returns the schema of a single rule.
+func networkACLRuleElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"rule_number": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+
+			"action": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "allow",
+				ValidateFunc: validation.StringInSlice([]string{"allow", "deny"}, false),
+			},
+
+			"cidr_list": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateCIDR,
+				},
+				Set: schema.HashString,
+			},
+
+			"protocol": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateProtocol(true, "tcp", "udp", "icmp", "all"),
+			},
+
+			"icmp_type": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"icmp_code": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(-1, 255),
+			},
+
+			"ports": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validatePorts,
+				},
+				Set: schema.HashString,
+			},
+
+			"traffic_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "ingress",
+				ValidateFunc: validation.StringInSlice([]string{"ingress", "egress"}, false),
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"uuids": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+		},
+	}
+}
+
 func resourceCloudStackNetworkACLRuleCreate(d *schema.ResourceData, meta interface{}) error {
 	// Make sure all required parameters are there
 	if err := verifyNetworkACLParams(d); err != nil {
@@ -137,7 +174,7 @@ func resourceCloudStackNetworkACLRuleCreate(d *schema.ResourceData, meta interfa
 		// Create an empty rule set to hold all newly created rules
 		rules := resourceCloudStackNetworkACLRule().Schema["rule"].ZeroValue().(*schema.Set)
 
-		err := createNetworkACLRules(d, meta, rules, nrs)
+		err := createRules(networkACLRuleHandler(d, meta), rules, nrs)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -150,56 +187,38 @@ func resourceCloudStackNetworkACLRuleCreate(d *schema.ResourceData, meta interfa
 	return resourceCloudStackNetworkACLRuleRead(d, meta)
 }
 
-func createNetworkACLRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, nrs *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(nrs.Len())
-
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range nrs.List() {
-		// Put in a tiny sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Create a single rule
-			err := createNetworkACLRule(d, meta, rule)
-
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
+// networkACLRuleHandler returns the handler used to create and delete the
+// rules of the network ACL.
+func networkACLRuleHandler(d *schema.ResourceData, meta interface{}) *ruleHandler {
+	cs := meta.(*Client)
+	aclid := d.Id()
+
+	return &ruleHandler{
+		parallelism: d.Get("parallelism").(int),
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
+			// Make sure all required parameters are there
+			if err := verifyNetworkACLRuleParams(d, rule); err != nil {
+				return nil, err
 			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			}
-
-			<-sem
-		}(rule.(map[string]interface{}))
+			return protocolRuleItems(rule), nil
+		},
+		create: func(rule map[string]interface{}, item ruleItem) (string, error) {
+			return createNetworkACLRule(cs, aclid, rule, item)
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			p := cs.NetworkACL.NewDeleteNetworkACLParams(id)
+			_, err := cs.NetworkACL.DeleteNetworkACL(p)
+			return err
+		},
 	}
-
-	wg.Wait()
-
-	return errs.ErrorOrNil()
 }
 
-func createNetworkACLRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
-
-	// Make sure all required parameters are there
-	if err := verifyNetworkACLRuleParams(d, rule); err != nil {
-		return err
-	}
-
+func createNetworkACLRule(cs *Client, aclid string, rule map[string]interface{}, item ruleItem) (string, error) {
 	// Create a new parameter struct
 	p := cs.NetworkACL.NewCreateNetworkACLParams(rule["protocol"].(string))
 
 	// Set the acl ID
-	p.SetAclid(d.Id())
+	p.SetAclid(aclid)
 
 	// Set the action
 	p.SetAction(rule["action"].(string))
@@ -214,90 +233,51 @@ func createNetworkACLRule(d *schema.ResourceData, meta interface{}, rule map[str
 	// Set the traffic type
 	p.SetTraffictype(rule["traffic_type"].(string))
 
+	// Set the description
+	if description := rule["description"].(string); description != "" {
+		p.SetReason(description)
+	}
+
 	// If the protocol is ICMP set the needed ICMP parameters
 	if rule["protocol"].(string) == "icmp" {
 		p.SetIcmptype(rule["icmp_type"].(int))
 		p.SetIcmpcode(rule["icmp_code"].(int))
-
-		r, err := Retry(4, retryableACLCreationFunc(cs, p))
-		if err != nil {
-			return err
-		}
-
-		uuids["icmp"] = r.(*cloudstack.CreateNetworkACLResponse).Id
-		rule["uuids"] = uuids
 	}
 
-	// If the protocol is ALL set the needed parameters
-	if rule["protocol"].(string) == "all" {
-		r, err := Retry(4, retryableACLCreationFunc(cs, p))
+	// If the item has a port, set the port range
+	if item.port != "" {
+		startPort, endPort, err := splitPortRange(item.port)
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		uuids["all"] = r.(*cloudstack.CreateNetworkACLResponse).Id
-		rule["uuids"] = uuids
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
 	}
 
-	// If protocol is TCP or UDP, loop through all ports
-	if rule["protocol"].(string) == "tcp" || rule["protocol"].(string) == "udp" {
-		if ps := rule["ports"].(*schema.Set); ps.Len() > 0 {
-
-			// Create an empty schema.Set to hold all processed ports
-			ports := &schema.Set{F: schema.HashString}
-
-			for _, port := range ps.List() {
-				if _, ok := uuids[port.(string)]; ok {
-					ports.Add(port)
-					rule["ports"] = ports
-					continue
-				}
-
-				m := splitPorts.FindStringSubmatch(port.(string))
-
-				startPort, err := strconv.Atoi(m[1])
-				if err != nil {
-					return err
-				}
-
-				endPort := startPort
-				if m[2] != "" {
-					endPort, err = strconv.Atoi(m[2])
-					if err != nil {
-						return err
-					}
-				}
-
-				p.SetStartport(startPort)
-				p.SetEndport(endPort)
-
-				r, err := Retry(4, retryableACLCreationFunc(cs, p))
-				if err != nil {
-					return err
-				}
-
-				ports.Add(port)
-				rule["ports"] = ports
+	// Set the number to use for the item, if any
+	if number, ok := networkACLRuleNumbers(rule)[item.key]; ok {
+		p.SetNumber(number)
+	}
 
-				uuids[port.(string)] = r.(*cloudstack.CreateNetworkACLResponse).Id
-				rule["uuids"] = uuids
-			}
-		}
+	r, err := Retry(4, retryableACLCreationFunc(cs, p))
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return r.(*cloudstack.CreateNetworkACLResponse).Id, nil
 }
 
 func resourceCloudStackNetworkACLRuleRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// First check if the ACL itself still exists
-	_, count, err := cs.NetworkACL.GetNetworkACLListByID(
+	_, _, err := cs.NetworkACL.GetNetworkACLListByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] Network ACL list %s does no longer exist", d.Id())
 			d.SetId("")
@@ -361,6 +341,10 @@ func resourceCloudStackNetworkACLRuleRead(d *schema.ResourceData, meta interface
 				rule["icmp_code"] = r.Icmpcode
 				rule["traffic_type"] = strings.ToLower(r.Traffictype)
 				rule["cidr_list"] = cidrs
+				rule["description"] = r.Reason
+				if rule["rule_number"].(int) > 0 {
+					rule["rule_number"] = r.Number
+				}
 				rules.Add(rule)
 			}
 
@@ -391,6 +375,10 @@ func resourceCloudStackNetworkACLRuleRead(d *schema.ResourceData, meta interface
 				rule["protocol"] = r.Protocol
 				rule["traffic_type"] = strings.ToLower(r.Traffictype)
 				rule["cidr_list"] = cidrs
+				rule["description"] = r.Reason
+				if rule["rule_number"].(int) > 0 {
+					rule["rule_number"] = r.Number
+				}
 				rules.Add(rule)
 			}
 
@@ -401,6 +389,10 @@ func resourceCloudStackNetworkACLRuleRead(d *schema.ResourceData, meta interface
 					// Create an empty schema.Set to hold all ports
 					ports := &schema.Set{F: schema.HashString}
 
+					// Get the numbers the ACL items of this rule should have
+					number := rule["rule_number"].(int)
+					numbers := networkACLRuleNumbers(rule)
+
 					// Loop through all ports and retrieve their info
 					for _, port := range ps.List() {
 						id, ok := uuids[port.(string)]
@@ -429,7 +421,13 @@ func resourceCloudStackNetworkACLRuleRead(d *schema.ResourceData, meta interface
 						rule["protocol"] = r.Protocol
 						rule["traffic_type"] = strings.ToLower(r.Traffictype)
 						rule["cidr_list"] = cidrs
+						rule["description"] = r.Reason
 						ports.Add(port)
+
+						// The ACL item of the first port holds the rule number
+						if number > 0 && numbers[port.(string)] == number {
+							rule["rule_number"] = r.Number
+						}
 					}
 
 					// If there is at least one port found, add this rule to the rules set
@@ -480,19 +478,47 @@ func resourceCloudStackNetworkACLRuleUpdate(d *schema.ResourceData, meta interfa
 
 	// Check if the rule set as a whole has changed
 	if d.HasChange("rule") {
-		o, n := d.GetChange("rule")
-		ors := o.(*schema.Set).Difference(n.(*schema.Set))
-		nrs := n.(*schema.Set).Difference(o.(*schema.Set))
-
 		// We need to start with a rule set containing all the rules we
 		// already have and want to keep. Any rules that are not deleted
 		// correctly and any newly created rules, will be added to this
 		// set to make sure we end up in a consistent state
-		rules := o.(*schema.Set).Intersection(n.(*schema.Set))
+		rules, ors, nrs := diffRules(d, "rule")
 
-		// First loop through all the new rules and create (before destroy) them
+		// Find the changed rules that can be updated in place
+		urs := pairNetworkACLRules(ors, nrs)
+
+		// Rules using a number that is currently used by one of the old
+		// rules can only be created or updated after the old rule is deleted
+		if drs := conflictingNetworkACLRules(ors, nrs, urs); drs.Len() > 0 {
+			for _, rule := range drs.List() {
+				ors.Remove(rule)
+			}
+
+			err := deleteRules(networkACLRuleHandler(d, meta), rules, drs)
+
+			// We need to update this first to preserve the correct state
+			d.Set("rule", rules)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// Then update all rules that can be updated in place
+		if len(urs) > 0 {
+			err := updateNetworkACLRules(d, meta, rules, urs)
+
+			// We need to update this first to preserve the correct state
+			d.Set("rule", rules)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// Then loop through all the new rules and create (before destroy) them
 		if nrs.Len() > 0 {
-			err := createNetworkACLRules(d, meta, rules, nrs)
+			err := createRules(networkACLRuleHandler(d, meta), rules, nrs)
 
 			// We need to update this first to preserve the correct state
 			d.Set("rule", rules)
@@ -504,7 +530,7 @@ func resourceCloudStackNetworkACLRuleUpdate(d *schema.ResourceData, meta interfa
 
 		// Then loop through all the old rules and delete them
 		if ors.Len() > 0 {
-			err := deleteNetworkACLRules(d, meta, rules, ors)
+			err := deleteRules(networkACLRuleHandler(d, meta), rules, ors)
 
 			// We need to update this first to preserve the correct state
 			d.Set("rule", rules)
@@ -518,6 +544,263 @@ func resourceCloudStackNetworkACLRuleUpdate(d *schema.ResourceData, meta interfa
 	return resourceCloudStackNetworkACLRuleRead(d, meta)
 }
 
+// pairNetworkACLRules finds old and new rules that only differ in fields that
+// can be updated in place, and removes them from the given rule sets. Rules
+// are first paired by their rule number, the remaining rules are paired only
+// if there is exactly one old and one new rule for the same protocol and ports.
+func pairNetworkACLRules(ors *schema.Set, nrs *schema.Set) [][2]map[string]interface{} {
+	var pairs [][2]map[string]interface{}
+
+	pair := func(key func(rule map[string]interface{}) string) {
+		olds := make(map[string][]interface{})
+		for _, rule := range ors.List() {
+			if k := key(rule.(map[string]interface{})); k != "" {
+				olds[k] = append(olds[k], rule)
+			}
+		}
+
+		news := make(map[string][]interface{})
+		for _, rule := range nrs.List() {
+			if k := key(rule.(map[string]interface{})); k != "" {
+				news[k] = append(news[k], rule)
+			}
+		}
+
+		for k, o := range olds {
+			n, ok := news[k]
+			if !ok || len(o) != 1 || len(n) != 1 {
+				continue
+			}
+
+			ors.Remove(o[0])
+			nrs.Remove(n[0])
+
+			pairs = append(pairs, [2]map[string]interface{}{
+				o[0].(map[string]interface{}),
+				n[0].(map[string]interface{}),
+			})
+		}
+	}
+
+	// First pair the rules that have the same rule number
+	pair(func(rule map[string]interface{}) string {
+		if rule["rule_number"].(int) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d|%s", rule["rule_number"].(int), networkACLRuleKey(rule))
+	})
+
+	// Then pair the remaining rules by only their protocol and ports
+	pair(networkACLRuleKey)
+
+	return pairs
+}
+
+// networkACLRuleKey returns a key identifying the ACL items of a rule, which
+// can not be changed without recreating the ACL items.
+func networkACLRuleKey(rule map[string]interface{}) string {
+	var ports []string
+	for _, port := range rule["ports"].(*schema.Set).List() {
+		ports = append(ports, port.(string))
+	}
+	sort.Strings(ports)
+
+	return fmt.Sprintf("%s|%d|%d|%s",
+		rule["protocol"].(string),
+		rule["icmp_type"].(int),
+		rule["icmp_code"].(int),
+		strings.Join(ports, ","),
+	)
+}
+
+// conflictingNetworkACLRules returns the old rules that use a number which
+// is also used by one of the new or updated rules.
+func conflictingNetworkACLRules(ors *schema.Set, nrs *schema.Set, urs [][2]map[string]interface{}) *schema.Set {
+	used := make(map[int]bool)
+	for _, rule := range nrs.List() {
+		for _, number := range networkACLRuleNumbers(rule.(map[string]interface{})) {
+			used[number] = true
+		}
+	}
+	for _, pair := range urs {
+		for _, number := range networkACLRuleNumbers(pair[1]) {
+			used[number] = true
+		}
+	}
+
+	drs := &schema.Set{F: ors.F}
+	for _, rule := range ors.List() {
+		for _, number := range networkACLRuleNumbers(rule.(map[string]interface{})) {
+			if used[number] {
+				drs.Add(rule)
+				break
+			}
+		}
+	}
+
+	return drs
+}
+
+func updateNetworkACLRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, urs [][2]map[string]interface{}) error {
+	var errs *multierror.Error
+
+	// Rules whose numbers are taken over by other updated rules (when numbers
+	// are swapped or shifted) are first moved to free numbers, so the updates
+	// can run in parallel without using the same number twice
+	if blocking := blockingNetworkACLRules(urs); len(blocking) > 0 {
+		if err := moveNetworkACLRules(d, meta, urs, blocking); err != nil {
+			// None of the rules are updated yet, so keep all old rules
+			for _, pair := range urs {
+				rules.Add(pair[0])
+			}
+			return err
+		}
+	}
+
+	results := runParallel(d.Get("parallelism").(int), len(urs), func(i int) error {
+		// Update a single rule
+		return updateNetworkACLRule(d, meta, urs[i][0], urs[i][1])
+	})
+
+	// Save the updated rule, or the old rule if the update failed
+	for i, pair := range urs {
+		if results[i] != nil {
+			rules.Add(pair[0])
+			errs = multierror.Append(errs, results[i])
+		} else {
+			rules.Add(pair[1])
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// blockingNetworkACLRules returns the indexes of the updated rules that
+// currently use a number which another updated rule will use.
+func blockingNetworkACLRules(urs [][2]map[string]interface{}) []int {
+	owners := make(map[int]int)
+	for i, pair := range urs {
+		for _, number := range networkACLRuleNumbers(pair[1]) {
+			owners[number] = i
+		}
+	}
+
+	var blocking []int
+	for i, pair := range urs {
+		for _, number := range networkACLRuleNumbers(pair[0]) {
+			if owner, ok := owners[number]; ok && owner != i {
+				blocking = append(blocking, i)
+				break
+			}
+		}
+	}
+
+	return blocking
+}
+
+// moveNetworkACLRules moves the ACL items of the given updated rules to
+// numbers that are not used by any ACL item of the ACL, nor by any of the
+// updated rules. The items are moved one by one to prevent any conflicts.
+func moveNetworkACLRules(d *schema.ResourceData, meta interface{}, urs [][2]map[string]interface{}, indexes []int) error {
+	cs := meta.(*Client)
+
+	// Get all the numbers currently used by the ACL items of the ACL
+	p := cs.NetworkACL.NewListNetworkACLsParams()
+	p.SetAclid(d.Id())
+	p.SetListall(true)
+
+	l, err := cs.NetworkACL.ListNetworkACLs(p)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[int]bool)
+	for _, r := range l.NetworkACLs {
+		used[r.Number] = true
+	}
+	for _, pair := range urs {
+		for _, number := range networkACLRuleNumbers(pair[1]) {
+			used[number] = true
+		}
+	}
+
+	// Use the highest free numbers, as those are the least likely to be used
+	free := 65535
+	for _, i := range indexes {
+		uuids := urs[i][0]["uuids"].(map[string]interface{})
+
+		for k := range networkACLRuleNumbers(urs[i][0]) {
+			id, ok := uuids[k]
+			if !ok {
+				continue
+			}
+
+			for used[free] {
+				free--
+			}
+			used[free] = true
+
+			log.Printf("[DEBUG] Temporarily moving ACL item %s to number %d", id.(string), free)
+
+			p := cs.NetworkACL.NewUpdateNetworkACLItemParams(id.(string))
+			p.SetNumber(free)
+
+			if _, err := cs.NetworkACL.UpdateNetworkACLItem(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func updateNetworkACLRule(d *schema.ResourceData, meta interface{}, orule, nrule map[string]interface{}) error {
+	cs := meta.(*Client)
+	uuids := orule["uuids"].(map[string]interface{})
+
+	// Make sure all required parameters are there
+	if err := verifyNetworkACLRuleParams(d, nrule); err != nil {
+		return err
+	}
+
+	// Set the CIDR list
+	var cidrList []string
+	for _, cidr := range nrule["cidr_list"].(*schema.Set).List() {
+		cidrList = append(cidrList, cidr.(string))
+	}
+
+	// Get the numbers to use for the rule, if any
+	numbers := networkACLRuleNumbers(nrule)
+
+	for k, id := range uuids {
+		// We don't care about the count here, so just continue
+		if k == "%" {
+			continue
+		}
+
+		// Create a new parameter struct
+		p := cs.NetworkACL.NewUpdateNetworkACLItemParams(id.(string))
+		p.SetAction(nrule["action"].(string))
+		p.SetCidrlist(cidrList)
+		p.SetTraffictype(nrule["traffic_type"].(string))
+		p.SetReason(nrule["description"].(string))
+
+		if number, ok := numbers[k]; ok {
+			p.SetNumber(number)
+		}
+
+		// Update the ACL item
+		if _, err := cs.NetworkACL.UpdateNetworkACLItem(p); err != nil {
+			return err
+		}
+	}
+
+	// The ACL items are not recreated, so keep using the same UUIDs
+	nrule["uuids"] = uuids
+
+	return nil
+}
+
 func resourceCloudStackNetworkACLRuleDelete(d *schema.ResourceData, meta interface{}) error {
 	// Create an empty rule set to hold all rules that where
 	// not deleted correctly
@@ -525,7 +808,7 @@ func resourceCloudStackNetworkACLRuleDelete(d *schema.ResourceData, meta interfa
 
 	// Delete all rules
 	if ors := d.Get("rule").(*schema.Set); ors.Len() > 0 {
-		err := deleteNetworkACLRules(d, meta, rules, ors)
+		err := deleteRules(networkACLRuleHandler(d, meta), rules, ors)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -538,76 +821,123 @@ func resourceCloudStackNetworkACLRuleDelete(d *schema.ResourceData, meta interfa
 	return nil
 }
 
-func deleteNetworkACLRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, ors *schema.Set) error {
-	var errs *multierror.Error
+func resourceCloudStackNetworkACLRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
 
-	var wg sync.WaitGroup
-	wg.Add(ors.Len())
+	// Try to split the ID to extract the optional project name
+	if _, err := importStatePassthrough(d, meta); err != nil {
+		return nil, err
+	}
 
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range ors.List() {
-		// Put a sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
+	// Get all the rules from the running environment
+	p := cs.NetworkACL.NewListNetworkACLsParams()
+	p.SetAclid(d.Id())
+	p.SetListall(true)
 
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
+	l, err := cs.NetworkACL.ListNetworkACLs(p)
+	if err != nil {
+		return nil, err
+	}
 
-			// Delete a single rule
-			err := deleteNetworkACLRule(d, meta, rule)
+	// Rebuild the rules, grouping all rules that only differ in their ports
+	rules := newImportedRules()
+	numbers := make(map[string]int, l.Count)
+	for _, r := range l.NetworkACLs {
+		cidrs, key := cidrSet(r.Cidrlist)
+
+		rule := map[string]interface{}{
+			"action":       strings.ToLower(r.Action),
+			"cidr_list":    cidrs,
+			"protocol":     r.Protocol,
+			"traffic_type": strings.ToLower(r.Traffictype),
+			"description":  r.Reason,
+			"rule_number":  r.Number,
+		}
 
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
+		switch r.Protocol {
+		case "icmp":
+			rule["icmp_type"] = r.Icmptype
+			rule["icmp_code"] = r.Icmpcode
+			rules.add(r.Id, rule, "", r.Id)
+		case "all":
+			rules.add(r.Id, rule, "", r.Id)
+		case "tcp", "udp":
+			startPort, err := strconv.Atoi(r.Startport)
+			if err != nil {
+				return nil, err
 			}
 
+			endPort, err := strconv.Atoi(r.Endport)
 			if err != nil {
-				errs = multierror.Append(errs, err)
+				return nil, err
 			}
 
-			<-sem
-		}(rule.(map[string]interface{}))
+			key = strings.Join([]string{rule["action"].(string), rule["traffic_type"].(string),
+				r.Protocol, key, r.Reason}, "/")
+			rules.add(key, rule, portRange(startPort, endPort), r.Id)
+			numbers[r.Id] = r.Number
+		default:
+			log.Printf("[DEBUG] Skipping ACL item %s with unsupported protocol %s", r.Id, r.Protocol)
+		}
 	}
 
-	wg.Wait()
+	// Grouped rules can only keep a single rule number when the numbers of
+	// their ACL items are consecutive, so split all other rules per port.
+	var rs []interface{}
+	for _, rule := range rules.list() {
+		rule := rule.(map[string]interface{})
+		uuids := rule["uuids"].(map[string]interface{})
 
-	return errs.ErrorOrNil()
-}
+		if rule["ports"].(*schema.Set).Len() < 2 {
+			rs = append(rs, rule)
+			continue
+		}
 
-func deleteNetworkACLRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
+		// Use the number of the first port as the number of the rule
+		rule["rule_number"] = 1
+		first := ""
+		for port, n := range networkACLRuleNumbers(rule) {
+			if n == 1 {
+				first = port
+			}
+		}
+		rule["rule_number"] = numbers[uuids[first].(string)]
 
-	for k, id := range uuids {
-		// We don't care about the count here, so just continue
-		if k == "%" {
+		consecutive := true
+		for port, n := range networkACLRuleNumbers(rule) {
+			if numbers[uuids[port].(string)] != n {
+				consecutive = false
+			}
+		}
+
+		if consecutive {
+			rs = append(rs, rule)
 			continue
 		}
 
-		// Create the parameter struct
-		p := cs.NetworkACL.NewDeleteNetworkACLParams(id.(string))
+		for port, uuid := range uuids {
+			r := make(map[string]interface{}, len(rule))
+			for k, v := range rule {
+				r[k] = v
+			}
 
-		// Delete the rule
-		if _, err := cs.NetworkACL.DeleteNetworkACL(p); err != nil {
+			ports := &schema.Set{F: schema.HashString}
+			ports.Add(port)
 
-			// This is a very poor way to be told the ID does no longer exist :(
-			if strings.Contains(err.Error(), fmt.Sprintf(
-				"Invalid parameter id value=%s due to incorrect long value format, "+
-					"or entity does not exist", id.(string))) {
-				delete(uuids, k)
-				rule["uuids"] = uuids
-				continue
-			}
+			r["ports"] = ports
+			r["rule_number"] = numbers[uuid.(string)]
+			r["uuids"] = map[string]interface{}{port: uuid}
 
-			return err
+			rs = append(rs, r)
 		}
-
-		// Delete the UUID of this rule
-		delete(uuids, k)
-		rule["uuids"] = uuids
 	}
 
-	return nil
+	d.Set("acl_id", d.Id())
+	d.Set("managed", false)
+	d.Set("parallelism", 2)
+	d.Set("rule", rs)
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func verifyNetworkACLParams(d *schema.ResourceData) error {
@@ -644,10 +974,8 @@ func verifyNetworkACLRuleParams(d *schema.ResourceData, rule map[string]interfac
 	case "tcp", "udp":
 		if ports, ok := rule["ports"].(*schema.Set); ok {
 			for _, port := range ports.List() {
-				m := splitPorts.FindStringSubmatch(port.(string))
-				if m == nil {
-					return fmt.Errorf(
-						"%q is not a valid port value. Valid options are '80' or '80-90'", port.(string))
+				if _, _, err := splitPortRange(port.(string)); err != nil {
+					return err
 				}
 			}
 		} else {
@@ -672,8 +1000,104 @@ func verifyNetworkACLRuleParams(d *schema.ResourceData, rule map[string]interfac
 	return nil
 }
 
+// networkACLRuleNumbers returns the number of the ACL item of each protocol
+// or port of the given rule. Rules with multiple ports get consecutive
+// numbers (ordered by port), starting at the rule number of the rule.
+func networkACLRuleNumbers(rule map[string]interface{}) map[string]int {
+	numbers := make(map[string]int)
+
+	number := rule["rule_number"].(int)
+	if number == 0 {
+		return numbers
+	}
+
+	switch protocol := rule["protocol"].(string); protocol {
+	case "tcp", "udp":
+		var ports []string
+		for _, port := range rule["ports"].(*schema.Set).List() {
+			ports = append(ports, port.(string))
+		}
+
+		sort.Slice(ports, func(i, j int) bool {
+			si, _, _ := splitPortRange(ports[i])
+			sj, _, _ := splitPortRange(ports[j])
+			if si != sj {
+				return si < sj
+			}
+			return ports[i] < ports[j]
+		})
+
+		for i, port := range ports {
+			numbers[port] = number + i
+		}
+	default:
+		numbers[protocol] = number
+	}
+
+	return numbers
+}
+
+// verifyNetworkACLRuleNumbers verifies that the numbers used by the rules do
+// not overlap, as each ACL item of a network ACL needs a unique number.
+func verifyNetworkACLRuleNumbers(d *schema.ResourceDiff, meta interface{}) error {
+	// We can only verify the rules once all values are known
+	if !d.NewValueKnown("rule") {
+		return nil
+	}
+
+	used := make(map[int]int)
+	for _, rule := range d.Get("rule").(*schema.Set).List() {
+		rule := rule.(map[string]interface{})
+
+		for _, number := range networkACLRuleNumbers(rule) {
+			if n, ok := used[number]; ok {
+				return fmt.Errorf(
+					"Rules %d and %d both use number %d. Rules with multiple ports "+
+						"use a consecutive number for each port", n, rule["rule_number"].(int), number)
+			}
+			used[number] = rule["rule_number"].(int)
+		}
+	}
+
+	return nil
+}
+
+// networkACLRuleHash hashes a rule like schema.HashResource does, but leaves
+// out the rule number and description when they are not set, so the hashes
+// of rules created before these fields were added don't change.
+func networkACLRuleHash(v interface{}) int {
+	rule := v.(map[string]interface{})
+
+	number, _ := rule["rule_number"].(int)
+	description, _ := rule["description"].(string)
+
+	return networkACLRuleHashes[[2]bool{number != 0, description != ""}](v)
+}
+
+// networkACLRuleHashes holds the hash functions used by networkACLRuleHash,
+// keyed by whether the rule number and description are set, so the rule
+// schema is only built once.
+var networkACLRuleHashes = func() map[[2]bool]schema.SchemaSetFunc {
+	hashes := make(map[[2]bool]schema.SchemaSetFunc)
+
+	for _, number := range []bool{true, false} {
+		for _, description := range []bool{true, false} {
+			r := networkACLRuleElem()
+			if !number {
+				delete(r.Schema, "rule_number")
+			}
+			if !description {
+				delete(r.Schema, "description")
+			}
+			hashes[[2]bool{number, description}] = schema.HashResource(r)
+		}
+	}
+
+	return hashes
+}()
+
 func retryableACLCreationFunc(
-	cs *cloudstack.CloudStackClient,
+	cs *Client,
 	p *cloudstack.CreateNetworkACLParams) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		r, err := cs.NetworkACL.CreateNetworkACL(p)
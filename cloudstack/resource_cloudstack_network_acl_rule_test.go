@@ -21,12 +21,12 @@ package cloudstack
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func TestAccCloudStackNetworkACLRule_basic(t *testing.T) {
@@ -168,6 +168,241 @@ func TestAccCloudStackNetworkACLRule_update(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackNetworkACLRule_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACLRule_numberReused,
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackNetworkACLRule_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACLRule_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.#", "3"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.action", "allow"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.cidr_list.2835005819", "172.16.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.ports.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.ports.3638101695", "443"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2898748868.traffic_type", "ingress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.action", "allow"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.cidr_list.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.icmp_code", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.icmp_type", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1480917538.traffic_type", "ingress"),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLRule_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.#", "4"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.action", "deny"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.cidr_list.3482919157", "10.0.0.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.ports.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.ports.1209010669", "1000-2000"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.1724235854.traffic_type", "egress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.action", "deny"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.cidr_list.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.cidr_list.2104435309", "172.18.101.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.icmp_code", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.icmp_type", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2090315355.traffic_type", "ingress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.action", "allow"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.ports.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.ports.3638101695", "443"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network_acl_rule.foo", "rule.2576683033.traffic_type", "ingress"),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackNetworkACLRule_simulatorRuleNumber(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCloudStackNetworkACLRule_overlappingNumbers,
+				ExpectError: regexp.MustCompile("Rules 10 and 11 both use number 11"),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLRule_numbered,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl_rule.foo"),
+					testAccCheckCloudStackNetworkACLItems("cloudstack_network_acl_rule.foo", map[string]string{
+						"tcp:80":  "10 Allow Ingress web",
+						"tcp:443": "11 Allow Ingress web",
+						"icmp:":   "20 Allow Ingress ",
+						"all:":    "30 Deny Ingress ",
+					}),
+					testAccCheckSimulatorCount(sim, "createNetworkACL", 4),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLRule_numberedUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl_rule.foo"),
+					testAccCheckCloudStackNetworkACLItems("cloudstack_network_acl_rule.foo", map[string]string{
+						"tcp:80":   "10 Deny Ingress web traffic",
+						"tcp:443":  "11 Deny Ingress web traffic",
+						"tcp:8080": "12 Allow Ingress ",
+						"icmp:":    "20 Allow Ingress ",
+						"all:":     "30 Deny Egress ",
+					}),
+					testAccCheckSimulatorCount(sim, "createNetworkACL", 5),
+					testAccCheckSimulatorCount(sim, "updateNetworkACLItem", 3),
+					testAccCheckSimulatorCount(sim, "deleteNetworkACL", 0),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLRule_numberReused,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl_rule.foo"),
+					testAccCheckCloudStackNetworkACLItems("cloudstack_network_acl_rule.foo", map[string]string{
+						"tcp:80":   "10 Deny Ingress web traffic",
+						"tcp:443":  "11 Deny Ingress web traffic",
+						"tcp:8080": "12 Allow Ingress ",
+						"icmp:":    "20 Allow Ingress ",
+						"tcp:22":   "30 Allow Ingress ",
+					}),
+					testAccCheckSimulatorCount(sim, "deleteNetworkACL", 1),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetworkACLRule_numbersSwapped,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkACLRulesExist("cloudstack_network_acl_rule.foo"),
+					testAccCheckCloudStackNetworkACLItems("cloudstack_network_acl_rule.foo", map[string]string{
+						"tcp:80":   "10 Deny Ingress web traffic",
+						"tcp:443":  "11 Deny Ingress web traffic",
+						"tcp:8080": "12 Allow Ingress ",
+						"icmp:":    "30 Allow Ingress ",
+						"tcp:22":   "20 Allow Ingress ",
+					}),
+					testAccCheckSimulatorCount(sim, "createNetworkACL", 6),
+					testAccCheckSimulatorCount(sim, "deleteNetworkACL", 1),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testAccCheckCloudStackNetworkACLItems checks the number, action, traffic
+// type and description of all ACL items of the ACL, keyed by protocol and
+// start port.
+func testAccCheckCloudStackNetworkACLItems(n string, items map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		cs := testAccProvider.Meta().(*Client)
+		p := cs.NetworkACL.NewListNetworkACLsParams()
+		p.SetAclid(rs.Primary.ID)
+
+		l, err := cs.NetworkACL.ListNetworkACLs(p)
+		if err != nil {
+			return err
+		}
+
+		if l.Count != len(items) {
+			return fmt.Errorf("Expected %d ACL items, got %d", len(items), l.Count)
+		}
+
+		for _, r := range l.NetworkACLs {
+			k := r.Protocol + ":" + r.Startport
+			v := fmt.Sprintf("%d %s %s %s", r.Number, r.Action, r.Traffictype, r.Reason)
+			if items[k] != v {
+				return fmt.Errorf("Bad ACL item %s: expected %q, got %q", k, items[k], v)
+			}
+		}
+
+		return nil
+	}
+}
+
 func testAccCheckCloudStackNetworkACLRulesExist(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -184,7 +419,7 @@ func testAccCheckCloudStackNetworkACLRulesExist(n string) resource.TestCheckFunc
 				continue
 			}
 
-			cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+			cs := testAccProvider.Meta().(*Client)
 			_, count, err := cs.NetworkACL.GetNetworkACLByID(id)
 
 			if err != nil {
@@ -201,7 +436,7 @@ func testAccCheckCloudStackNetworkACLRulesExist(n string) resource.TestCheckFunc
 }
 
 func testAccCheckCloudStackNetworkACLRuleDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_network_acl_rule" {
@@ -317,3 +552,228 @@ resource "cloudstack_network_acl_rule" "foo" {
     traffic_type = "egress"
   }
 }`
+
+const testAccCloudStackNetworkACLRule_numbered = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+
+  rule {
+    rule_number = 10
+    action = "allow"
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["443", "80"]
+    description = "web"
+  }
+
+  rule {
+    rule_number = 20
+    cidr_list = ["172.18.100.0/24"]
+    protocol = "icmp"
+    icmp_type = -1
+    icmp_code = -1
+  }
+
+  rule {
+    rule_number = 30
+    action = "deny"
+    cidr_list = ["0.0.0.0/0"]
+    protocol = "all"
+    traffic_type = "ingress"
+  }
+}`
+
+const testAccCloudStackNetworkACLRule_numberedUpdate = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+
+  rule {
+    rule_number = 10
+    action = "deny"
+    cidr_list = ["172.16.100.0/24", "172.16.101.0/24"]
+    protocol = "tcp"
+    ports = ["443", "80"]
+    description = "web traffic"
+  }
+
+  rule {
+    rule_number = 12
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["8080"]
+  }
+
+  rule {
+    rule_number = 20
+    cidr_list = ["172.18.100.0/24"]
+    protocol = "icmp"
+    icmp_type = -1
+    icmp_code = -1
+  }
+
+  rule {
+    rule_number = 30
+    action = "deny"
+    cidr_list = ["0.0.0.0/0"]
+    protocol = "all"
+    traffic_type = "egress"
+  }
+}`
+
+const testAccCloudStackNetworkACLRule_numberReused = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+
+  rule {
+    rule_number = 10
+    action = "deny"
+    cidr_list = ["172.16.100.0/24", "172.16.101.0/24"]
+    protocol = "tcp"
+    ports = ["443", "80"]
+    description = "web traffic"
+  }
+
+  rule {
+    rule_number = 12
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["8080"]
+  }
+
+  rule {
+    rule_number = 20
+    cidr_list = ["172.18.100.0/24"]
+    protocol = "icmp"
+    icmp_type = -1
+    icmp_code = -1
+  }
+
+  rule {
+    rule_number = 30
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["22"]
+  }
+}`
+
+const testAccCloudStackNetworkACLRule_numbersSwapped = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+
+  rule {
+    rule_number = 10
+    action = "deny"
+    cidr_list = ["172.16.100.0/24", "172.16.101.0/24"]
+    protocol = "tcp"
+    ports = ["443", "80"]
+    description = "web traffic"
+  }
+
+  rule {
+    rule_number = 12
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["8080"]
+  }
+
+  rule {
+    rule_number = 30
+    cidr_list = ["172.18.100.0/24"]
+    protocol = "icmp"
+    icmp_type = -1
+    icmp_code = -1
+  }
+
+  rule {
+    rule_number = 20
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["22"]
+  }
+}`
+
+const testAccCloudStackNetworkACLRule_overlappingNumbers = `
+resource "cloudstack_vpc" "foo" {
+  name = "terraform-vpc"
+  cidr = "10.0.0.0/8"
+  vpc_offering = "Default VPC offering"
+  zone = "Sandbox-simulator"
+}
+
+resource "cloudstack_network_acl" "foo" {
+  name = "terraform-acl"
+  description = "terraform-acl-text"
+  vpc_id = "${cloudstack_vpc.foo.id}"
+}
+
+resource "cloudstack_network_acl_rule" "foo" {
+  acl_id = "${cloudstack_network_acl.foo.id}"
+
+  rule {
+    rule_number = 10
+    action = "allow"
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["443", "80"]
+    description = "web"
+  }
+
+  rule {
+    rule_number = 11
+    cidr_list = ["172.16.100.0/24"]
+    protocol = "tcp"
+    ports = ["8080"]
+  }
+}`
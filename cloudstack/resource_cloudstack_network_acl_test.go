@@ -66,6 +66,27 @@ func TestAccCloudStackNetworkACL_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackNetworkACL_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkACLDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetworkACL_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_network_acl.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackNetworkACLExists(
 	n string, acl *cloudstack.NetworkACLList) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -78,7 +99,7 @@ func testAccCheckCloudStackNetworkACLExists(
 			return fmt.Errorf("No network ACL ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		acllist, _, err := cs.NetworkACL.GetNetworkACLListByID(rs.Primary.ID)
 		if err != nil {
 			return err
@@ -111,7 +132,7 @@ func testAccCheckCloudStackNetworkACLBasicAttributes(
 }
 
 func testAccCheckCloudStackNetworkACLDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_network_acl" {
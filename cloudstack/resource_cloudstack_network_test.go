@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -158,6 +159,139 @@ func TestAccCloudStackNetwork_importProject(t *testing.T) {
 	})
 }
 
+func TestCloudStackNetwork_simulator(t *testing.T) {
+	var network cloudstack.Network
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetwork_acl,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkExists(
+						"cloudstack_network.foo", &network),
+					testAccCheckCloudStackNetworkVPCAttributes(&network),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNetwork_updateACL,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkExists(
+						"cloudstack_network.foo", &network),
+					testAccCheckCloudStackNetworkVPCAttributes(&network),
+				),
+			},
+		},
+	})
+}
+
+func TestCloudStackNetwork_simulatorRemoveACL(t *testing.T) {
+	var network cloudstack.Network
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNetwork_acl,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkExists(
+						"cloudstack_network.foo", &network),
+					testAccCheckSimulatorCount(sim, "createNetwork", 1),
+				),
+			},
+
+			{
+				// Changing the ACL updates the network in place
+				Config: testAccCloudStackNetwork_updateACL,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkExists(
+						"cloudstack_network.foo", &network),
+					testAccCheckSimulatorCount(sim, "createNetwork", 1),
+					testAccCheckSimulatorCount(sim, "replaceNetworkACLList", 1),
+				),
+			},
+
+			{
+				// Removing the ACL requires a new network
+				Config: testAccCloudStackNetwork_vpc,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNetworkExists(
+						"cloudstack_network.foo", &network),
+					resource.TestCheckResourceAttr(
+						"cloudstack_network.foo", "acl_id", none),
+					testAccCheckSimulatorCount(sim, "createNetwork", 2),
+					testAccCheckSimulatorCount(sim, "replaceNetworkACLList", 1),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceCloudStackNetworkACLDiff(t *testing.T) {
+	r := resourceCloudStackNetwork()
+
+	cases := []struct {
+		ACLID       string
+		RequiresNew bool
+	}{
+		{ACLID: "2", RequiresNew: false},
+		{ACLID: none, RequiresNew: true},
+	}
+
+	for _, tc := range cases {
+		raw, err := config.NewRawConfig(map[string]interface{}{
+			"name":             "terraform-network",
+			"cidr":             "10.1.1.0/24",
+			"network_offering": "DefaultIsolatedNetworkOfferingForVpcNetworks",
+			"zone":             "Sandbox-simulator",
+			"acl_id":           tc.ACLID,
+		})
+		if err != nil {
+			t.Fatalf("Error creating the config: %s", err)
+		}
+
+		state := &terraform.InstanceState{
+			ID: "1234",
+			Attributes: map[string]string{
+				"name":             "terraform-network",
+				"display_text":     "terraform-network",
+				"cidr":             "10.1.1.0/24",
+				"network_offering": "DefaultIsolatedNetworkOfferingForVpcNetworks",
+				"zone":             "Sandbox-simulator",
+				"acl_id":           "1",
+			},
+		}
+
+		diff, err := r.Diff(state, terraform.NewResourceConfig(raw), nil)
+		if err != nil {
+			t.Fatalf("Error computing the diff for acl_id %s: %s", tc.ACLID, err)
+		}
+
+		attr, ok := diff.Attributes["acl_id"]
+		if !ok {
+			t.Fatalf("Expected a diff for acl_id %s", tc.ACLID)
+		}
+
+		if attr.RequiresNew != tc.RequiresNew {
+			t.Fatalf("Expected RequiresNew to be %t for acl_id %s", tc.RequiresNew, tc.ACLID)
+		}
+	}
+
+	// Planning must not change the schema, as it is shared by all networks
+	if r.Schema["acl_id"].ForceNew {
+		t.Fatal("Expected the acl_id schema to be left unchanged")
+	}
+}
+
 func testAccCheckCloudStackNetworkExists(
 	n string, network *cloudstack.Network) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -170,7 +304,7 @@ func testAccCheckCloudStackNetworkExists(
 			return fmt.Errorf("No network ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		ntwrk, _, err := cs.Network.GetNetworkByID(
 			rs.Primary.ID,
 			cloudstack.WithProject(rs.Primary.Attributes["project"]),
@@ -238,7 +372,7 @@ func testAccCheckCloudStackNetworkVPCAttributes(
 }
 
 func testAccCheckCloudStackNetworkDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_network" {
@@ -33,6 +33,9 @@ func resourceCloudStackNIC() *schema.Resource {
 		Create: resourceCloudStackNICCreate,
 		Read:   resourceCloudStackNICRead,
 		Delete: resourceCloudStackNICDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackNICImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"network_id": {
@@ -58,7 +61,7 @@ func resourceCloudStackNIC() *schema.Resource {
 }
 
 func resourceCloudStackNICCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.VirtualMachine.NewAddNicToVirtualMachineParams(
@@ -94,12 +97,12 @@ func resourceCloudStackNICCreate(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceCloudStackNICRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the virtual machine details
-	vm, count, err := cs.VirtualMachine.GetVirtualMachineByID(d.Get("virtual_machine_id").(string))
+	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(d.Get("virtual_machine_id").(string))
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Instance %s does no longer exist", d.Get("virtual_machine_id").(string))
 			d.SetId("")
 			return nil
@@ -128,8 +131,22 @@ func resourceCloudStackNICRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+func resourceCloudStackNICImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// The ID should be given as <virtual_machine_id>/<nic_id>
+	s := strings.SplitN(d.Id(), "/", 2)
+	if len(s) != 2 {
+		return nil, fmt.Errorf(
+			"Invalid NIC import ID %q, expected <virtual_machine_id>/<nic_id>", d.Id())
+	}
+
+	d.Set("virtual_machine_id", s[0])
+	d.SetId(s[1])
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceCloudStackNICDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.VirtualMachine.NewRemoveNicFromVirtualMachineParams(
@@ -140,10 +157,8 @@ func resourceCloudStackNICDelete(d *schema.ResourceData, meta interface{}) error
 	// Remove the NIC
 	_, err := cs.VirtualMachine.RemoveNicFromVirtualMachine(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -153,7 +168,7 @@ func resourceCloudStackNICDelete(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
-func retryableAddNicFunc(cs *cloudstack.CloudStackClient, p *cloudstack.AddNicToVirtualMachineParams) func() (interface{}, error) {
+func retryableAddNicFunc(cs *Client, p *cloudstack.AddNicToVirtualMachineParams) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		r, err := cs.VirtualMachine.AddNicToVirtualMachine(p)
 		if err != nil {
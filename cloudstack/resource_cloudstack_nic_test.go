@@ -79,6 +79,80 @@ func TestAccCloudStackNIC_update(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackNIC_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNICDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNIC_ipaddress,
+			},
+
+			{
+				ResourceName:      "cloudstack_nic.foo",
+				ImportState:       true,
+				ImportStateIdFunc: testAccVirtualMachineImportStateID("cloudstack_nic.foo"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackNIC_simulator(t *testing.T) {
+	var nic cloudstack.Nic
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackNICDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackNIC_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNICExists(
+						"cloudstack_instance.foobar", "cloudstack_nic.foo", &nic),
+					testAccCheckCloudStackNICAttributes(&nic),
+				),
+			},
+
+			{
+				Config: testAccCloudStackNIC_ipaddress,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackNICExists(
+						"cloudstack_instance.foobar", "cloudstack_nic.foo", &nic),
+					testAccCheckCloudStackNICIPAddress(&nic),
+					resource.TestCheckResourceAttr(
+						"cloudstack_nic.foo", "ip_address", "10.1.2.123"),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_nic.foo",
+				ImportState:       true,
+				ImportStateIdFunc: testAccVirtualMachineImportStateID("cloudstack_nic.foo"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testAccVirtualMachineImportStateID returns the ID used to import resources
+// that belong to a virtual machine, which is <virtual_machine_id>/<id>.
+func testAccVirtualMachineImportStateID(n string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return "", fmt.Errorf("Not found: %s", n)
+		}
+
+		return fmt.Sprintf("%s/%s",
+			rs.Primary.Attributes["virtual_machine_id"], rs.Primary.ID), nil
+	}
+}
+
 func testAccCheckCloudStackNICExists(
 	v, n string, nic *cloudstack.Nic) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -100,7 +174,7 @@ func testAccCheckCloudStackNICExists(
 			return fmt.Errorf("No NIC ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(rsv.Primary.ID)
 
 		if err != nil {
@@ -147,7 +221,7 @@ func testAccCheckCloudStackNICIPAddress(
 }
 
 func testAccCheckCloudStackNICDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	// Deleting the instance automatically deletes any additional NICs
 	for _, rs := range s.RootModule().Resources {
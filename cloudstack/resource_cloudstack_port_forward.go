@@ -23,12 +23,9 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
-	"sync"
-	"time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -38,6 +35,9 @@ func resourceCloudStackPortForward() *schema.Resource {
 		Read:   resourceCloudStackPortForwardRead,
 		Update: resourceCloudStackPortForwardUpdate,
 		Delete: resourceCloudStackPortForwardDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackPortForwardImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"ip_address_id": {
@@ -64,18 +64,21 @@ func resourceCloudStackPortForward() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"protocol": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateProtocol(false, "tcp", "udp"),
 						},
 
 						"private_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(1, 65535),
 						},
 
 						"public_port": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(1, 65535),
 						},
 
 						"virtual_machine_id": {
@@ -108,7 +111,7 @@ func resourceCloudStackPortForwardCreate(d *schema.ResourceData, meta interface{
 		// Create an empty schema.Set to hold all forwards
 		forwards := resourceCloudStackPortForward().Schema["forward"].ZeroValue().(*schema.Set)
 
-		err := createPortForwards(d, meta, forwards, nrs)
+		err := createRules(portForwardHandler(d, meta), forwards, nrs)
 
 		// We need to update this first to preserve the correct state
 		d.Set("forward", forwards)
@@ -121,60 +124,55 @@ func resourceCloudStackPortForwardCreate(d *schema.ResourceData, meta interface{
 	return resourceCloudStackPortForwardRead(d, meta)
 }
 
-func createPortForwards(d *schema.ResourceData, meta interface{}, forwards *schema.Set, nrs *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(nrs.Len())
-
-	sem := make(chan struct{}, 10)
-	for _, forward := range nrs.List() {
-		// Put in a tiny sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(forward map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Create a single forward
-			err := createPortForward(d, meta, forward)
-
-			// If we have a UUID, we need to save the forward
-			if forward["uuid"].(string) != "" {
-				forwards.Add(forward)
+// portForwardHandler returns the handler used to create and delete the
+// forwards of the port forward.
+func portForwardHandler(d *schema.ResourceData, meta interface{}) *ruleHandler {
+	cs := meta.(*Client)
+	ipaddressid := d.Id()
+	project := getProject(cs, d)
+
+	return &ruleHandler{
+		parallelism: 10,
+		items: func(forward map[string]interface{}) ([]ruleItem, error) {
+			// Make sure all required parameters are there
+			if err := verifyPortForwardParams(d, forward); err != nil {
+				return nil, err
 			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
+			return []ruleItem{{key: "uuid"}}, nil
+		},
+		create: func(forward map[string]interface{}, item ruleItem) (string, error) {
+			return createPortForward(cs, ipaddressid, project, forward)
+		},
+		delete: func(forward map[string]interface{}, id string) error {
+			p := cs.Firewall.NewDeletePortForwardingRuleParams(id)
+			_, err := cs.Firewall.DeletePortForwardingRule(p)
+			return err
+		},
+		uuids: func(forward map[string]interface{}) map[string]interface{} {
+			uuids := make(map[string]interface{})
+			if uuid := forward["uuid"].(string); uuid != "" {
+				uuids["uuid"] = uuid
 			}
-
-			<-sem
-		}(forward.(map[string]interface{}))
+			return uuids
+		},
+		setUUIDs: func(forward map[string]interface{}, uuids map[string]interface{}) {
+			uuid, _ := uuids["uuid"].(string)
+			forward["uuid"] = uuid
+		},
 	}
-
-	wg.Wait()
-
-	return errs.ErrorOrNil()
 }
 
-func createPortForward(d *schema.ResourceData, meta interface{}, forward map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-
-	// Make sure all required parameters are there
-	if err := verifyPortForwardParams(d, forward); err != nil {
-		return err
-	}
-
+func createPortForward(cs *Client, ipaddressid string, project string, forward map[string]interface{}) (string, error) {
 	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
 		forward["virtual_machine_id"].(string),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(project),
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Create a new parameter struct
-	p := cs.Firewall.NewCreatePortForwardingRuleParams(d.Id(), forward["private_port"].(int),
+	p := cs.Firewall.NewCreatePortForwardingRuleParams(ipaddressid, forward["private_port"].(int),
 		forward["protocol"].(string), forward["public_port"].(int), vm.Id)
 
 	if vmGuestIP, ok := forward["vm_guest_ip"]; ok && vmGuestIP.(string) != "" {
@@ -205,24 +203,22 @@ func createPortForward(d *schema.ResourceData, meta interface{}, forward map[str
 
 	r, err := cs.Firewall.CreatePortForwardingRule(p)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	forward["uuid"] = r.Id
-
-	return nil
+	return r.Id, nil
 }
 
 func resourceCloudStackPortForwardRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// First check if the IP address is still associated
-	_, count, err := cs.Address.GetPublicIpAddressByID(
+	_, _, err := cs.Address.GetPublicIpAddressByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] IP address with ID %s is no longer associated", d.Id())
 			d.SetId("")
@@ -332,38 +328,8 @@ func resourceCloudStackPortForwardRead(d *schema.ResourceData, meta interface{})
 func resourceCloudStackPortForwardUpdate(d *schema.ResourceData, meta interface{}) error {
 	// Check if the forward set as a whole has changed
 	if d.HasChange("forward") {
-		o, n := d.GetChange("forward")
-		ors := o.(*schema.Set).Difference(n.(*schema.Set))
-		nrs := n.(*schema.Set).Difference(o.(*schema.Set))
-
-		// We need to start with a rule set containing all the rules we
-		// already have and want to keep. Any rules that are not deleted
-		// correctly and any newly created rules, will be added to this
-		// set to make sure we end up in a consistent state
-		forwards := o.(*schema.Set).Intersection(n.(*schema.Set))
-
-		// First loop through all the old forwards and delete them
-		if ors.Len() > 0 {
-			err := deletePortForwards(d, meta, forwards, ors)
-
-			// We need to update this first to preserve the correct state
-			d.Set("forward", forwards)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		// Then loop through all the new forwards and create them
-		if nrs.Len() > 0 {
-			err := createPortForwards(d, meta, forwards, nrs)
-
-			// We need to update this first to preserve the correct state
-			d.Set("forward", forwards)
-
-			if err != nil {
-				return err
-			}
+		if err := reconcileRules(d, "forward", portForwardHandler(d, meta)); err != nil {
+			return err
 		}
 	}
 
@@ -377,7 +343,7 @@ func resourceCloudStackPortForwardDelete(d *schema.ResourceData, meta interface{
 
 	// Delete all forwards
 	if ors := d.Get("forward").(*schema.Set); ors.Len() > 0 {
-		err := deletePortForwards(d, meta, forwards, ors)
+		err := deleteRules(portForwardHandler(d, meta), forwards, ors)
 
 		// We need to update this first to preserve the correct state
 		d.Set("forward", forwards)
@@ -390,62 +356,54 @@ func resourceCloudStackPortForwardDelete(d *schema.ResourceData, meta interface{
 	return nil
 }
 
-func deletePortForwards(d *schema.ResourceData, meta interface{}, forwards *schema.Set, ors *schema.Set) error {
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(ors.Len())
-
-	sem := make(chan struct{}, 10)
-	for _, forward := range ors.List() {
-		// Put a sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(forward map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
-
-			// Delete a single forward
-			err := deletePortForward(d, meta, forward)
-
-			// If we have a UUID, we need to save the forward
-			if forward["uuid"].(string) != "" {
-				forwards.Add(forward)
-			}
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			}
+func resourceCloudStackPortForwardImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
 
-			<-sem
-		}(forward.(map[string]interface{}))
+	// Try to split the ID to extract the optional project name
+	if _, err := importStatePassthrough(d, meta); err != nil {
+		return nil, err
 	}
 
-	wg.Wait()
+	// Get all the forwards from the running environment
+	p := cs.Firewall.NewListPortForwardingRulesParams()
+	p.SetIpaddressid(d.Id())
+	p.SetListall(true)
 
-	return errs.ErrorOrNil()
-}
+	if err := setProjectid(p, cs, d); err != nil {
+		return nil, err
+	}
 
-func deletePortForward(d *schema.ResourceData, meta interface{}, forward map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	l, err := cs.Firewall.ListPortForwardingRules(p)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create the parameter struct
-	p := cs.Firewall.NewDeletePortForwardingRuleParams(forward["uuid"].(string))
+	var forwards []interface{}
+	for _, f := range l.PortForwardingRules {
+		privPort, err := strconv.Atoi(f.Privateport)
+		if err != nil {
+			return nil, err
+		}
 
-	// Delete the forward
-	if _, err := cs.Firewall.DeletePortForwardingRule(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if !strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", forward["uuid"].(string))) {
-			return err
+		pubPort, err := strconv.Atoi(f.Publicport)
+		if err != nil {
+			return nil, err
 		}
+
+		forwards = append(forwards, map[string]interface{}{
+			"protocol":           f.Protocol,
+			"private_port":       privPort,
+			"public_port":        pubPort,
+			"virtual_machine_id": f.Virtualmachineid,
+			"uuid":               f.Id,
+		})
 	}
 
-	// Empty the UUID of this rule
-	forward["uuid"] = ""
+	d.Set("ip_address_id", d.Id())
+	d.Set("managed", false)
+	d.Set("forward", forwards)
 
-	return nil
+	return []*schema.ResourceData{d}, nil
 }
 
 func verifyPortForwardParams(d *schema.ResourceData, forward map[string]interface{}) error {
@@ -22,10 +22,8 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackPrivateGateway() *schema.Resource {
@@ -90,7 +88,7 @@ func resourceCloudStackPrivateGateway() *schema.Resource {
 }
 
 func resourceCloudStackPrivateGatewayCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	ipaddress := d.Get("ip_address").(string)
 	networkofferingid := d.Get("network_offering").(string)
@@ -131,12 +129,12 @@ func resourceCloudStackPrivateGatewayCreate(d *schema.ResourceData, meta interfa
 }
 
 func resourceCloudStackPrivateGatewayRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the private gateway details
-	gw, count, err := cs.VPC.GetPrivateGatewayByID(d.Id())
+	gw, _, err := cs.VPC.GetPrivateGatewayByID(d.Id())
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Private gateway %s does no longer exist", d.Id())
 			d.SetId("")
 			return nil
@@ -156,7 +154,7 @@ func resourceCloudStackPrivateGatewayRead(d *schema.ResourceData, meta interface
 }
 
 func resourceCloudStackPrivateGatewayUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Replace the ACL if the ID has changed
 	if d.HasChange("acl_id") {
@@ -173,7 +171,7 @@ func resourceCloudStackPrivateGatewayUpdate(d *schema.ResourceData, meta interfa
 }
 
 func resourceCloudStackPrivateGatewayDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.VPC.NewDeletePrivateGatewayParams(d.Id())
@@ -181,10 +179,8 @@ func resourceCloudStackPrivateGatewayDelete(d *schema.ResourceData, meta interfa
 	// Delete the private gateway
 	_, err := cs.VPC.DeletePrivateGateway(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
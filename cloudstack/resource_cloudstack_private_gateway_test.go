@@ -67,6 +67,28 @@ func TestAccCloudStackPrivateGateway_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackPrivateGateway_simulator(t *testing.T) {
+	var gateway cloudstack.PrivateGateway
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackPrivateGatewayDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackPrivateGateway_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackPrivateGatewayExists(
+						"cloudstack_private_gateway.foo", &gateway),
+					testAccCheckCloudStackPrivateGatewayAttributes(&gateway),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackPrivateGatewayExists(
 	n string, gateway *cloudstack.PrivateGateway) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -79,7 +101,7 @@ func testAccCheckCloudStackPrivateGatewayExists(
 			return fmt.Errorf("No Private Gateway ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		pgw, _, err := cs.VPC.GetPrivateGatewayByID(rs.Primary.ID)
 
 		if err != nil {
@@ -117,7 +139,7 @@ func testAccCheckCloudStackPrivateGatewayAttributes(
 }
 
 func testAccCheckCloudStackPrivateGatewayDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_private_gateway" {
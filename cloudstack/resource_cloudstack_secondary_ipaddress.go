@@ -25,7 +25,6 @@ import (
 	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackSecondaryIPAddress() *schema.Resource {
@@ -33,6 +32,9 @@ func resourceCloudStackSecondaryIPAddress() *schema.Resource {
 		Create: resourceCloudStackSecondaryIPAddressCreate,
 		Read:   resourceCloudStackSecondaryIPAddressRead,
 		Delete: resourceCloudStackSecondaryIPAddressDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackSecondaryIPAddressImport,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"ip_address": {
@@ -59,16 +61,16 @@ func resourceCloudStackSecondaryIPAddress() *schema.Resource {
 }
 
 func resourceCloudStackSecondaryIPAddressCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	nicid, ok := d.GetOk("nic_id")
 	if !ok {
 		virtualmachineid := d.Get("virtual_machine_id").(string)
 
 		// Get the virtual machine details
-		vm, count, err := cs.VirtualMachine.GetVirtualMachineByID(virtualmachineid)
+		vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(virtualmachineid)
 		if err != nil {
-			if count == 0 {
+			if isNotFound(err) {
 				log.Printf("[DEBUG] Virtual Machine %s does no longer exist", virtualmachineid)
 				d.SetId("")
 				return nil
@@ -98,14 +100,14 @@ func resourceCloudStackSecondaryIPAddressCreate(d *schema.ResourceData, meta int
 }
 
 func resourceCloudStackSecondaryIPAddressRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	virtualmachineid := d.Get("virtual_machine_id").(string)
 
 	// Get the virtual machine details
-	vm, count, err := cs.VirtualMachine.GetVirtualMachineByID(virtualmachineid)
+	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(virtualmachineid)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Virtual Machine %s does no longer exist", virtualmachineid)
 			d.SetId("")
 			return nil
@@ -151,18 +153,49 @@ func resourceCloudStackSecondaryIPAddressRead(d *schema.ResourceData, meta inter
 	return nil
 }
 
+func resourceCloudStackSecondaryIPAddressImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
+
+	// The ID should be given as <virtual_machine_id>/<ip_address_id>
+	s := strings.SplitN(d.Id(), "/", 2)
+	if len(s) != 2 {
+		return nil, fmt.Errorf(
+			"Invalid secondary IP address import ID %q, expected "+
+				"<virtual_machine_id>/<ip_address_id>", d.Id())
+	}
+
+	// Find the NIC the IP address is assigned to
+	l, err := cs.Nic.ListNics(cs.Nic.NewListNicsParams(s[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range l.Nics {
+		for _, ip := range n.Secondaryip {
+			if ip.Id == s[1] {
+				d.Set("nic_id", n.Id)
+				d.Set("virtual_machine_id", s[0])
+				d.SetId(s[1])
+
+				return []*schema.ResourceData{d}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"Secondary IP address %s not found on virtual machine %s", s[1], s[0])
+}
+
 func resourceCloudStackSecondaryIPAddressDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.Nic.NewRemoveIpFromNicParams(d.Id())
 
 	log.Printf("[INFO] Removing secondary IP address: %s", d.Get("ip_address").(string))
 	if _, err := cs.Nic.RemoveIpFromNic(p); err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
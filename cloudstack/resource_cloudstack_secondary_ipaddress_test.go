@@ -69,6 +69,54 @@ func TestAccCloudStackSecondaryIPAddress_fixedIP(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackSecondaryIPAddress_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSecondaryIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSecondaryIPAddress_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_secondary_ipaddress.foo",
+				ImportState:       true,
+				ImportStateIdFunc: testAccVirtualMachineImportStateID("cloudstack_secondary_ipaddress.foo"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackSecondaryIPAddress_simulator(t *testing.T) {
+	var ip cloudstack.AddIpToNicResponse
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSecondaryIPAddressDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSecondaryIPAddress_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackSecondaryIPAddressExists(
+						"cloudstack_secondary_ipaddress.foo", &ip),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_secondary_ipaddress.foo",
+				ImportState:       true,
+				ImportStateIdFunc: testAccVirtualMachineImportStateID("cloudstack_secondary_ipaddress.foo"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackSecondaryIPAddressExists(
 	n string, ip *cloudstack.AddIpToNicResponse) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -81,7 +129,7 @@ func testAccCheckCloudStackSecondaryIPAddressExists(
 			return fmt.Errorf("No IP address ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 
 		virtualmachine, ok := rs.Primary.Attributes["virtual_machine_id"]
 		if !ok {
@@ -151,7 +199,7 @@ func testAccCheckCloudStackSecondaryIPAddressAttributes(
 }
 
 func testAccCheckCloudStackSecondaryIPAddressDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_secondary_ipaddress" {
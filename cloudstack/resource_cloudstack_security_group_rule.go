@@ -22,13 +22,12 @@ package cloudstack
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 
-	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
@@ -49,6 +48,11 @@ func resourceCloudStackSecurityGroupRule() *schema.Resource {
 		Read:   resourceCloudStackSecurityGroupRuleRead,
 		Update: resourceCloudStackSecurityGroupRuleUpdate,
 		Delete: resourceCloudStackSecurityGroupRuleDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceCloudStackSecurityGroupRuleImport,
+		},
+
+		CustomizeDiff: verifyRulesDiff("rule", verifyRuleProtocolParams),
 
 		Schema: map[string]*schema.Schema{
 			"security_group_id": {
@@ -65,38 +69,48 @@ func resourceCloudStackSecurityGroupRule() *schema.Resource {
 						"cidr_list": {
 							Type:     schema.TypeSet,
 							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateCIDR,
+							},
+							Set: schema.HashString,
 						},
 
 						"protocol": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateProtocol(true, "tcp", "udp", "icmp"),
 						},
 
 						"icmp_type": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"icmp_code": {
-							Type:     schema.TypeInt,
-							Optional: true,
-							Computed: true,
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntBetween(-1, 255),
 						},
 
 						"ports": {
 							Type:     schema.TypeSet,
 							Optional: true,
-							Elem:     &schema.Schema{Type: schema.TypeString},
-							Set:      schema.HashString,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validatePorts,
+							},
+							Set: schema.HashString,
 						},
 
 						"traffic_type": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "ingress",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "ingress",
+							ValidateFunc: validation.StringInSlice([]string{"ingress", "egress"}, false),
 						},
 
 						"user_security_group_list": {
@@ -138,7 +152,7 @@ func resourceCloudStackSecurityGroupRuleCreate(d *schema.ResourceData, meta inte
 		// Create an empty rule set to hold all newly created rules
 		rules := resourceCloudStackSecurityGroupRule().Schema["rule"].ZeroValue().(*schema.Set)
 
-		err := createSecurityGroupRules(d, meta, rules, nrs)
+		err := createRules(securityGroupRuleHandler(d, meta), rules, nrs)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -151,98 +165,94 @@ func resourceCloudStackSecurityGroupRuleCreate(d *schema.ResourceData, meta inte
 	return resourceCloudStackSecurityGroupRuleRead(d, meta)
 }
 
-func createSecurityGroupRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, nrs *schema.Set) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	var errs *multierror.Error
-
-	var wg sync.WaitGroup
-	wg.Add(nrs.Len())
-
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range nrs.List() {
-		// Put in a tiny sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
-
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
+// securityGroupRuleHandler returns the handler used to create and delete the
+// rules of the security group.
+func securityGroupRuleHandler(d *schema.ResourceData, meta interface{}) *ruleHandler {
+	cs := meta.(*Client)
+	securitygroupid := d.Id()
+	project := getProject(cs, d)
 
+	return &ruleHandler{
+		parallelism: d.Get("parallelism").(int),
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
 			// Make sure all required parameters are there
 			if err := verifySecurityGroupRuleParams(d, rule); err != nil {
-				errs = multierror.Append(errs, err)
-				return
+				return nil, err
 			}
 
-			var p authorizeSecurityGroupParams
-
-			if cidrList, ok := rule["cidr_list"].(*schema.Set); ok && cidrList.Len() > 0 {
-				for _, cidr := range cidrList.List() {
-					// Create a new parameter struct
-					switch rule["traffic_type"].(string) {
-					case "ingress":
-						p = cs.SecurityGroup.NewAuthorizeSecurityGroupIngressParams()
-					case "egress":
-						p = cs.SecurityGroup.NewAuthorizeSecurityGroupEgressParams()
-					}
-
-					p.SetSecuritygroupid(d.Id())
-					p.SetCidrlist([]string{cidr.(string)})
-
-					// Create a single rule
-					err := createSecurityGroupRule(d, meta, rule, p, cidr.(string))
-					if err != nil {
-						errs = multierror.Append(errs, err)
+			// Each CIDR and security group needs its own rule
+			var items []ruleItem
+			for _, item := range protocolRuleItems(rule) {
+				if cidrList, ok := rule["cidr_list"].(*schema.Set); ok {
+					for _, cidr := range cidrList.List() {
+						items = append(items, ruleItem{
+							key:  cidr.(string) + item.key,
+							port: item.port,
+							cidr: cidr.(string),
+						})
 					}
 				}
-			}
-
-			if usgList, ok := rule["user_security_group_list"].(*schema.Set); ok && usgList.Len() > 0 {
-				for _, usg := range usgList.List() {
-					sg, _, err := cs.SecurityGroup.GetSecurityGroupByName(
-						usg.(string),
-						cloudstack.WithProject(d.Get("project").(string)),
-					)
-					if err != nil {
-						errs = multierror.Append(errs, err)
-						continue
-					}
 
-					// Create a new parameter struct
-					switch rule["traffic_type"].(string) {
-					case "ingress":
-						p = cs.SecurityGroup.NewAuthorizeSecurityGroupIngressParams()
-					case "egress":
-						p = cs.SecurityGroup.NewAuthorizeSecurityGroupEgressParams()
-					}
-
-					p.SetSecuritygroupid(d.Id())
-					p.SetUsersecuritygrouplist(map[string]string{sg.Account: usg.(string)})
-
-					// Create a single rule
-					err = createSecurityGroupRule(d, meta, rule, p, usg.(string))
-					if err != nil {
-						errs = multierror.Append(errs, err)
+				if usgList, ok := rule["user_security_group_list"].(*schema.Set); ok {
+					for _, usg := range usgList.List() {
+						items = append(items, ruleItem{
+							key:   usg.(string) + item.key,
+							port:  item.port,
+							group: usg.(string),
+						})
 					}
 				}
 			}
 
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
+			return items, nil
+		},
+		create: func(rule map[string]interface{}, item ruleItem) (string, error) {
+			return createSecurityGroupRule(cs, securitygroupid, project, rule, item)
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			var err error
+			switch rule["traffic_type"].(string) {
+			case "ingress":
+				p := cs.SecurityGroup.NewRevokeSecurityGroupIngressParams(id)
+				_, err = cs.SecurityGroup.RevokeSecurityGroupIngress(p)
+			case "egress":
+				p := cs.SecurityGroup.NewRevokeSecurityGroupEgressParams(id)
+				_, err = cs.SecurityGroup.RevokeSecurityGroupEgress(p)
 			}
+			return err
+		},
+	}
+}
 
-			<-sem
-		}(rule.(map[string]interface{}))
+func createSecurityGroupRule(cs *Client, securitygroupid string, project string, rule map[string]interface{}, item ruleItem) (string, error) {
+	var p authorizeSecurityGroupParams
+
+	// Create a new parameter struct
+	switch rule["traffic_type"].(string) {
+	case "ingress":
+		p = cs.SecurityGroup.NewAuthorizeSecurityGroupIngressParams()
+	case "egress":
+		p = cs.SecurityGroup.NewAuthorizeSecurityGroupEgressParams()
 	}
 
-	wg.Wait()
+	p.SetSecuritygroupid(securitygroupid)
 
-	return errs.ErrorOrNil()
-}
+	// Set either the CIDR or the security group of the rule
+	if item.cidr != "" {
+		p.SetCidrlist([]string{item.cidr})
+	}
 
-func createSecurityGroupRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}, p authorizeSecurityGroupParams, uuid string) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
+	if item.group != "" {
+		sg, _, err := cs.SecurityGroup.GetSecurityGroupByName(
+			item.group,
+			cloudstack.WithProject(project),
+		)
+		if err != nil {
+			return "", err
+		}
+
+		p.SetUsersecuritygrouplist(map[string]string{sg.Account: item.group})
+	}
 
 	// Set the protocol
 	p.SetProtocol(rule["protocol"].(string))
@@ -251,66 +261,23 @@ func createSecurityGroupRule(d *schema.ResourceData, meta interface{}, rule map[
 	if rule["protocol"].(string) == "icmp" {
 		p.SetIcmptype(rule["icmp_type"].(int))
 		p.SetIcmpcode(rule["icmp_code"].(int))
+	}
 
-		ruleID, err := createIngressOrEgressRule(cs, p)
+	// If the item has a port, set the port range
+	if item.port != "" {
+		startPort, endPort, err := splitPortRange(item.port)
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		uuids[uuid+"icmp"] = ruleID
-		rule["uuids"] = uuids
-	}
-
-	// If protocol is TCP or UDP, loop through all ports
-	if rule["protocol"].(string) == "tcp" || rule["protocol"].(string) == "udp" {
-		if ps := rule["ports"].(*schema.Set); ps.Len() > 0 {
-
-			// Create an empty schema.Set to hold all processed ports
-			ports := &schema.Set{F: schema.HashString}
-
-			for _, port := range ps.List() {
-				if _, ok := uuids[uuid+port.(string)]; ok {
-					ports.Add(port)
-					rule["ports"] = ports
-					continue
-				}
-
-				m := splitPorts.FindStringSubmatch(port.(string))
-
-				startPort, err := strconv.Atoi(m[1])
-				if err != nil {
-					return err
-				}
-
-				endPort := startPort
-				if m[2] != "" {
-					endPort, err = strconv.Atoi(m[2])
-					if err != nil {
-						return err
-					}
-				}
-
-				p.SetStartport(startPort)
-				p.SetEndport(endPort)
-
-				ruleID, err := createIngressOrEgressRule(cs, p)
-				if err != nil {
-					return err
-				}
-
-				ports.Add(port)
-				rule["ports"] = ports
-
-				uuids[uuid+port.(string)] = ruleID
-				rule["uuids"] = uuids
-			}
-		}
+		p.SetStartport(startPort)
+		p.SetEndport(endPort)
 	}
 
-	return nil
+	return createIngressOrEgressRule(cs, p)
 }
 
-func createIngressOrEgressRule(cs *cloudstack.CloudStackClient, p authorizeSecurityGroupParams) (string, error) {
+func createIngressOrEgressRule(cs *Client, p authorizeSecurityGroupParams) (string, error) {
 	switch p := p.(type) {
 	case *cloudstack.AuthorizeSecurityGroupIngressParams:
 		r, err := cs.SecurityGroup.AuthorizeSecurityGroupIngress(p)
@@ -330,15 +297,15 @@ func createIngressOrEgressRule(cs *cloudstack.CloudStackClient, p authorizeSecur
 }
 
 func resourceCloudStackSecurityGroupRuleRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the security group details
-	sg, count, err := cs.SecurityGroup.GetSecurityGroupByID(
+	sg, _, err := cs.SecurityGroup.GetSecurityGroupByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Security group %s does not longer exist", d.Id())
 			d.SetId("")
 			return nil
@@ -467,38 +434,8 @@ func readSecurityGroupRule(sg *cloudstack.SecurityGroup, ruleIndex map[string]in
 func resourceCloudStackSecurityGroupRuleUpdate(d *schema.ResourceData, meta interface{}) error {
 	// Check if the rule set as a whole has changed
 	if d.HasChange("rule") {
-		o, n := d.GetChange("rule")
-		ors := o.(*schema.Set).Difference(n.(*schema.Set))
-		nrs := n.(*schema.Set).Difference(o.(*schema.Set))
-
-		// We need to start with a rule set containing all the rules we
-		// already have and want to keep. Any rules that are not deleted
-		// correctly and any newly created rules, will be added to this
-		// set to make sure we end up in a consistent state
-		rules := o.(*schema.Set).Intersection(n.(*schema.Set))
-
-		// First loop through all the old rules destroy them
-		if ors.Len() > 0 {
-			err := deleteSecurityGroupRules(d, meta, rules, ors)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
-		}
-
-		// Then loop through all the new rules and delete them
-		if nrs.Len() > 0 {
-			err := createSecurityGroupRules(d, meta, rules, nrs)
-
-			// We need to update this first to preserve the correct state
-			d.Set("rule", rules)
-
-			if err != nil {
-				return err
-			}
+		if err := reconcileRules(d, "rule", securityGroupRuleHandler(d, meta)); err != nil {
+			return err
 		}
 	}
 
@@ -512,7 +449,7 @@ func resourceCloudStackSecurityGroupRuleDelete(d *schema.ResourceData, meta inte
 
 	// Delete all rules
 	if ors := d.Get("rule").(*schema.Set); ors.Len() > 0 {
-		err := deleteSecurityGroupRules(d, meta, rules, ors)
+		err := deleteRules(securityGroupRuleHandler(d, meta), rules, ors)
 
 		// We need to update this first to preserve the correct state
 		d.Set("rule", rules)
@@ -525,78 +462,133 @@ func resourceCloudStackSecurityGroupRuleDelete(d *schema.ResourceData, meta inte
 	return nil
 }
 
-func deleteSecurityGroupRules(d *schema.ResourceData, meta interface{}, rules *schema.Set, ors *schema.Set) error {
-	var errs *multierror.Error
+func resourceCloudStackSecurityGroupRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	cs := meta.(*Client)
+
+	// Try to split the ID to extract the optional project name
+	if _, err := importStatePassthrough(d, meta); err != nil {
+		return nil, err
+	}
+
+	// Get the security group details
+	sg, _, err := cs.SecurityGroup.GetSecurityGroupByID(
+		d.Id(),
+		cloudstack.WithProject(getProject(cs, d)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each live rule has a single source, so first collect the ports (or
+	// the ICMP rule) of each source per traffic type and protocol
+	type source struct {
+		rule   map[string]interface{}
+		source string
+		cidr   bool
+		ports  map[string]string
+	}
+
+	var keys []string
+	sources := make(map[string]*source)
 
-	var wg sync.WaitGroup
-	wg.Add(ors.Len())
+	addRules := func(trafficType string, sgRules []cloudstack.SecurityGroupRule) {
+		for _, r := range sgRules {
+			name := r.Cidr
+			if name == "" {
+				name = r.Securitygroupname
+			}
 
-	sem := make(chan struct{}, d.Get("parallelism").(int))
-	for _, rule := range ors.List() {
-		// Put a sleep here to avoid DoS'ing the API
-		time.Sleep(500 * time.Millisecond)
+			key := strings.Join([]string{trafficType, r.Protocol, name}, "/")
+			if r.Protocol == "icmp" {
+				key = fmt.Sprintf("%s/%d/%d", key, r.Icmptype, r.Icmpcode)
+			}
 
-		go func(rule map[string]interface{}) {
-			defer wg.Done()
-			sem <- struct{}{}
+			s, ok := sources[key]
+			if !ok {
+				s = &source{
+					rule: map[string]interface{}{
+						"protocol":     r.Protocol,
+						"traffic_type": trafficType,
+					},
+					source: name,
+					cidr:   r.Cidr != "",
+					ports:  make(map[string]string),
+				}
 
-			// Create a single rule
-			err := deleteSecurityGroupRule(d, meta, rule)
-			if err != nil {
-				errs = multierror.Append(errs, err)
+				if r.Protocol == "icmp" {
+					s.rule["icmp_type"] = r.Icmptype
+					s.rule["icmp_code"] = r.Icmpcode
+				}
+
+				keys = append(keys, key)
+				sources[key] = s
 			}
 
-			// If we have at least one UUID, we need to save the rule
-			if len(rule["uuids"].(map[string]interface{})) > 0 {
-				rules.Add(rule)
+			if r.Protocol == "icmp" {
+				s.ports["icmp"] = r.Ruleid
+				continue
 			}
 
-			<-sem
-		}(rule.(map[string]interface{}))
+			s.ports[portRange(r.Startport, r.Endport)] = r.Ruleid
+		}
 	}
 
-	wg.Wait()
+	addRules("ingress", sg.Ingressrule)
+	addRules("egress", sg.Egressrule)
 
-	return errs.ErrorOrNil()
-}
+	// Then group all sources that use the same ports into a single rule
+	var groups []string
+	rules := make(map[string]map[string]interface{})
 
-func deleteSecurityGroupRule(d *schema.ResourceData, meta interface{}, rule map[string]interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
-	uuids := rule["uuids"].(map[string]interface{})
+	for _, key := range keys {
+		s := sources[key]
+
+		var ports []string
+		for port := range s.ports {
+			ports = append(ports, port)
+		}
+		sort.Strings(ports)
+
+		group := fmt.Sprintf("%s/%s/%v/%v/%s", s.rule["traffic_type"], s.rule["protocol"],
+			s.rule["icmp_type"], s.rule["icmp_code"], strings.Join(ports, ","))
+
+		rule, ok := rules[group]
+		if !ok {
+			rule = s.rule
+			rule["cidr_list"] = &schema.Set{F: schema.HashString}
+			rule["user_security_group_list"] = &schema.Set{F: schema.HashString}
+			rule["ports"] = &schema.Set{F: schema.HashString}
+			rule["uuids"] = make(map[string]interface{})
 
-	for k, id := range uuids {
-		// We don't care about the count here, so just continue
-		if k == "%" {
-			continue
+			groups = append(groups, group)
+			rules[group] = rule
 		}
 
-		var err error
-		switch rule["traffic_type"].(string) {
-		case "ingress":
-			p := cs.SecurityGroup.NewRevokeSecurityGroupIngressParams(id.(string))
-			_, err = cs.SecurityGroup.RevokeSecurityGroupIngress(p)
-		case "egress":
-			p := cs.SecurityGroup.NewRevokeSecurityGroupEgressParams(id.(string))
-			_, err = cs.SecurityGroup.RevokeSecurityGroupEgress(p)
+		if s.cidr {
+			rule["cidr_list"].(*schema.Set).Add(s.source)
+		} else {
+			rule["user_security_group_list"].(*schema.Set).Add(s.source)
 		}
 
-		if err != nil {
-			// This is a very poor way to be told the ID does no longer exist :(
-			if strings.Contains(err.Error(), fmt.Sprintf(
-				"Invalid parameter id value=%s due to incorrect long value format, "+
-					"or entity does not exist", id.(string))) {
-				delete(uuids, k)
-				continue
+		uuids := rule["uuids"].(map[string]interface{})
+		for port, id := range s.ports {
+			if port != "icmp" {
+				rule["ports"].(*schema.Set).Add(port)
 			}
-
-			return err
+			uuids[s.source+port] = id
 		}
+	}
 
-		// Delete the UUID of this rule
-		delete(uuids, k)
+	var rs []interface{}
+	for _, group := range groups {
+		rs = append(rs, rules[group])
 	}
 
-	return nil
+	d.Set("security_group_id", d.Id())
+	d.Set("parallelism", 2)
+	d.Set("rule", rs)
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func verifySecurityGroupRuleParams(d *schema.ResourceData, rule map[string]interface{}) error {
@@ -622,10 +614,8 @@ func verifySecurityGroupRuleParams(d *schema.ResourceData, rule map[string]inter
 	case "tcp", "udp":
 		if ports, ok := rule["ports"].(*schema.Set); ok {
 			for _, port := range ports.List() {
-				m := splitPorts.FindStringSubmatch(port.(string))
-				if m == nil {
-					return fmt.Errorf(
-						"%q is not a valid port value. Valid options are '80' or '80-90'", port.(string))
+				if _, _, err := splitPortRange(port.(string)); err != nil {
+					return err
 				}
 			}
 		} else {
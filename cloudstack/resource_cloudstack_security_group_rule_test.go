@@ -26,7 +26,6 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func TestAccCloudStackSecurityGroupRule_basic(t *testing.T) {
@@ -142,6 +141,108 @@ func TestAccCloudStackSecurityGroupRule_update(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackSecurityGroupRule_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSecurityGroupRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSecurityGroupRule_update,
+			},
+
+			{
+				ResourceName:      "cloudstack_security_group_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackSecurityGroupRule_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSecurityGroupRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSecurityGroupRule_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackSecurityGroupRulesExist("cloudstack_security_group.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.#", "2"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1322309156.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1322309156.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1322309156.ports.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1322309156.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1322309156.traffic_type", "ingress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3666289950.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3666289950.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3666289950.ports.3638101695", "443"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3666289950.traffic_type", "egress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3666289950.user_security_group_list.1089118859", "terraform-security-group-bar"),
+				),
+			},
+
+			{
+				Config: testAccCloudStackSecurityGroupRule_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackSecurityGroupRulesExist("cloudstack_security_group.foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.#", "3"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3156342770.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3156342770.cidr_list.951907883", "172.18.200.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3156342770.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3156342770.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3156342770.ports.3638101695", "443"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3839437815.cidr_list.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3839437815.cidr_list.3056857544", "172.18.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3839437815.icmp_code", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.3839437815.icmp_type", "-1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1804489748.protocol", "tcp"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1804489748.ports.#", "1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1804489748.ports.1889509032", "80"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1804489748.traffic_type", "egress"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_security_group_rule.foo", "rule.1804489748.user_security_group_list.1089118859", "terraform-security-group-bar"),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_security_group_rule.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackSecurityGroupRulesExist(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -153,7 +254,7 @@ func testAccCheckCloudStackSecurityGroupRulesExist(n string) resource.TestCheckF
 			return fmt.Errorf("No security group rule ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		sg, count, err := cs.SecurityGroup.GetSecurityGroupByID(rs.Primary.ID)
 		if err != nil {
 			if count == 0 {
@@ -184,7 +285,7 @@ func testAccCheckCloudStackSecurityGroupRulesExist(n string) resource.TestCheckF
 }
 
 func testAccCheckCloudStackSecurityGroupRuleDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_security_group_rule" {
@@ -66,6 +66,27 @@ func TestAccCloudStackSecurityGroup_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackSecurityGroup_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSecurityGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSecurityGroup_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_security_group.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackSecurityGroupExists(
 	n string, sg *cloudstack.SecurityGroup) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -78,7 +99,7 @@ func testAccCheckCloudStackSecurityGroupExists(
 			return fmt.Errorf("No security group ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		resp, _, err := cs.SecurityGroup.GetSecurityGroupByID(rs.Primary.ID)
 		if err != nil {
 			return err
@@ -111,7 +132,7 @@ func testAccCheckCloudStackSecurityGroupBasicAttributes(
 }
 
 func testAccCheckCloudStackSecurityGroupDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_security_group" {
@@ -22,10 +22,8 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackSSHKeyPair() *schema.Resource {
@@ -33,6 +31,9 @@ func resourceCloudStackSSHKeyPair() *schema.Resource {
 		Create: resourceCloudStackSSHKeyPairCreate,
 		Read:   resourceCloudStackSSHKeyPairRead,
 		Delete: resourceCloudStackSSHKeyPairDelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -53,6 +54,19 @@ func resourceCloudStackSSHKeyPair() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
 			"private_key": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -67,7 +81,7 @@ func resourceCloudStackSSHKeyPair() *schema.Resource {
 }
 
 func resourceCloudStackSSHKeyPairCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	name := d.Get("name").(string)
 	publicKey := d.Get("public_key").(string)
@@ -81,6 +95,11 @@ func resourceCloudStackSSHKeyPairCreate(d *schema.ResourceData, meta interface{}
 			return err
 		}
 
+		// If there is an account supplied, we retrieve and set the account and domain id
+		if err := setAccount(p, cs, d); err != nil {
+			return err
+		}
+
 		_, err := cs.SSH.RegisterSSHKeyPair(p)
 		if err != nil {
 			return err
@@ -94,6 +113,11 @@ func resourceCloudStackSSHKeyPairCreate(d *schema.ResourceData, meta interface{}
 			return err
 		}
 
+		// If there is an account supplied, we retrieve and set the account and domain id
+		if err := setAccount(p, cs, d); err != nil {
+			return err
+		}
+
 		r, err := cs.SSH.CreateSSHKeyPair(p)
 		if err != nil {
 			return err
@@ -108,7 +132,7 @@ func resourceCloudStackSSHKeyPairCreate(d *schema.ResourceData, meta interface{}
 }
 
 func resourceCloudStackSSHKeyPairRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	log.Printf("[DEBUG] looking for key pair with name %s", d.Id())
 
@@ -120,6 +144,11 @@ func resourceCloudStackSSHKeyPairRead(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	r, err := cs.SSH.ListSSHKeyPairs(p)
 	if err != nil {
 		return err
@@ -138,7 +167,7 @@ func resourceCloudStackSSHKeyPairRead(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackSSHKeyPairDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.SSH.NewDeleteSSHKeyPairParams(d.Id())
@@ -148,12 +177,16 @@ func resourceCloudStackSSHKeyPairDelete(d *schema.ResourceData, meta interface{}
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Remove the SSH Keypair
 	_, err := cs.SSH.DeleteSSHKeyPair(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"A key pair with name '%s' does not exist for account", d.Id())) {
+		// The key pair may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -70,6 +70,56 @@ func TestAccCloudStackSSHKeyPair_register(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackSSHKeyPair_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSSHKeyPairDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSSHKeyPair_register,
+			},
+
+			{
+				ResourceName:            "cloudstack_ssh_keypair.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"public_key"},
+			},
+		},
+	})
+}
+
+func TestCloudStackSSHKeyPair_simulator(t *testing.T) {
+	var sshkey cloudstack.SSHKeyPair
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackSSHKeyPairDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackSSHKeyPair_register,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackSSHKeyPairExists("cloudstack_ssh_keypair.foo", &sshkey),
+					testAccCheckCloudStackSSHKeyPairAttributes(&sshkey),
+					resource.TestCheckResourceAttr(
+						"cloudstack_ssh_keypair.foo", "public_key", publicKey),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_ssh_keypair.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"public_key"},
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackSSHKeyPairExists(n string, sshkey *cloudstack.SSHKeyPair) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -81,7 +131,7 @@ func testAccCheckCloudStackSSHKeyPairExists(n string, sshkey *cloudstack.SSHKeyP
 			return fmt.Errorf("No key pair ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		p := cs.SSH.NewListSSHKeyPairsParams()
 		p.SetName(rs.Primary.ID)
 
@@ -145,7 +195,7 @@ func testAccCheckCloudStackSSHKeyPairCreateAttributes(name string) resource.Test
 }
 
 func testAccCheckCloudStackSSHKeyPairDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_ssh_keypair" {
@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -34,6 +33,9 @@ func resourceCloudStackStaticNAT() *schema.Resource {
 		Exists: resourceCloudStackStaticNATExists,
 		Read:   resourceCloudStackStaticNATRead,
 		Delete: resourceCloudStackStaticNATDelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"ip_address_id": {
@@ -66,13 +68,13 @@ func resourceCloudStackStaticNAT() *schema.Resource {
 }
 
 func resourceCloudStackStaticNATCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	ipaddressid := d.Get("ip_address_id").(string)
 
 	vm, _, err := cs.VirtualMachine.GetVirtualMachineByID(
 		d.Get("virtual_machine_id").(string),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
 		return err
@@ -115,15 +117,15 @@ func resourceCloudStackStaticNATCreate(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackStaticNATExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the IP address details
-	ip, count, err := cs.Address.GetPublicIpAddressByID(
+	ip, _, err := cs.Address.GetPublicIpAddressByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] IP address with ID %s no longer exists", d.Id())
 			return false, nil
 		}
@@ -135,15 +137,15 @@ func resourceCloudStackStaticNATExists(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackStaticNATRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the IP address details
-	ip, count, err := cs.Address.GetPublicIpAddressByID(
+	ip, _, err := cs.Address.GetPublicIpAddressByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] IP address with ID %s no longer exists", d.Id())
 			d.SetId("")
 			return nil
@@ -158,6 +160,7 @@ func resourceCloudStackStaticNATRead(d *schema.ResourceData, meta interface{}) e
 		return nil
 	}
 
+	d.Set("ip_address_id", ip.Id)
 	d.Set("virtual_machine_id", ip.Virtualmachineid)
 	d.Set("vm_guest_ip", ip.Vmipaddress)
 
@@ -167,7 +170,7 @@ func resourceCloudStackStaticNATRead(d *schema.ResourceData, meta interface{}) e
 }
 
 func resourceCloudStackStaticNATDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.NAT.NewDisableStaticNatParams(d.Id())
@@ -175,10 +178,8 @@ func resourceCloudStackStaticNATDelete(d *schema.ResourceData, meta interface{})
 	// Disable static NAT
 	_, err := cs.NAT.DisableStaticNat(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
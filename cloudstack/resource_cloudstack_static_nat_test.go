@@ -48,6 +48,53 @@ func TestAccCloudStackStaticNAT_basic(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackStaticNAT_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackStaticNATDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackStaticNAT_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_static_nat.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackStaticNAT_simulator(t *testing.T) {
+	var ipaddr cloudstack.PublicIpAddress
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackStaticNATDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackStaticNAT_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackStaticNATExists(
+						"cloudstack_static_nat.foo", &ipaddr),
+					testAccCheckCloudStackStaticNATAttributes(&ipaddr),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_static_nat.foo",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackStaticNATExists(
 	n string, ipaddr *cloudstack.PublicIpAddress) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -60,7 +107,7 @@ func testAccCheckCloudStackStaticNATExists(
 			return fmt.Errorf("No static NAT ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		ip, _, err := cs.Address.GetPublicIpAddressByID(rs.Primary.ID)
 
 		if err != nil {
@@ -94,7 +141,7 @@ func testAccCheckCloudStackStaticNATAttributes(
 }
 
 func testAccCheckCloudStackStaticNATDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_static_nat" {
@@ -22,10 +22,8 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackStaticRoute() *schema.Resource {
@@ -33,6 +31,9 @@ func resourceCloudStackStaticRoute() *schema.Resource {
 		Create: resourceCloudStackStaticRouteCreate,
 		Read:   resourceCloudStackStaticRouteRead,
 		Delete: resourceCloudStackStaticRouteDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"cidr": {
@@ -51,7 +52,7 @@ func resourceCloudStackStaticRoute() *schema.Resource {
 }
 
 func resourceCloudStackStaticRouteCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.VPC.NewCreateStaticRouteParams(
@@ -71,12 +72,12 @@ func resourceCloudStackStaticRouteCreate(d *schema.ResourceData, meta interface{
 }
 
 func resourceCloudStackStaticRouteRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the virtual machine details
-	r, count, err := cs.VPC.GetStaticRouteByID(d.Id())
+	r, _, err := cs.VPC.GetStaticRouteByID(d.Id())
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] Static route %s does no longer exist", d.Id())
 			d.SetId("")
 			return nil
@@ -86,12 +87,13 @@ func resourceCloudStackStaticRouteRead(d *schema.ResourceData, meta interface{})
 	}
 
 	d.Set("cidr", r.Cidr)
+	d.Set("gateway_id", r.Gatewayid)
 
 	return nil
 }
 
 func resourceCloudStackStaticRouteDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Create a new parameter struct
 	p := cs.VPC.NewDeleteStaticRouteParams(d.Id())
@@ -99,10 +101,8 @@ func resourceCloudStackStaticRouteDelete(d *schema.ResourceData, meta interface{
 	// Delete the private gateway
 	_, err := cs.VPC.DeleteStaticRoute(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -35,6 +34,15 @@ func resourceCloudStackTemplate() *schema.Resource {
 		Read:   resourceCloudStackTemplateRead,
 		Update: resourceCloudStackTemplateUpdate,
 		Delete: resourceCloudStackTemplateDelete,
+		Importer: &schema.ResourceImporter{
+			State: importStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -121,9 +129,10 @@ func resourceCloudStackTemplate() *schema.Resource {
 			},
 
 			"is_ready_timeout": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				Default:  300,
+				Type:       schema.TypeInt,
+				Optional:   true,
+				Computed:   true,
+				Deprecated: "Use the create timeout instead",
 			},
 
 			"tags": tagsSchema(),
@@ -132,7 +141,7 @@ func resourceCloudStackTemplate() *schema.Resource {
 }
 
 func resourceCloudStackTemplateCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 
 	if err := verifyTemplateParams(d); err != nil {
 		return err
@@ -205,45 +214,36 @@ func resourceCloudStackTemplateCreate(d *schema.ResourceData, meta interface{})
 
 	d.SetId(r.RegisterTemplate[0].Id)
 
+	// The template may have been looked up by name before it existed
+	cs.lookups.forget("template", name)
+
 	// Set tags if necessary
 	if err = setTags(cs, d, "Template"); err != nil {
 		return fmt.Errorf("Error setting tags on the template %s: %s", name, err)
 	}
 
 	// Wait until the template is ready to use, or timeout with an error...
-	currentTime := time.Now().Unix()
-	timeout := int64(d.Get("is_ready_timeout").(int))
-	for {
-		// Start with the sleep so the register action has a few seconds
-		// to process the registration correctly. Without this wait
-		time.Sleep(10 * time.Second)
-
-		err := resourceCloudStackTemplateRead(d, meta)
-		if err != nil {
-			return err
-		}
-
-		if d.Get("is_ready").(bool) {
-			return nil
-		}
+	timeout := resourceTimeout(cs, d.Timeout(schema.TimeoutCreate))
 
-		if time.Now().Unix()-currentTime > timeout {
-			return fmt.Errorf("Timeout while waiting for template to become ready")
-		}
+	// Keep honoring the deprecated is_ready_timeout when it is configured
+	if t, ok := d.GetOk("is_ready_timeout"); ok {
+		timeout = time.Duration(t.(int)) * time.Second
 	}
+
+	return waitForReady(d, meta, "template", resourceCloudStackTemplateRead, timeout)
 }
 
 func resourceCloudStackTemplateRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the template details
-	t, count, err := cs.Template.GetTemplateByID(
+	t, _, err := cs.Template.GetTemplateByID(
 		d.Id(),
 		"executable",
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] Template %s no longer exists", d.Get("name").(string))
 			d.SetId("")
@@ -264,11 +264,7 @@ func resourceCloudStackTemplateRead(d *schema.ResourceData, meta interface{}) er
 	d.Set("password_enabled", t.Passwordenabled)
 	d.Set("is_ready", t.Isready)
 
-	tags := make(map[string]interface{})
-	for _, tag := range t.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, t.Tags)
 
 	setValueOrID(d, "os_type", t.Ostypename, t.Ostypeid)
 	setValueOrID(d, "project", t.Project, t.Projectid)
@@ -278,7 +274,7 @@ func resourceCloudStackTemplateRead(d *schema.ResourceData, meta interface{}) er
 }
 
 func resourceCloudStackTemplateUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 	name := d.Get("name").(string)
 
 	// Create a new parameter struct
@@ -317,6 +313,12 @@ func resourceCloudStackTemplateUpdate(d *schema.ResourceData, meta interface{})
 		return fmt.Errorf("Error updating template %s: %s", name, err)
 	}
 
+	if d.HasChange("name") {
+		o, n := d.GetChange("name")
+		cs.lookups.forget("template", o.(string))
+		cs.lookups.forget("template", n.(string))
+	}
+
 	if d.HasChange("tags") {
 		if err := updateTags(cs, d, "Template"); err != nil {
 			return fmt.Errorf("Error updating tags on template %s: %s", name, err)
@@ -327,7 +329,7 @@ func resourceCloudStackTemplateUpdate(d *schema.ResourceData, meta interface{})
 }
 
 func resourceCloudStackTemplateDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.Template.NewDeleteTemplateParams(d.Id())
@@ -336,15 +338,17 @@ func resourceCloudStackTemplateDelete(d *schema.ResourceData, meta interface{})
 	log.Printf("[INFO] Deleting template: %s", d.Get("name").(string))
 	_, err := cs.Template.DeleteTemplate(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
 		return fmt.Errorf("Error deleting template %s: %s", d.Get("name").(string), err)
 	}
+
+	// Make sure a new template with the same name is looked up again
+	cs.lookups.forget("template", d.Get("name").(string))
+
 	return nil
 }
 
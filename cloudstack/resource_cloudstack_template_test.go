@@ -88,6 +88,62 @@ func TestAccCloudStackTemplate_update(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackTemplate_import(t *testing.T) {
+	if cloudStackTemplateURL == "" {
+		t.Skip("This test requires an upload URL")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackTemplate_basic,
+			},
+
+			{
+				ResourceName:            "cloudstack_template.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"url"},
+			},
+		},
+	})
+}
+
+func TestCloudStackTemplate_simulator(t *testing.T) {
+	var template cloudstack.Template
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccCloudStackTemplate_isReadyTimeout, "http://example.com/terraform.qcow2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackTemplateExists("cloudstack_template.foo", &template),
+					testAccCheckCloudStackTemplateBasicAttributes(&template),
+					resource.TestCheckResourceAttr(
+						"cloudstack_template.foo", "is_ready_timeout", "300"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_template.foo", "is_ready", "true"),
+				),
+			},
+
+			{
+				ResourceName:            "cloudstack_template.foo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"url", "is_ready_timeout"},
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackTemplateExists(
 	n string, template *cloudstack.Template) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -100,7 +156,7 @@ func testAccCheckCloudStackTemplateExists(
 			return fmt.Errorf("No template ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		tmpl, _, err := cs.Template.GetTemplateByID(rs.Primary.ID, "executable")
 
 		if err != nil {
@@ -166,7 +222,7 @@ func testAccCheckCloudStackTemplateUpdatedAttributes(
 }
 
 func testAccCheckCloudStackTemplateDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_template" {
@@ -211,3 +267,14 @@ resource "cloudstack_template" "foo" {
   password_enabled = true
   zone = "Sandbox-simulator"
 }`, cloudStackTemplateURL)
+
+const testAccCloudStackTemplate_isReadyTimeout = `
+resource "cloudstack_template" "foo" {
+  name = "terraform-test"
+  format = "QCOW2"
+  hypervisor = "Simulator"
+  os_type = "Centos 5.6 (64-bit)"
+  url = "%s"
+  zone = "Sandbox-simulator"
+  is_ready_timeout = 300
+}`
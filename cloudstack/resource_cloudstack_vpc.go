@@ -22,7 +22,6 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/xanzy/go-cloudstack/v2/cloudstack"
@@ -38,6 +37,12 @@ func resourceCloudStackVPC() *schema.Resource {
 			State: importStatePassthrough,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -76,6 +81,21 @@ func resourceCloudStackVPC() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"account": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"project"},
+			},
+
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
 			"source_nat_ip": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -83,7 +103,8 @@ func resourceCloudStackVPC() *schema.Resource {
 
 			"zone": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 
@@ -93,7 +114,7 @@ func resourceCloudStackVPC() *schema.Resource {
 }
 
 func resourceCloudStackVPCCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 
 	name := d.Get("name").(string)
 
@@ -104,7 +125,7 @@ func resourceCloudStackVPCCreate(d *schema.ResourceData, meta interface{}) error
 	}
 
 	// Retrieve the zone ID
-	zoneid, e := retrieveID(cs, "zone", d.Get("zone").(string))
+	zoneid, e := retrieveZoneID(cs, d)
 	if e != nil {
 		return e.Error()
 	}
@@ -135,6 +156,11 @@ func resourceCloudStackVPCCreate(d *schema.ResourceData, meta interface{}) error
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Create the new VPC
 	r, err := cs.VPC.CreateVPC(p)
 	if err != nil {
@@ -153,15 +179,16 @@ func resourceCloudStackVPCCreate(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceCloudStackVPCRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the VPC details
-	v, count, err := cs.VPC.GetVPCByID(
+	v, _, err := cs.VPC.GetVPCByID(
 		d.Id(),
-		cloudstack.WithProject(d.Get("project").(string)),
+		cloudstack.WithProject(getProject(cs, d)),
+		withAccount(cs, d),
 	)
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] VPC %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
@@ -176,11 +203,7 @@ func resourceCloudStackVPCRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("cidr", v.Cidr)
 	d.Set("network_domain", v.Networkdomain)
 
-	tags := make(map[string]interface{})
-	for _, tag := range v.Tags {
-		tags[tag.Key] = tag.Value
-	}
-	d.Set("tags", tags)
+	readTags(cs, d, v.Tags)
 
 	// Get the VPC offering details
 	o, _, err := cs.VPC.GetVPCOfferingByID(v.Vpcofferingid)
@@ -190,6 +213,7 @@ func resourceCloudStackVPCRead(d *schema.ResourceData, meta interface{}) error {
 
 	setValueOrID(d, "vpc_offering", o.Name, v.Vpcofferingid)
 	setValueOrID(d, "project", v.Project, v.Projectid)
+	readAccount(d, v.Account, v.Domain, v.Domainid, v.Projectid)
 	setValueOrID(d, "zone", v.Zonename, v.Zoneid)
 
 	// Create a new parameter struct
@@ -202,6 +226,11 @@ func resourceCloudStackVPCRead(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	// If there is an account supplied, we retrieve and set the account and domain id
+	if err := setAccount(p, cs, d); err != nil {
+		return err
+	}
+
 	// Get the source NAT IP assigned to the VPC
 	l, err := cs.Address.ListPublicIpAddresses(p)
 	if err != nil {
@@ -216,7 +245,7 @@ func resourceCloudStackVPCRead(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceCloudStackVPCUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 
 	name := d.Get("name").(string)
 
@@ -271,7 +300,7 @@ func resourceCloudStackVPCUpdate(d *schema.ResourceData, meta interface{}) error
 }
 
 func resourceCloudStackVPCDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.VPC.NewDeleteVPCParams(d.Id())
@@ -279,10 +308,8 @@ func resourceCloudStackVPCDelete(d *schema.ResourceData, meta interface{}) error
 	// Delete the VPC
 	_, err := cs.VPC.DeleteVPC(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
@@ -70,6 +70,31 @@ func TestAccCloudStackVPC_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackVPC_simulator(t *testing.T) {
+	var vpc cloudstack.VPC
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackVPCDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackVPC_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackVPCExists(
+						"cloudstack_vpc.foo", &vpc),
+					testAccCheckCloudStackVPCAttributes(&vpc),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpc.foo", "vpc_offering", "Default VPC offering"),
+					testAccCheckResourceTags(&vpc),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackVPCExists(
 	n string, vpc *cloudstack.VPC) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -82,7 +107,7 @@ func testAccCheckCloudStackVPCExists(
 			return fmt.Errorf("No VPC ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		v, _, err := cs.VPC.GetVPCByID(rs.Primary.ID)
 
 		if err != nil {
@@ -124,7 +149,7 @@ func testAccCheckCloudStackVPCAttributes(
 }
 
 func testAccCheckCloudStackVPCDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_vpc" {
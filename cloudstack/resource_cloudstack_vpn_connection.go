@@ -22,10 +22,8 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackVPNConnection() *schema.Resource {
@@ -33,6 +31,14 @@ func resourceCloudStackVPNConnection() *schema.Resource {
 		Create: resourceCloudStackVPNConnectionCreate,
 		Read:   resourceCloudStackVPNConnectionRead,
 		Delete: resourceCloudStackVPNConnectionDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"customer_gateway_id": {
@@ -51,7 +57,7 @@ func resourceCloudStackVPNConnection() *schema.Resource {
 }
 
 func resourceCloudStackVPNConnectionCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 
 	// Create a new parameter struct
 	p := cs.VPN.NewCreateVpnConnectionParams(
@@ -71,12 +77,12 @@ func resourceCloudStackVPNConnectionCreate(d *schema.ResourceData, meta interfac
 }
 
 func resourceCloudStackVPNConnectionRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the VPN Connection details
-	v, count, err := cs.VPN.GetVpnConnectionByID(d.Id())
+	v, _, err := cs.VPN.GetVpnConnectionByID(d.Id())
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf("[DEBUG] VPN Connection does no longer exist")
 			d.SetId("")
 			return nil
@@ -92,7 +98,7 @@ func resourceCloudStackVPNConnectionRead(d *schema.ResourceData, meta interface{
 }
 
 func resourceCloudStackVPNConnectionDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.VPN.NewDeleteVpnConnectionParams(d.Id())
@@ -100,10 +106,8 @@ func resourceCloudStackVPNConnectionDelete(d *schema.ResourceData, meta interfac
 	// Delete the VPN Connection
 	_, err := cs.VPN.DeleteVpnConnection(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
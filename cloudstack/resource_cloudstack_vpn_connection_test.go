@@ -49,6 +49,54 @@ func TestAccCloudStackVPNConnection_basic(t *testing.T) {
 	})
 }
 
+func TestAccCloudStackVPNConnection_import(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackVPNConnectionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackVPNConnection_basic,
+			},
+
+			{
+				ResourceName:      "cloudstack_vpn_connection.foo-bar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCloudStackVPNConnection_simulator(t *testing.T) {
+	var vpnConnection cloudstack.VpnConnection
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackVPNConnectionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackVPNConnection_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackVPNConnectionExists(
+						"cloudstack_vpn_connection.foo-bar", &vpnConnection),
+					testAccCheckCloudStackVPNConnectionExists(
+						"cloudstack_vpn_connection.bar-foo", &vpnConnection),
+				),
+			},
+
+			{
+				ResourceName:      "cloudstack_vpn_connection.foo-bar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackVPNConnectionExists(
 	n string, vpnConnection *cloudstack.VpnConnection) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -61,7 +109,7 @@ func testAccCheckCloudStackVPNConnectionExists(
 			return fmt.Errorf("No VPN Connection ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		v, _, err := cs.VPN.GetVpnConnectionByID(rs.Primary.ID)
 
 		if err != nil {
@@ -79,7 +127,7 @@ func testAccCheckCloudStackVPNConnectionExists(
 }
 
 func testAccCheckCloudStackVPNConnectionDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_vpn_connection" {
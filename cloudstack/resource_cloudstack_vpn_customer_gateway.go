@@ -22,10 +22,8 @@ package cloudstack
 import (
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/xanzy/go-cloudstack/v2/cloudstack"
 )
 
 func resourceCloudStackVPNCustomerGateway() *schema.Resource {
@@ -38,6 +36,12 @@ func resourceCloudStackVPNCustomerGateway() *schema.Resource {
 			State: importStatePassthrough,
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(providerTimeout),
+			Update: schema.DefaultTimeout(providerTimeout),
+			Delete: schema.DefaultTimeout(providerTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -98,7 +102,7 @@ func resourceCloudStackVPNCustomerGateway() *schema.Resource {
 }
 
 func resourceCloudStackVPNCustomerGatewayCreate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutCreate))
 
 	// Create a new parameter struct
 	p := cs.VPN.NewCreateVpnCustomerGatewayParams(
@@ -140,12 +144,12 @@ func resourceCloudStackVPNCustomerGatewayCreate(d *schema.ResourceData, meta int
 }
 
 func resourceCloudStackVPNCustomerGatewayRead(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := meta.(*Client)
 
 	// Get the VPN Customer Gateway details
-	v, count, err := cs.VPN.GetVpnCustomerGatewayByID(d.Id())
+	v, _, err := cs.VPN.GetVpnCustomerGatewayByID(d.Id())
 	if err != nil {
-		if count == 0 {
+		if isNotFound(err) {
 			log.Printf(
 				"[DEBUG] VPN Customer Gateway %s does no longer exist", d.Get("name").(string))
 			d.SetId("")
@@ -171,7 +175,7 @@ func resourceCloudStackVPNCustomerGatewayRead(d *schema.ResourceData, meta inter
 }
 
 func resourceCloudStackVPNCustomerGatewayUpdate(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutUpdate))
 
 	// Create a new parameter struct
 	p := cs.VPN.NewUpdateVpnCustomerGatewayParams(
@@ -207,7 +211,7 @@ func resourceCloudStackVPNCustomerGatewayUpdate(d *schema.ResourceData, meta int
 }
 
 func resourceCloudStackVPNCustomerGatewayDelete(d *schema.ResourceData, meta interface{}) error {
-	cs := meta.(*cloudstack.CloudStackClient)
+	cs := clientWithTimeout(meta.(*Client), d.Timeout(schema.TimeoutDelete))
 
 	// Create a new parameter struct
 	p := cs.VPN.NewDeleteVpnCustomerGatewayParams(d.Id())
@@ -215,10 +219,8 @@ func resourceCloudStackVPNCustomerGatewayDelete(d *schema.ResourceData, meta int
 	// Delete the VPN Customer Gateway
 	_, err := cs.VPN.DeleteVpnCustomerGateway(p)
 	if err != nil {
-		// This is a very poor way to be told the ID does no longer exist :(
-		if strings.Contains(err.Error(), fmt.Sprintf(
-			"Invalid parameter id value=%s due to incorrect long value format, "+
-				"or entity does not exist", d.Id())) {
+		// The object may already be deleted outside of Terraform
+		if isNotFound(err) {
 			return nil
 		}
 
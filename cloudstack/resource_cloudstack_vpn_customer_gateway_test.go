@@ -120,6 +120,53 @@ func TestAccCloudStackVPNCustomerGateway_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackVPNCustomerGateway_simulator(t *testing.T) {
+	var vpnCustomerGateway cloudstack.VpnCustomerGateway
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackVPNCustomerGatewayDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackVPNCustomerGateway_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackVPNCustomerGatewayExists(
+						"cloudstack_vpn_customer_gateway.foo", &vpnCustomerGateway),
+					testAccCheckCloudStackVPNCustomerGatewayAttributes(&vpnCustomerGateway),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.foo", "name", "terraform-foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.bar", "name", "terraform-bar"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.bar", "esp_policy", "aes256-sha1"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.foo", "ike_policy", "aes256-sha1;modp1536"),
+				),
+			},
+
+			{
+				Config: testAccCloudStackVPNCustomerGateway_update,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackVPNCustomerGatewayExists(
+						"cloudstack_vpn_customer_gateway.foo", &vpnCustomerGateway),
+					testAccCheckCloudStackVPNCustomerGatewayUpdatedAttributes(&vpnCustomerGateway),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.foo", "name", "terraform-foo-bar"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.bar", "name", "terraform-bar-foo"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.bar", "esp_policy", "3des-md5"),
+					resource.TestCheckResourceAttr(
+						"cloudstack_vpn_customer_gateway.foo", "ike_policy", "3des-md5;modp1536"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackVPNCustomerGatewayExists(
 	n string, vpnCustomerGateway *cloudstack.VpnCustomerGateway) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -132,7 +179,7 @@ func testAccCheckCloudStackVPNCustomerGatewayExists(
 			return fmt.Errorf("No VPN CustomerGateway ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		v, _, err := cs.VPN.GetVpnCustomerGatewayByID(rs.Primary.ID)
 
 		if err != nil {
@@ -190,7 +237,7 @@ func testAccCheckCloudStackVPNCustomerGatewayUpdatedAttributes(
 }
 
 func testAccCheckCloudStackVPNCustomerGatewayDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_vpn_customer_gateway" {
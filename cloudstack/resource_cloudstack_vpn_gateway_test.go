@@ -66,6 +66,27 @@ func TestAccCloudStackVPNGateway_import(t *testing.T) {
 	})
 }
 
+func TestCloudStackVPNGateway_simulator(t *testing.T) {
+	var vpnGateway cloudstack.VpnGateway
+
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	sim.test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCloudStackVPNGatewayDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudStackVPNGateway_basic,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCloudStackVPNGatewayExists(
+						"cloudstack_vpn_gateway.foo", &vpnGateway),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckCloudStackVPNGatewayExists(
 	n string, vpnGateway *cloudstack.VpnGateway) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
@@ -78,7 +99,7 @@ func testAccCheckCloudStackVPNGatewayExists(
 			return fmt.Errorf("No VPN Gateway ID is set")
 		}
 
-		cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+		cs := testAccProvider.Meta().(*Client)
 		v, _, err := cs.VPN.GetVpnGatewayByID(rs.Primary.ID)
 
 		if err != nil {
@@ -96,7 +117,7 @@ func testAccCheckCloudStackVPNGatewayExists(
 }
 
 func testAccCheckCloudStackVPNGatewayDestroy(s *terraform.State) error {
-	cs := testAccProvider.Meta().(*cloudstack.CloudStackClient)
+	cs := testAccProvider.Meta().(*Client)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "cloudstack_vpn_gateway" {
@@ -20,9 +20,13 @@
 package cloudstack
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -57,29 +61,54 @@ func setValueOrID(d *schema.ResourceData, key string, value string, id string) {
 	}
 }
 
-func retrieveID(cs *cloudstack.CloudStackClient, name string, value string, opts ...cloudstack.OptionFunc) (id string, e *retrieveError) {
+// retrieveID retrieves the ID of the object of the given kind with the given
+// name. Retrieved IDs are cached for the lifetime of the provider, unless any
+// options are given (as they may change the result).
+func retrieveID(cs *Client, name string, value string, opts ...cloudstack.OptionFunc) (id string, e *retrieveError) {
 	// If the supplied value isn't a ID, try to retrieve the ID ourselves
 	if cloudstack.IsID(value) {
 		return value, nil
 	}
 
-	log.Printf("[DEBUG] Retrieving ID of %s: %s", name, value)
+	lookup := func() (string, error) {
+		log.Printf("[DEBUG] Retrieving ID of %s: %s", name, value)
+		return lookupID(cs, name, value, opts...)
+	}
 
-	// Ignore counts, since an error is returned if there is no exact match
 	var err error
+	if len(opts) > 0 {
+		id, err = lookup()
+	} else {
+		id, err = cs.lookups.get(cs, lookupKey{kind: name, name: value}, lookup)
+	}
+
+	if err != nil {
+		return id, &retrieveError{name: name, value: value, err: err}
+	}
+
+	return id, nil
+}
+
+// lookupID retrieves the ID of the object of the given kind with the given
+// name using the CloudStack API.
+func lookupID(cs *Client, name string, value string, opts ...cloudstack.OptionFunc) (id string, err error) {
+	// Ignore counts, since an error is returned if there is no exact match
 	switch name {
 	case "disk_offering":
-		id, _, err = cs.DiskOffering.GetDiskOfferingID(value)
+		id, _, err = cs.DiskOffering.GetDiskOfferingID(value, opts...)
 	case "service_offering":
-		id, _, err = cs.ServiceOffering.GetServiceOfferingID(value)
+		id, _, err = cs.ServiceOffering.GetServiceOfferingID(value, opts...)
 	case "network_offering":
-		id, _, err = cs.NetworkOffering.GetNetworkOfferingID(value)
+		id, _, err = cs.NetworkOffering.GetNetworkOfferingID(value, opts...)
+	case "domain":
+		id, _, err = cs.Domain.GetDomainID(value, opts...)
 	case "project":
-		id, _, err = cs.Project.GetProjectID(value)
+		// List all projects, the same as when prefetching projects
+		id, _, err = cs.Project.GetProjectID(value, append(opts, withListall)...)
 	case "vpc_offering":
-		id, _, err = cs.VPC.GetVPCOfferingID(value)
+		id, _, err = cs.VPC.GetVPCOfferingID(value, opts...)
 	case "zone":
-		id, _, err = cs.Zone.GetZoneID(value)
+		id, _, err = cs.Zone.GetZoneID(value, opts...)
 	case "os_type":
 		p := cs.GuestOS.NewListOsTypesParams()
 		p.SetDescription(value)
@@ -94,59 +123,146 @@ func retrieveID(cs *cloudstack.CloudStackClient, name string, value string, opts
 		}
 		err = fmt.Errorf("Could not find ID of OS Type: %s", value)
 	default:
-		return id, &retrieveError{name: name, value: value,
-			err: fmt.Errorf("Unknown request: %s", name)}
+		err = fmt.Errorf("Unknown request: %s", name)
+	}
+
+	return id, err
+}
+
+// withListall is an option listing all objects the caller has access to,
+// instead of only the objects owned by the caller.
+func withListall(_ *cloudstack.CloudStackClient, p interface{}) error {
+	if ps, ok := p.(interface{ SetListall(bool) }); ok {
+		ps.SetListall(true)
+	}
+	return nil
+}
+
+// retrieveZoneID retrieves the ID of the zone of the resource, falling back to
+// the default zone of the provider if the resource has no zone configured.
+func retrieveZoneID(cs *Client, d *schema.ResourceData) (id string, e *retrieveError) {
+	zone := d.Get("zone").(string)
+	if zone == "" {
+		zone = cs.defaultZone
+	}
+
+	if zone == "" {
+		return "", &retrieveError{name: "zone", value: zone,
+			err: errors.New("no zone configured and no default_zone set for the provider")}
+	}
+
+	return retrieveID(cs, "zone", zone)
+}
+
+func retrieveTemplateID(cs *Client, zoneid, value string) (id string, e *retrieveError) {
+	// If the supplied value isn't a ID, try to retrieve the ID ourselves
+	if cloudstack.IsID(value) {
+		return value, nil
 	}
 
+	key := lookupKey{kind: "template", name: value, scope: zoneid}
+	id, err := cs.lookups.get(cs, key, func() (string, error) {
+		log.Printf("[DEBUG] Retrieving ID of template: %s", value)
+
+		// Ignore count, since an error is returned if there is no exact match
+		id, _, err := cs.Template.GetTemplateID(value, "executable", zoneid)
+		return id, err
+	})
 	if err != nil {
-		return id, &retrieveError{name: name, value: value, err: err}
+		return id, &retrieveError{name: "template", value: value, err: err}
 	}
 
 	return id, nil
 }
 
-func retrieveTemplateID(cs *cloudstack.CloudStackClient, zoneid, value string) (id string, e *retrieveError) {
+func retrieveISOID(cs *Client, zoneid, value string) (id string, e *retrieveError) {
 	// If the supplied value isn't a ID, try to retrieve the ID ourselves
 	if cloudstack.IsID(value) {
 		return value, nil
 	}
 
-	log.Printf("[DEBUG] Retrieving ID of template: %s", value)
+	key := lookupKey{kind: "iso", name: value, scope: zoneid}
+	id, err := cs.lookups.get(cs, key, func() (string, error) {
+		log.Printf("[DEBUG] Retrieving ID of ISO: %s", value)
 
-	// Ignore count, since an error is returned if there is no exact match
-	id, _, err := cs.Template.GetTemplateID(value, "executable", zoneid)
+		// Ignore count, since an error is returned if there is no exact match
+		id, _, err := cs.ISO.GetIsoID(value, "executable", zoneid)
+		return id, err
+	})
 	if err != nil {
-		return id, &retrieveError{name: "template", value: value, err: err}
+		return id, &retrieveError{name: "ISO", value: value, err: err}
 	}
 
 	return id, nil
 }
 
+// readyWait is the time to wait between checks if a registered template or
+// ISO is ready to use.
+var readyWait = 10 * time.Second
+
+// waitForReady waits until a registered template or ISO is ready to use, by
+// reading the resource until is_ready is set, or timeout with an error.
+func waitForReady(d *schema.ResourceData, meta interface{}, kind string, read schema.ReadFunc, timeout time.Duration) error {
+	start := time.Now()
+	for {
+		// Start with the sleep so the register action has a few seconds
+		// to process the registration correctly.
+		time.Sleep(readyWait)
+
+		if err := read(d, meta); err != nil {
+			return err
+		}
+
+		if d.Get("is_ready").(bool) {
+			return nil
+		}
+
+		if time.Since(start) > timeout {
+			return fmt.Errorf("Timeout while waiting for %s to become ready", kind)
+		}
+	}
+}
+
 // RetryFunc is the function retried n times
 type RetryFunc func() (interface{}, error)
 
+// retryWait is the policy used by Retry to wait between attempts
+var retryWait = retryPolicy{minWait: 5 * time.Second, maxWait: 30 * time.Second}
+
 // Retry is a wrapper around a RetryFunc that will retry a function
-// n times or until it succeeds.
+// n times or until it succeeds, using an exponential backoff between
+// attempts. Errors telling the object does not exist are returned
+// immediately, as retrying will not help.
 func Retry(n int, f RetryFunc) (interface{}, error) {
 	var lastErr error
 
 	for i := 0; i < n; i++ {
 		r, err := f()
-		if err == nil || err == cloudstack.AsyncTimeoutErr {
+		if err == nil || err == cloudstack.AsyncTimeoutErr || isNotFound(err) {
 			return r, err
 		}
 
 		lastErr = err
-		time.Sleep(30 * time.Second)
+		if i < n-1 {
+			time.Sleep(retryWait.backoff(i + 1))
+		}
 	}
 
 	return nil, lastErr
 }
 
-// If there is a project supplied, we retrieve and set the project id
-func setProjectid(p cloudstack.ProjectIDSetter, cs *cloudstack.CloudStackClient, d *schema.ResourceData) error {
-	if project, ok := d.GetOk("project"); ok {
-		projectid, e := retrieveID(cs, "project", project.(string))
+// resourceGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff, so the helpers below can also be used while planning.
+type resourceGetter interface {
+	Get(string) interface{}
+	GetOk(string) (interface{}, bool)
+}
+
+// If there is a project supplied (or a default project is configured for the
+// provider), we retrieve and set the project id
+func setProjectid(p cloudstack.ProjectIDSetter, cs *Client, d resourceGetter) error {
+	if project := getProject(cs, d); project != "" {
+		projectid, e := retrieveID(cs, "project", project)
 		if e != nil {
 			return e.Error()
 		}
@@ -156,15 +272,324 @@ func setProjectid(p cloudstack.ProjectIDSetter, cs *cloudstack.CloudStackClient,
 	return nil
 }
 
-// importStatePassthrough is a generic importer with project support.
+// getProject returns the project of the resource, falling back to the default
+// project of the provider if the resource has no project and no account
+// configured.
+func getProject(cs *Client, d resourceGetter) string {
+	if project, ok := d.GetOk("project"); ok {
+		return project.(string)
+	}
+	if _, ok := d.GetOk("account"); ok {
+		return ""
+	}
+	return cs.defaultProject
+}
+
+// accountSetter is implemented by the parameters of all API calls that can be
+// scoped to an account.
+type accountSetter interface {
+	SetAccount(string)
+}
+
+// If there is an account and/or domain supplied, we retrieve the domain id and
+// set the account and domain id. An account can only be used together with the
+// domain it belongs to.
+func setAccount(p interface{}, cs *Client, d resourceGetter) error {
+	account := d.Get("account").(string)
+	domain := d.Get("domain").(string)
+
+	if account != "" && domain == "" {
+		return fmt.Errorf("The domain of account %s is required when setting the account", account)
+	}
+
+	if ps, ok := p.(cloudstack.DomainIDSetter); ok && domain != "" {
+		domainid, e := retrieveID(cs, "domain", domain)
+		if e != nil {
+			return e.Error()
+		}
+		ps.SetDomainid(domainid)
+	}
+
+	if ps, ok := p.(accountSetter); ok && account != "" {
+		ps.SetAccount(account)
+	}
+
+	return nil
+}
+
+// withAccount returns an option scoping a list or get call to the account and
+// domain of the resource.
+func withAccount(cs *Client, d resourceGetter) cloudstack.OptionFunc {
+	return func(_ *cloudstack.CloudStackClient, p interface{}) error {
+		return setAccount(p, cs, d)
+	}
+}
+
+// readAccount updates the account and domain of a resource. Resources owned by
+// a project belong to an account of the project itself, so the account is only
+// set for resources that are not owned by a project. The account and domain are
+// only updated when they were configured or imported, as otherwise the account
+// of the caller would end up in the state and getProject would stop falling
+// back to the default project.
+func readAccount(d *schema.ResourceData, account, domain, domainid, projectid string) {
+	if d.Get("account").(string) == "" && d.Get("domain").(string) == "" {
+		return
+	}
+	if projectid == "" {
+		d.Set("account", account)
+	}
+	setValueOrID(d, "domain", domain, domainid)
+}
+
+// importStatePassthrough is a generic importer with project support. Resources
+// that can be scoped to an account can also be imported using the domain and
+// account the resource belongs to.
 func importStatePassthrough(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-	// Try to split the ID to extract the optional project name.
-	s := strings.SplitN(d.Id(), "/", 2)
-	if len(s) == 2 {
+	// Try to split the ID to extract the optional project name, or the
+	// optional domain and account names.
+	s := strings.SplitN(d.Id(), "/", 3)
+	switch len(s) {
+	case 2:
 		d.Set("project", s[0])
+	case 3:
+		if err := d.Set("domain", s[0]); err != nil {
+			return nil, fmt.Errorf("Importing using a domain and account is not supported for this resource")
+		}
+		d.Set("account", s[1])
 	}
 
 	d.SetId(s[len(s)-1])
 
 	return []*schema.ResourceData{d}, nil
 }
+
+// importedRules is used when importing one of the rule aggregates. It groups
+// the live rules that only differ in their ports into a single rule, which is
+// how these rules are usually configured.
+type importedRules struct {
+	keys  []string
+	rules map[string]map[string]interface{}
+}
+
+func newImportedRules() *importedRules {
+	return &importedRules{rules: make(map[string]map[string]interface{})}
+}
+
+// add adds a live rule to the group with the given key. The port is used
+// as the key in the uuids map of the rule. Rules without a port (e.g. ICMP
+// rules) use their protocol as the key instead.
+func (r *importedRules) add(key string, rule map[string]interface{}, port string, uuid string) {
+	if _, ok := r.rules[key]; !ok {
+		rule["ports"] = &schema.Set{F: schema.HashString}
+		rule["uuids"] = make(map[string]interface{})
+
+		r.keys = append(r.keys, key)
+		r.rules[key] = rule
+	}
+
+	rule = r.rules[key]
+	uuids := rule["uuids"].(map[string]interface{})
+
+	if port == "" {
+		uuids[rule["protocol"].(string)] = uuid
+		return
+	}
+
+	rule["ports"].(*schema.Set).Add(port)
+	uuids[port] = uuid
+}
+
+// list returns all grouped rules in the order they were first added.
+func (r *importedRules) list() []interface{} {
+	rules := make([]interface{}, 0, len(r.keys))
+	for _, key := range r.keys {
+		rules = append(rules, r.rules[key])
+	}
+	return rules
+}
+
+// cidrSet returns a schema.Set containing all CIDRs of a comma separated
+// CIDR list, together with a sorted representation usable as a key.
+func cidrSet(cidrList string) (*schema.Set, string) {
+	cidrs := &schema.Set{F: schema.HashString}
+
+	var sorted []string
+	for _, cidr := range strings.Split(cidrList, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			cidrs.Add(cidr)
+			sorted = append(sorted, cidr)
+		}
+	}
+	sort.Strings(sorted)
+
+	return cidrs, strings.Join(sorted, ",")
+}
+
+// validatePorts validates a port or port range like '80' or '80-90'.
+func validatePorts(v interface{}, k string) (ws []string, errs []error) {
+	m := splitPorts.FindStringSubmatch(v.(string))
+	if m == nil {
+		errs = append(errs, fmt.Errorf(
+			"%q is not a valid port value. Valid options are '80' or '80-90'", v.(string)))
+		return
+	}
+
+	startPort, _ := strconv.Atoi(m[1])
+	endPort := startPort
+	if m[2] != "" {
+		endPort, _ = strconv.Atoi(m[2])
+	}
+
+	if startPort < 1 || endPort > 65535 || startPort > endPort {
+		errs = append(errs, fmt.Errorf(
+			"%q is not a valid port range. Ports should be between 1 and 65535 and "+
+				"the start port should not be greater than the end port", v.(string)))
+	}
+
+	return
+}
+
+// validateCIDR validates an IPv4 or IPv6 CIDR like '10.0.0.0/8'.
+func validateCIDR(v interface{}, k string) (ws []string, errs []error) {
+	if _, _, err := net.ParseCIDR(v.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q is not a valid CIDR: %s", v.(string), err))
+	}
+	return
+}
+
+// validateProtocol returns a validation function that accepts the given
+// protocol names and, if numbers is true, any protocol number.
+func validateProtocol(numbers bool, protocols ...string) schema.SchemaValidateFunc {
+	return func(v interface{}, k string) (ws []string, errs []error) {
+		protocol := v.(string)
+
+		for _, p := range protocols {
+			if protocol == p {
+				return
+			}
+		}
+
+		if numbers {
+			if n, err := strconv.Atoi(protocol); err == nil && n >= 0 && n <= 255 {
+				return
+			}
+		}
+
+		valid := "'" + strings.Join(protocols, "', '") + "'"
+		if numbers {
+			valid += " or a valid protocol number"
+		}
+
+		errs = append(errs, fmt.Errorf(
+			"%q is not a valid protocol. Valid options are %s", protocol, valid))
+
+		return
+	}
+}
+
+// verifyRulesDiff returns a CustomizeDiffFunc that verifies all rules of the
+// given set during plan, so invalid rules are reported before any of the
+// rules are created.
+func verifyRulesDiff(key string, verify func(rule map[string]interface{}) error) schema.CustomizeDiffFunc {
+	return func(d *schema.ResourceDiff, meta interface{}) error {
+		// We can only verify the rules once all values are known
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+
+		for _, rule := range d.Get(key).(*schema.Set).List() {
+			if err := verify(rule.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// verifyRuleProtocolParams verifies that the ports and ICMP fields of a rule
+// match the configured protocol.
+func verifyRuleProtocolParams(rule map[string]interface{}) error {
+	protocol := rule["protocol"].(string)
+	ports := rule["ports"].(*schema.Set)
+
+	switch protocol {
+	case "icmp":
+		if ports.Len() > 0 {
+			return fmt.Errorf(
+				"Parameter ports can not be used when using protocol 'icmp'")
+		}
+		if rule["icmp_type"].(int) == -1 && rule["icmp_code"].(int) > 0 {
+			return fmt.Errorf(
+				"Parameter icmp_code %d requires a specific icmp_type, not -1 (all types)",
+				rule["icmp_code"].(int))
+		}
+	case "tcp", "udp":
+		if ports.Len() == 0 {
+			return fmt.Errorf(
+				"Parameter ports is a required parameter when using protocol %q", protocol)
+		}
+	}
+
+	return nil
+}
+
+// verifyRuleDiff verifies that the ports and ICMP fields of a resource that
+// manages a single rule match the configured protocol.
+func verifyRuleDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// We can only verify the rule once all values are known
+	if !d.NewValueKnown("protocol") || !d.NewValueKnown("port") {
+		return nil
+	}
+
+	if d.Get("protocol").(string) != "icmp" &&
+		(d.Get("icmp_type").(int) != -1 || d.Get("icmp_code").(int) != -1) {
+		return fmt.Errorf(
+			"Parameters icmp_type and icmp_code can only be used when using protocol 'icmp'")
+	}
+
+	ports := &schema.Set{F: schema.HashString}
+	if port := d.Get("port").(string); port != "" {
+		ports.Add(port)
+	}
+
+	return verifyRuleProtocolParams(map[string]interface{}{
+		"protocol":  d.Get("protocol").(string),
+		"ports":     ports,
+		"icmp_type": d.Get("icmp_type").(int),
+		"icmp_code": d.Get("icmp_code").(int),
+	})
+}
+
+// splitPortRange returns the start and end port of a port or port range
+// like '80' or '80-90'.
+func splitPortRange(port string) (int, int, error) {
+	m := splitPorts.FindStringSubmatch(port)
+	if m == nil {
+		return 0, 0, fmt.Errorf(
+			"%q is not a valid port value. Valid options are '80' or '80-90'", port)
+	}
+
+	startPort, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	endPort := startPort
+	if m[2] != "" {
+		endPort, err = strconv.Atoi(m[2])
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return startPort, endPort, nil
+}
+
+// portRange returns the given start and end port as '80' or '80-90'.
+func portRange(startPort, endPort int) string {
+	if startPort == endPort {
+		return strconv.Itoa(startPort)
+	}
+	return fmt.Sprintf("%d-%d", startPort, endPort)
+}
@@ -0,0 +1,165 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestValidatePorts(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "80", ErrCount: 0},
+		{Value: "1000-2000", ErrCount: 0},
+		{Value: "1-65535", ErrCount: 0},
+		{Value: "80-", ErrCount: 1},
+		{Value: "-80", ErrCount: 1},
+		{Value: "http", ErrCount: 1},
+		{Value: "0", ErrCount: 1},
+		{Value: "65536", ErrCount: 1},
+		{Value: "2000-1000", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errs := validatePorts(tc.Value, "ports")
+		if len(errs) != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %q, got: %v", tc.ErrCount, tc.Value, errs)
+		}
+	}
+}
+
+func TestValidateCIDR(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{Value: "10.0.0.0/8", ErrCount: 0},
+		{Value: "0.0.0.0/0", ErrCount: 0},
+		{Value: "2001:db8::/32", ErrCount: 0},
+		{Value: "10.0.0.0", ErrCount: 1},
+		{Value: "10.0.0.0/33", ErrCount: 1},
+		{Value: "10.0.0.256/24", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateCIDR(tc.Value, "cidr_list")
+		if len(errs) != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %q, got: %v", tc.ErrCount, tc.Value, errs)
+		}
+	}
+}
+
+func TestValidateProtocol(t *testing.T) {
+	cases := []struct {
+		Numbers  bool
+		Value    string
+		ErrCount int
+	}{
+		{Numbers: false, Value: "tcp", ErrCount: 0},
+		{Numbers: false, Value: "icmp", ErrCount: 0},
+		{Numbers: false, Value: "tpc", ErrCount: 1},
+		{Numbers: false, Value: "TCP", ErrCount: 1},
+		{Numbers: false, Value: "6", ErrCount: 1},
+		{Numbers: true, Value: "6", ErrCount: 0},
+		{Numbers: true, Value: "256", ErrCount: 1},
+		{Numbers: true, Value: "all", ErrCount: 1},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateProtocol(tc.Numbers, "tcp", "udp", "icmp")(tc.Value, "protocol")
+		if len(errs) != tc.ErrCount {
+			t.Fatalf("Expected %d errors for %q, got: %v", tc.ErrCount, tc.Value, errs)
+		}
+	}
+}
+
+func TestVerifyRuleProtocolParams(t *testing.T) {
+	cases := []struct {
+		Protocol string
+		Ports    []interface{}
+		ICMPType int
+		ICMPCode int
+		Valid    bool
+	}{
+		{Protocol: "tcp", Ports: []interface{}{"80"}, Valid: true},
+		{Protocol: "tcp", Valid: false},
+		{Protocol: "udp", Valid: false},
+		{Protocol: "all", Valid: true},
+		{Protocol: "icmp", ICMPType: -1, ICMPCode: -1, Valid: true},
+		{Protocol: "icmp", ICMPType: 3, ICMPCode: 1, Valid: true},
+		{Protocol: "icmp", ICMPType: -1, ICMPCode: 1, Valid: false},
+		{Protocol: "icmp", Ports: []interface{}{"80"}, Valid: false},
+	}
+
+	for _, tc := range cases {
+		rule := map[string]interface{}{
+			"protocol":  tc.Protocol,
+			"ports":     schema.NewSet(schema.HashString, tc.Ports),
+			"icmp_type": tc.ICMPType,
+			"icmp_code": tc.ICMPCode,
+		}
+
+		err := verifyRuleProtocolParams(rule)
+		if tc.Valid && err != nil {
+			t.Fatalf("Unexpected error for %#v: %s", tc, err)
+		}
+		if !tc.Valid && err == nil {
+			t.Fatalf("Expected an error for %#v", tc)
+		}
+	}
+}
+
+func TestReadAccount(t *testing.T) {
+	cs := &Client{defaultProject: "terraform"}
+
+	cases := []struct {
+		Config  map[string]interface{}
+		Account string
+		Domain  string
+		Project string
+	}{
+		{Config: map[string]interface{}{}, Account: "", Domain: "", Project: "terraform"},
+		{
+			Config:  map[string]interface{}{"account": "acme", "domain": "customers"},
+			Account: "admin",
+			Domain:  "ROOT",
+			Project: "",
+		},
+	}
+
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, resourceCloudStackDisk().Schema, tc.Config)
+		readAccount(d, "admin", "ROOT", "domain-1", "")
+
+		if account := d.Get("account").(string); account != tc.Account {
+			t.Fatalf("Expected account %q for %v, got: %q", tc.Account, tc.Config, account)
+		}
+		if domain := d.Get("domain").(string); domain != tc.Domain {
+			t.Fatalf("Expected domain %q for %v, got: %q", tc.Domain, tc.Config, domain)
+		}
+		if project := getProject(cs, d); project != tc.Project {
+			t.Fatalf("Expected project %q for %v, got: %q", tc.Project, tc.Config, project)
+		}
+	}
+}
@@ -0,0 +1,552 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// retryPolicy describes how often and how long to wait before retrying a
+// failed API call.
+type retryPolicy struct {
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+// backoff returns the time to wait before the given retry (starting at 1).
+// The wait time doubles with every retry up to the maximum wait time, and
+// a random jitter of up to half the wait time is subtracted to prevent
+// concurrent calls from being retried at the same time.
+func (p retryPolicy) backoff(retry int) time.Duration {
+	wait := p.maxWait
+	if retry < 32 {
+		if w := p.minWait << uint(retry-1); w > 0 && w < p.maxWait {
+			wait = w
+		}
+	}
+
+	if half := int64(wait / 2); half > 0 {
+		wait -= time.Duration(rand.Int63n(half + 1))
+	}
+
+	return wait
+}
+
+// sleep waits before the given retry, unless the context is done first.
+func (p retryPolicy) sleep(ctx context.Context, retry int) error {
+	t := time.NewTimer(p.backoff(retry))
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryJobExpiry is how long an async job is remembered after it was last
+// submitted or queried. Jobs are queried at least every 15 seconds while their
+// result is awaited, so jobs that are not queried for this long are abandoned.
+const retryJobExpiry = 5 * time.Minute
+
+// retryTransport is a http.RoundTripper that retries API calls failing with
+// a transient error. Commands that only read (list, query and get commands)
+// are retried after a connection error, a HTTP 5xx or throttling response, or
+// a transient CloudStack error. Other commands may already have been executed
+// by CloudStack when their response is lost, so they are only retried when
+// they were not sent at all: after a dial error or a throttling response.
+//
+// Async jobs that fail with a transient error are retried by submitting the
+// original API call again, after which queries for the result of the failed
+// job are answered with the result of the new job.
+type retryTransport struct {
+	policy    retryPolicy
+	secret    string
+	timeout   time.Duration
+	transport http.RoundTripper
+
+	mu   sync.Mutex
+	jobs map[string]*retryJob
+}
+
+// retryJob is an async job that can be retried.
+type retryJob struct {
+	request *retryRequest
+	jobid   string
+	retries int
+	seen    time.Time
+}
+
+// retryRequest is a buffered API call that can be sent multiple times.
+type retryRequest struct {
+	req     *http.Request
+	body    []byte
+	command string
+	params  url.Values
+}
+
+func newRetryTransport(policy retryPolicy, secret string, timeout time.Duration, transport http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		policy:    policy,
+		secret:    secret,
+		timeout:   timeout,
+		transport: transport,
+		jobs:      make(map[string]*retryJob),
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, err := newRetryRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.command == "queryAsyncJobResult" {
+		return t.queryAsyncJobResult(r)
+	}
+
+	resp, body, err := t.do(req.Context(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the API calls that started an async job, so they can be
+	// submitted again when the job fails with a transient error
+	if resp.StatusCode == http.StatusOK && t.policy.maxRetries > 0 {
+		if jobid := responseJobID(body); jobid != "" {
+			t.mu.Lock()
+			t.forgetExpiredJobs()
+			t.jobs[jobid] = &retryJob{request: r, jobid: jobid, seen: time.Now()}
+			t.mu.Unlock()
+		}
+	}
+
+	return resp, nil
+}
+
+// forgetExpiredJobs removes the jobs whose result is no longer awaited, for
+// example because waiting for the job timed out. The lock must be held.
+func (t *retryTransport) forgetExpiredJobs() {
+	for jobid, job := range t.jobs {
+		if time.Since(job.seen) > retryJobExpiry {
+			delete(t.jobs, jobid)
+		}
+	}
+}
+
+// queryAsyncJobResult queries the result of the latest job started for the
+// queried job, and retries the job if it failed with a transient error.
+func (t *retryTransport) queryAsyncJobResult(r *retryRequest) (*http.Response, error) {
+	jobid := r.params.Get("jobid")
+
+	t.mu.Lock()
+	t.forgetExpiredJobs()
+	job := t.jobs[jobid]
+	if job != nil {
+		job.seen = time.Now()
+	}
+	t.mu.Unlock()
+
+	if job == nil {
+		resp, _, err := t.do(r.req.Context(), r)
+		return resp, err
+	}
+
+	// Forget the job on every exit, unless it is still pending and will be
+	// queried again
+	pending := false
+	defer func() {
+		if !pending {
+			t.mu.Lock()
+			delete(t.jobs, jobid)
+			t.mu.Unlock()
+		}
+	}()
+
+	for {
+		t.mu.Lock()
+		current := job.jobid
+		t.mu.Unlock()
+
+		q := r
+		if current != jobid {
+			q = r.withParam("jobid", current, t.secret)
+		}
+
+		resp, body, err := t.do(r.req.Context(), q)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		status, jobErr := responseJobStatus(body)
+		if status == 0 {
+			pending = jobErr == nil
+			return resp, nil
+		}
+
+		if status == 2 && job.retries < t.policy.maxRetries && isRetryable(jobErr) {
+			job.retries++
+
+			log.Printf("[DEBUG] Retrying %s (%d/%d) after transient failure: %s",
+				job.request.command, job.retries, t.policy.maxRetries, jobErr)
+
+			if err := t.policy.sleep(r.req.Context(), job.retries); err != nil {
+				return nil, err
+			}
+
+			// The context of the original call is done, so use the context
+			// of the current query to submit the original call again
+			jresp, jbody, err := t.do(r.req.Context(), job.request)
+			if err == nil && jresp.StatusCode == http.StatusOK {
+				if newid := responseJobID(jbody); newid != "" {
+					t.mu.Lock()
+					job.jobid = newid
+					t.mu.Unlock()
+					continue
+				}
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			// Report why submitting the call again failed as the result of
+			// the job, as that error is more relevant than the job error
+			if jresp.StatusCode != http.StatusOK {
+				return jobFailedResponse(jresp, jobid, jbody)
+			}
+
+			return resp, nil
+		}
+
+		return resp, nil
+	}
+}
+
+// do sends the request, retrying it if it failed with a transient error.
+// The returned response contains the (already read) body.
+func (t *retryTransport) do(ctx context.Context, r *retryRequest) (*http.Response, []byte, error) {
+	for retry := 0; ; retry++ {
+		resp, body, err := t.send(ctx, r)
+
+		reason := retryReason(r.command, resp, body, err)
+		if reason == nil || retry >= t.policy.maxRetries {
+			return resp, body, err
+		}
+
+		log.Printf("[DEBUG] Retrying %s (%d/%d) after transient failure: %s",
+			r.command, retry+1, t.policy.maxRetries, reason)
+
+		if err := t.policy.sleep(ctx, retry+1); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// send sends the request once, and reads the body of the response. The
+// timeout applies to every attempt, so waiting between retries does not
+// count towards it.
+func (t *retryTransport) send(ctx context.Context, r *retryRequest) (*http.Response, []byte, error) {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	resp, err := t.transport.RoundTrip(r.newRequest(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return resp, body, nil
+}
+
+// retryReason returns why the API call should be retried, or nil if the
+// result of the API call is final.
+func retryReason(command string, resp *http.Response, body []byte, err error) error {
+	// The request was never sent, or rejected before it was executed
+	if isDialError(err) {
+		return err
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return errors.New(resp.Status)
+	}
+
+	// Otherwise the command may have been executed, so only commands that
+	// do not change anything can safely be sent again
+	if !isReadOnly(command) {
+		return nil
+	}
+
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF || isRetryable(err) {
+			return err
+		}
+		return nil
+	}
+
+	if resp.StatusCode < 500 {
+		return nil
+	}
+
+	// CloudStack uses HTTP 5xx status codes for its own errors as well, so
+	// only retry those when the error itself is transient
+	var e csError
+	if raw, rerr := responseValue(body); rerr == nil && json.Unmarshal(raw, &e) == nil && e.ErrorCode != 0 {
+		csErr := fmt.Errorf("CloudStack API error %d (CSExceptionErrorCode: %d): %s",
+			e.ErrorCode, e.CSErrorCode, e.ErrorText)
+		if isRetryable(csErr) {
+			return csErr
+		}
+		return nil
+	}
+
+	return errors.New(resp.Status)
+}
+
+// isDialError returns true if the error occurred while connecting, so before
+// any part of the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isReadOnly returns true if the API command only reads, so it can be sent
+// again without side effects.
+func isReadOnly(command string) bool {
+	for _, prefix := range []string{"list", "query", "get"} {
+		if strings.HasPrefix(command, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRetryRequest(req *http.Request) (*retryRequest, error) {
+	r := &retryRequest{req: req, params: req.URL.Query()}
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.body = body
+
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			params, err := url.ParseQuery(string(body))
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range params {
+				r.params[k] = v
+			}
+		}
+	}
+
+	r.command = r.params.Get("command")
+
+	return r, nil
+}
+
+// newRequest returns a new HTTP request with the given context that can be
+// sent once.
+func (r *retryRequest) newRequest(ctx context.Context) *http.Request {
+	req := r.req.WithContext(ctx)
+	if r.body != nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(r.body))
+		req.ContentLength = int64(len(r.body))
+	}
+	return req
+}
+
+// withParam returns a copy of the (GET) request with the given parameter
+// changed. If the request was signed, the copy is signed again.
+func (r *retryRequest) withParam(key, value, secret string) *retryRequest {
+	params := url.Values{}
+	for k, v := range r.req.URL.Query() {
+		params[k] = v
+	}
+	params.Set(key, value)
+
+	signed := params.Get("signature") != ""
+	params.Del("signature")
+
+	query := encodeValues(params)
+	if signed {
+		query += "&signature=" + url.QueryEscape(signature(query, secret))
+	}
+
+	req := r.req.WithContext(r.req.Context())
+	u := *r.req.URL
+	u.RawQuery = query
+	req.URL = &u
+
+	c := *r
+	c.req = req
+	c.params = params
+
+	return &c
+}
+
+// signature returns the signature of the given (encoded) parameters in the
+// same way as the CloudStack client does.
+func signature(query, secret string) string {
+	s := strings.Replace(strings.ToLower(query), "+", "%20", -1)
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(s))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encodeValues encodes the given values sorted by key, only escaping the
+// values, in the same way as the CloudStack client does.
+func encodeValues(v url.Values) string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		for _, v := range v[k] {
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(k + "=" + url.QueryEscape(v))
+		}
+	}
+
+	return buf.String()
+}
+
+// responseValue returns the value of the single key of an API response.
+func responseValue(body []byte) (json.RawMessage, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	for _, v := range m {
+		return v, nil
+	}
+	return nil, fmt.Errorf("Unable to extract the raw value from: %s", body)
+}
+
+// responseJobID returns the ID of the async job started by an API call.
+func responseJobID(body []byte) string {
+	raw, err := responseValue(body)
+	if err != nil {
+		return ""
+	}
+
+	var r struct {
+		JobID string `json:"jobid"`
+	}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return ""
+	}
+
+	return r.JobID
+}
+
+// jobFailedResponse changes the given API error response into a response of
+// queryAsyncJobResult telling the given job failed with that error.
+func jobFailedResponse(resp *http.Response, jobid string, body []byte) (*http.Response, error) {
+	raw, err := responseValue(body)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"queryasyncjobresultresponse": map[string]interface{}{
+			"jobid":         jobid,
+			"jobstatus":     2,
+			"jobresulttype": "object",
+			"jobresult":     raw,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.StatusCode = http.StatusOK
+	resp.Status = "200 OK"
+	resp.ContentLength = int64(len(b))
+	resp.Header.Del("Content-Length")
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	return resp, nil
+}
+
+// responseJobStatus returns the status of a queried async job and, if the
+// job failed, the error in the same format as the CloudStack client does.
+func responseJobStatus(body []byte) (int, error) {
+	raw, err := responseValue(body)
+	if err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Jobstatus     int             `json:"jobstatus"`
+		Jobresult     json.RawMessage `json:"jobresult"`
+		Jobresulttype string          `json:"jobresulttype"`
+	}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return 0, err
+	}
+
+	if r.Jobstatus != 2 {
+		return r.Jobstatus, nil
+	}
+
+	if r.Jobresulttype == "text" {
+		return r.Jobstatus, errors.New(string(r.Jobresult))
+	}
+
+	return r.Jobstatus, fmt.Errorf("Undefined error: %s", string(r.Jobresult))
+}
@@ -0,0 +1,256 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := retryPolicy{maxRetries: 10, minWait: time.Second, maxWait: 10 * time.Second}
+
+	cases := []struct {
+		Retry int
+		Min   time.Duration
+		Max   time.Duration
+	}{
+		{Retry: 1, Min: 500 * time.Millisecond, Max: time.Second},
+		{Retry: 2, Min: time.Second, Max: 2 * time.Second},
+		{Retry: 3, Min: 2 * time.Second, Max: 4 * time.Second},
+		{Retry: 5, Min: 5 * time.Second, Max: 10 * time.Second},
+		{Retry: 100, Min: 5 * time.Second, Max: 10 * time.Second},
+	}
+
+	for _, c := range cases {
+		for i := 0; i < 100; i++ {
+			if wait := p.backoff(c.Retry); wait < c.Min || wait > c.Max {
+				t.Fatalf("Expected the wait before retry %d to be between %s and %s, got %s",
+					c.Retry, c.Min, c.Max, wait)
+			}
+		}
+	}
+}
+
+func TestRetryTransport_httpErrors(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"listzonesresponse":{}}`))
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: newRetryTransport(
+		retryPolicy{maxRetries: 2}, "", time.Minute, http.DefaultTransport)}
+
+	resp, err := client.Get(ts.URL + "?command=listZones")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("Expected the 3rd call to succeed, got status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+// roundTripFunc is a http.RoundTripper returning the results of a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransport_sideEffects(t *testing.T) {
+	cases := []struct {
+		Command string
+		Err     error
+		Status  int
+		Calls   int
+	}{
+		{Command: "listZones", Status: http.StatusServiceUnavailable, Calls: 3},
+		{Command: "listZones", Err: io.ErrUnexpectedEOF, Calls: 3},
+		{Command: "deployVirtualMachine", Status: http.StatusServiceUnavailable, Calls: 1},
+		{Command: "deployVirtualMachine", Err: io.ErrUnexpectedEOF, Calls: 1},
+		{Command: "deployVirtualMachine", Err: &net.OpError{Op: "read", Err: errors.New("i/o timeout")}, Calls: 1},
+		{Command: "deployVirtualMachine", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, Calls: 3},
+		{Command: "deployVirtualMachine", Status: http.StatusTooManyRequests, Calls: 3},
+	}
+
+	for _, c := range cases {
+		calls := 0
+		rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			if c.Err != nil {
+				return nil, c.Err
+			}
+			return &http.Response{
+				StatusCode: c.Status,
+				Status:     http.StatusText(c.Status),
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		})
+
+		client := &http.Client{Transport: newRetryTransport(
+			retryPolicy{maxRetries: 2}, "", time.Minute, rt)}
+
+		resp, err := client.Get("http://localhost/client/api?command=" + c.Command)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		if calls != c.Calls {
+			t.Errorf("Expected %d calls of %s failing with %v/%d, got %d",
+				c.Calls, c.Command, c.Err, c.Status, calls)
+		}
+	}
+}
+
+func TestRetryTransport_forgetJobs(t *testing.T) {
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"deployvirtualmachineresponse":{"jobid":"` + req.URL.Query().Get("id") + `"}}`
+		status := http.StatusOK
+		if req.URL.Query().Get("command") == "queryAsyncJobResult" {
+			body = `{"errorresponse":{"errorcode":431,"errortext":"Unable to query"}}`
+			status = 431
+		}
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+
+	transport := newRetryTransport(retryPolicy{maxRetries: 2}, "", time.Minute, rt)
+	client := &http.Client{Transport: transport}
+
+	get := func(query string) {
+		resp, err := client.Get("http://localhost/client/api?" + query)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	// Jobs are forgotten when querying their result fails
+	get("command=deployVirtualMachine&id=job-1")
+	if len(transport.jobs) != 1 {
+		t.Fatalf("Expected 1 remembered job, got %d", len(transport.jobs))
+	}
+
+	get("command=queryAsyncJobResult&jobid=job-1")
+	if len(transport.jobs) != 0 {
+		t.Fatalf("Expected the failed query to forget the job, got %d jobs", len(transport.jobs))
+	}
+
+	// Jobs whose result is never queried are forgotten once they expire
+	get("command=deployVirtualMachine&id=job-2")
+	transport.jobs["job-2"].seen = time.Now().Add(-2 * retryJobExpiry)
+
+	get("command=deployVirtualMachine&id=job-3")
+	if _, ok := transport.jobs["job-2"]; ok || len(transport.jobs) != 1 {
+		t.Fatalf("Expected only job-3 to be remembered, got %d jobs", len(transport.jobs))
+	}
+}
+
+func TestRetryTransport_simulator(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	cfg := Config{
+		APIURL:     sim.URL + "/client/api",
+		APIKey:     testSimAPIKey,
+		SecretKey:  testSimSecretKey,
+		Timeout:    10,
+		MaxRetries: 2,
+	}
+
+	cs, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Transient API errors of read only commands are retried
+	sim.fail("listVirtualMachines", 530, "Failed due to a concurrent operation, please try again")
+	_, err = cs.VirtualMachine.ListVirtualMachines(cs.VirtualMachine.NewListVirtualMachinesParams())
+	if err != nil {
+		t.Fatalf("Expected the retried call to succeed, got: %v", err)
+	}
+	if n := sim.count("listVirtualMachines"); n != 2 {
+		t.Fatalf("Expected 2 calls of listVirtualMachines, got %d", n)
+	}
+
+	// Other API errors are returned immediately
+	sim.fail("listVirtualMachines", 533, "Unable to list the virtual machines")
+	_, err = cs.VirtualMachine.ListVirtualMachines(cs.VirtualMachine.NewListVirtualMachinesParams())
+	if err == nil || !strings.Contains(err.Error(), "Unable to list") {
+		t.Fatalf("Expected the API error to be returned, got: %v", err)
+	}
+	if n := sim.count("listVirtualMachines"); n != 3 {
+		t.Fatalf("Expected 3 calls of listVirtualMachines, got %d", n)
+	}
+
+	// Commands that change something are not sent again, as CloudStack may
+	// already have executed them
+	p := cs.VirtualMachine.NewUpdateVirtualMachineParams("00000000-0000-4000-8000-000000000001")
+
+	sim.fail("updateVirtualMachine", 530, "Failed due to a concurrent operation, please try again")
+	_, err = cs.VirtualMachine.UpdateVirtualMachine(p)
+	if err == nil || !strings.Contains(err.Error(), "concurrent operation") {
+		t.Fatalf("Expected the API error to be returned, got: %v", err)
+	}
+	if n := sim.count("updateVirtualMachine"); n != 1 {
+		t.Fatalf("Expected 1 call of updateVirtualMachine, got %d", n)
+	}
+
+	// Async jobs failing with a transient error are submitted again, until
+	// the maximum number of retries is reached
+	for i := 0; i < 3; i++ {
+		sim.fail("deleteNetwork", 530, "resource busy")
+	}
+
+	_, err = cs.Network.DeleteNetwork(cs.Network.NewDeleteNetworkParams("00000000-0000-4000-8000-000000000001"))
+	if err == nil || !strings.Contains(err.Error(), "resource busy") {
+		t.Fatalf("Expected the async job to fail, got: %v", err)
+	}
+	if n := sim.count("deleteNetwork"); n != 3 {
+		t.Fatalf("Expected 3 calls of deleteNetwork, got %d", n)
+	}
+
+	sim.fail("deleteNetwork", 530, "resource busy")
+
+	_, err = cs.Network.DeleteNetwork(cs.Network.NewDeleteNetworkParams("00000000-0000-4000-8000-000000000001"))
+	if err == nil || !strings.Contains(err.Error(), "entity does not exist") {
+		t.Fatalf("Expected the retried async job to fail with a not found error, got: %v", err)
+	}
+	if n := sim.count("deleteNetwork"); n != 5 {
+		t.Fatalf("Expected 5 calls of deleteNetwork, got %d", n)
+	}
+}
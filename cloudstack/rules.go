@@ -0,0 +1,355 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// ruleItem is a single firewall rule, ACL item, security group rule or port
+// forward in the API, which is (part of) a configured rule.
+type ruleItem struct {
+	// The key used for the UUID of the item in the uuids map of the rule
+	key string
+
+	// The port (range) of the item, if any
+	port string
+
+	// The CIDR or the name of the security group of a security group rule
+	cidr  string
+	group string
+}
+
+// ruleHandler contains the resource specific functions needed to create and
+// delete the rules of a rule resource.
+type ruleHandler struct {
+	// The maximum number of API calls to run concurrently
+	parallelism int
+
+	// items returns all items of the given rule, after verifying the rule
+	items func(rule map[string]interface{}) ([]ruleItem, error)
+
+	// create creates a single item of the given rule and returns its UUID
+	create func(rule map[string]interface{}, item ruleItem) (string, error)
+
+	// delete deletes the item with the given UUID of the given rule
+	delete func(rule map[string]interface{}, id string) error
+
+	// uuids and setUUIDs can be used to override where the UUIDs of
+	// the items are saved, which defaults to the uuids map of the rule
+	uuids    func(rule map[string]interface{}) map[string]interface{}
+	setUUIDs func(rule map[string]interface{}, uuids map[string]interface{})
+}
+
+// reconcileRules deletes all rules stored under the given key that are no
+// longer configured and creates all newly configured rules. The state is
+// updated after each step, so it is correct even if the update failed.
+func reconcileRules(d *schema.ResourceData, key string, h *ruleHandler) error {
+	rules, ors, nrs := diffRules(d, key)
+
+	// First loop through all the old rules and delete them
+	if ors.Len() > 0 {
+		err := deleteRules(h, rules, ors)
+
+		// We need to update this first to preserve the correct state
+		d.Set(key, rules)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Then loop through all the new rules and create them
+	if nrs.Len() > 0 {
+		err := createRules(h, rules, nrs)
+
+		// We need to update this first to preserve the correct state
+		d.Set(key, rules)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffRules returns the rules stored under the given key that did not change,
+// the old rules that need to be deleted and the new rules that need to be
+// created. Any rules that are not deleted correctly and any newly created
+// rules should be added to the unchanged rules, which are then saved to make
+// sure we end up in a consistent state.
+func diffRules(d *schema.ResourceData, key string) (*schema.Set, *schema.Set, *schema.Set) {
+	o, n := d.GetChange(key)
+	rules := o.(*schema.Set).Intersection(n.(*schema.Set))
+	ors := o.(*schema.Set).Difference(n.(*schema.Set))
+	nrs := n.(*schema.Set).Difference(o.(*schema.Set))
+
+	return rules, ors, nrs
+}
+
+// createRules creates all items of the given rules that don't have a UUID yet.
+// All rules that have at least one item are added to rules, with the ports
+// and sources of the items that failed to be created removed.
+func createRules(h *ruleHandler, rules *schema.Set, nrs *schema.Set) error {
+	var errs *multierror.Error
+
+	type job struct {
+		rule int
+		item ruleItem
+		id   string
+	}
+
+	list := nrs.List()
+	items := make([][]ruleItem, len(list))
+	uuids := make([]map[string]interface{}, len(list))
+
+	var jobs []*job
+	for i, rule := range list {
+		rule := rule.(map[string]interface{})
+		uuids[i] = h.getUUIDs(rule)
+
+		is, err := h.items(rule)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		items[i] = is
+
+		for _, item := range is {
+			if _, ok := uuids[i][item.key]; ok {
+				continue
+			}
+			jobs = append(jobs, &job{rule: i, item: item})
+		}
+	}
+
+	results := runParallel(h.parallelism, len(jobs), func(i int) error {
+		id, err := h.create(list[jobs[i].rule].(map[string]interface{}), jobs[i].item)
+		if err != nil {
+			return err
+		}
+		jobs[i].id = id
+		return nil
+	})
+
+	for i, j := range jobs {
+		if results[i] != nil {
+			errs = multierror.Append(errs, results[i])
+			continue
+		}
+		uuids[j.rule][j.item.key] = j.id
+	}
+
+	for i, rule := range list {
+		saveRule(h, rules, rule.(map[string]interface{}), items[i], uuids[i])
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// deleteRules deletes all items of the given rules. All rules that still have
+// at least one item are added to rules, so they can be deleted again later.
+func deleteRules(h *ruleHandler, rules *schema.Set, ors *schema.Set) error {
+	var errs *multierror.Error
+
+	type job struct {
+		rule int
+		key  string
+		id   string
+	}
+
+	list := ors.List()
+	uuids := make([]map[string]interface{}, len(list))
+
+	var jobs []job
+	for i, rule := range list {
+		uuids[i] = h.getUUIDs(rule.(map[string]interface{}))
+
+		var keys []string
+		for k := range uuids[i] {
+			// We don't care about the count here, so just continue
+			if k == "%" {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			jobs = append(jobs, job{rule: i, key: k, id: uuids[i][k].(string)})
+		}
+	}
+
+	results := runParallel(h.parallelism, len(jobs), func(i int) error {
+		err := h.delete(list[jobs[i].rule].(map[string]interface{}), jobs[i].id)
+
+		// The item may already be deleted outside of Terraform
+		if isNotFound(err) {
+			return nil
+		}
+
+		return err
+	})
+
+	for i, j := range jobs {
+		if results[i] != nil {
+			errs = multierror.Append(errs, results[i])
+			continue
+		}
+		delete(uuids[j.rule], j.key)
+	}
+
+	for i, rule := range list {
+		rule := rule.(map[string]interface{})
+
+		// The items are only used to find the remaining ports and sources, so
+		// any errors can be ignored (e.g. for dummy rules of managed resources)
+		items, _ := h.items(rule)
+
+		saveRule(h, rules, rule, items, uuids[i])
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// saveRule adds the rule to rules if at least one of its items has a UUID,
+// after removing the ports and sources of the items without a UUID.
+func saveRule(h *ruleHandler, rules *schema.Set, rule map[string]interface{}, items []ruleItem, uuids map[string]interface{}) {
+	h.saveUUIDs(rule, uuids)
+
+	if len(uuids) == 0 {
+		return
+	}
+
+	ports := &schema.Set{F: schema.HashString}
+	cidrs := &schema.Set{F: schema.HashString}
+	groups := &schema.Set{F: schema.HashString}
+
+	for _, item := range items {
+		if _, ok := uuids[item.key]; !ok {
+			continue
+		}
+		if item.port != "" {
+			ports.Add(item.port)
+		}
+		if item.cidr != "" {
+			cidrs.Add(item.cidr)
+		}
+		if item.group != "" {
+			groups.Add(item.group)
+		}
+	}
+
+	if ports.Len() > 0 {
+		rule["ports"] = ports
+	}
+	if cidrs.Len() > 0 || groups.Len() > 0 {
+		rule["cidr_list"] = cidrs
+		rule["user_security_group_list"] = groups
+	}
+
+	rules.Add(rule)
+}
+
+// getUUIDs returns a copy of the UUIDs of the items of the given rule.
+func (h *ruleHandler) getUUIDs(rule map[string]interface{}) map[string]interface{} {
+	var current map[string]interface{}
+	if h.uuids != nil {
+		current = h.uuids(rule)
+	} else {
+		current, _ = rule["uuids"].(map[string]interface{})
+	}
+
+	uuids := make(map[string]interface{}, len(current))
+	for k, id := range current {
+		uuids[k] = id
+	}
+
+	return uuids
+}
+
+// saveUUIDs saves the UUIDs of the items of the given rule.
+func (h *ruleHandler) saveUUIDs(rule map[string]interface{}, uuids map[string]interface{}) {
+	if h.setUUIDs != nil {
+		h.setUUIDs(rule, uuids)
+		return
+	}
+	rule["uuids"] = uuids
+}
+
+// runParallel calls f for each index from 0 to n using at most parallelism
+// concurrent workers, and returns the resulting errors by index.
+func runParallel(parallelism int, n int, f func(i int) error) []error {
+	errs := make([]error, n)
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > n {
+		parallelism = n
+	}
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+
+	for w := 0; w < parallelism; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+
+	return errs
+}
+
+// protocolRuleItems returns an item for each port of a TCP or UDP rule, or a
+// single item for a rule using the ICMP or ALL protocol.
+func protocolRuleItems(rule map[string]interface{}) []ruleItem {
+	var items []ruleItem
+
+	switch protocol := rule["protocol"].(string); protocol {
+	case "icmp", "all":
+		items = append(items, ruleItem{key: protocol})
+	case "tcp", "udp":
+		if ps, ok := rule["ports"].(*schema.Set); ok {
+			for _, port := range ps.List() {
+				items = append(items, ruleItem{key: port.(string), port: port.(string)})
+			}
+		}
+	}
+
+	return items
+}
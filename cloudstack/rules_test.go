@@ -0,0 +1,241 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func testRuleSet(rules ...map[string]interface{}) *schema.Set {
+	s := resourceCloudStackFirewall().Schema["rule"].ZeroValue().(*schema.Set)
+	for _, rule := range rules {
+		s.Add(rule)
+	}
+	return s
+}
+
+func testRule(protocol string, uuids map[string]interface{}, ports ...string) map[string]interface{} {
+	cidrs := &schema.Set{F: schema.HashString}
+	cidrs.Add("10.0.0.0/24")
+
+	ps := &schema.Set{F: schema.HashString}
+	for _, port := range ports {
+		ps.Add(port)
+	}
+
+	return map[string]interface{}{
+		"cidr_list": cidrs,
+		"protocol":  protocol,
+		"icmp_type": 0,
+		"icmp_code": 0,
+		"ports":     ps,
+		"uuids":     uuids,
+	}
+}
+
+// testRuleHandler returns a handler that fails for all items using the given
+// port and keeps track of the maximum number of concurrent API calls.
+func testRuleHandler(parallelism int, failPort string) (*ruleHandler, func() int) {
+	var mu sync.Mutex
+	var running, max int
+
+	call := func(port string) error {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if port == failPort {
+			return fmt.Errorf("failed port %s", port)
+		}
+		return nil
+	}
+
+	h := &ruleHandler{
+		parallelism: parallelism,
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
+			return protocolRuleItems(rule), nil
+		},
+		create: func(rule map[string]interface{}, item ruleItem) (string, error) {
+			if err := call(item.port); err != nil {
+				return "", err
+			}
+			return "id-" + item.key, nil
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			return call(id[len("id-"):])
+		},
+	}
+
+	return h, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return max
+	}
+}
+
+func TestCreateRules(t *testing.T) {
+	h, max := testRuleHandler(2, "443")
+
+	nrs := testRuleSet(
+		testRule("tcp", map[string]interface{}{}, "80", "443", "8000-8080"),
+		testRule("udp", map[string]interface{}{}, "443"),
+		testRule("icmp", map[string]interface{}{}),
+	)
+	rules := testRuleSet()
+
+	err := createRules(h, rules, nrs)
+	if err == nil {
+		t.Fatal("Expected an error for the failed rules")
+	}
+
+	if m := max(); m > 2 {
+		t.Fatalf("Expected at most 2 concurrent calls, got: %d", m)
+	}
+
+	expected := map[string]map[string]interface{}{
+		"tcp":  {"80": "id-80", "8000-8080": "id-8000-8080"},
+		"icmp": {"icmp": "id-icmp"},
+	}
+
+	if rules.Len() != len(expected) {
+		t.Fatalf("Expected %d rules, got: %d", len(expected), rules.Len())
+	}
+
+	for _, rule := range rules.List() {
+		rule := rule.(map[string]interface{})
+		protocol := rule["protocol"].(string)
+
+		uuids := rule["uuids"].(map[string]interface{})
+		if fmt.Sprint(uuids) != fmt.Sprint(expected[protocol]) {
+			t.Fatalf("Expected uuids %v for %s, got: %v", expected[protocol], protocol, uuids)
+		}
+
+		if ports := rule["ports"].(*schema.Set); ports.Len() != len(uuids) && protocol != "icmp" {
+			t.Fatalf("Expected the ports of %s to match the uuids, got: %v", protocol, ports.List())
+		}
+	}
+}
+
+func TestDeleteRules(t *testing.T) {
+	h, max := testRuleHandler(3, "443")
+
+	ors := testRuleSet(
+		testRule("tcp", map[string]interface{}{
+			"80":  "id-80",
+			"443": "id-443",
+			"22":  "id-22",
+		}, "80", "443", "22"),
+		testRule("icmp", map[string]interface{}{"icmp": "id-icmp"}),
+	)
+	rules := testRuleSet()
+
+	err := deleteRules(h, rules, ors)
+	if err == nil {
+		t.Fatal("Expected an error for the failed rule")
+	}
+
+	if m := max(); m > 3 {
+		t.Fatalf("Expected at most 3 concurrent calls, got: %d", m)
+	}
+
+	if rules.Len() != 1 {
+		t.Fatalf("Expected 1 remaining rule, got: %d", rules.Len())
+	}
+
+	rule := rules.List()[0].(map[string]interface{})
+
+	uuids := rule["uuids"].(map[string]interface{})
+	if len(uuids) != 1 || uuids["443"] != "id-443" {
+		t.Fatalf("Expected only the uuid of port 443, got: %v", uuids)
+	}
+
+	if ports := rule["ports"].(*schema.Set); ports.Len() != 1 || !ports.Contains("443") {
+		t.Fatalf("Expected only port 443, got: %v", ports.List())
+	}
+}
+
+func TestDeleteRules_notFound(t *testing.T) {
+	h := &ruleHandler{
+		parallelism: 2,
+		items: func(rule map[string]interface{}) ([]ruleItem, error) {
+			return protocolRuleItems(rule), nil
+		},
+		delete: func(rule map[string]interface{}, id string) error {
+			return fmt.Errorf(
+				"CloudStack API error 431 (CSExceptionErrorCode: 9999): Invalid parameter id "+
+					"value=%s due to incorrect long value format, or entity does not exist", id)
+		},
+	}
+
+	ors := testRuleSet(testRule("tcp", map[string]interface{}{"80": "id-80"}, "80"))
+	rules := testRuleSet()
+
+	if err := deleteRules(h, rules, ors); err != nil {
+		t.Fatalf("Expected rules that no longer exist to be deleted, got: %s", err)
+	}
+
+	if rules.Len() != 0 {
+		t.Fatalf("Expected no remaining rules, got: %d", rules.Len())
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	var mu sync.Mutex
+	var calls []int
+
+	errs := runParallel(4, 10, func(i int) error {
+		mu.Lock()
+		calls = append(calls, i)
+		mu.Unlock()
+
+		if i%3 == 0 {
+			return fmt.Errorf("error %d", i)
+		}
+		return nil
+	})
+
+	if len(calls) != 10 {
+		t.Fatalf("Expected 10 calls, got: %d", len(calls))
+	}
+
+	for i, err := range errs {
+		if (i%3 == 0) != (err != nil) {
+			t.Fatalf("Unexpected error for index %d: %v", i, err)
+		}
+	}
+
+	if errs := runParallel(2, 0, nil); len(errs) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errs)
+	}
+}
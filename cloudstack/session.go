@@ -0,0 +1,219 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// sessionTransport is a http.RoundTripper that authenticates API calls using
+// a session instead of an API key and secret. The session is created using
+// the login command the first time an API call is made, and is created again
+// when it expired. API calls are authenticated using the session key and
+// the session cookie returned by the login command.
+type sessionTransport struct {
+	apiURL    string
+	username  string
+	password  string
+	domain    string
+	transport http.RoundTripper
+
+	mu         sync.Mutex
+	jar        *cookiejar.Jar
+	sessionkey string
+}
+
+func newSessionTransport(apiURL, username, password, domain string, transport http.RoundTripper) *sessionTransport {
+	jar, _ := cookiejar.New(nil)
+	return &sessionTransport{
+		apiURL:    apiURL,
+		username:  username,
+		password:  password,
+		domain:    domain,
+		transport: transport,
+		jar:       jar,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	var expired string
+	for {
+		sessionkey, err := t.session(req, expired)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := t.withSession(req, body, sessionkey)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.transport.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+
+		t.jar.SetCookies(r.URL, resp.Cookies())
+
+		// An expired session results in a 401, in which case we login again
+		// and retry the call once
+		if resp.StatusCode != http.StatusUnauthorized || expired != "" {
+			return resp, nil
+		}
+
+		log.Printf("[DEBUG] Session expired, logging in again as %s", t.username)
+		resp.Body.Close()
+
+		expired = sessionkey
+	}
+}
+
+// session returns the current session key, after logging in if there is no
+// session yet or if the given (expired) session is still the current one.
+func (t *sessionTransport) session(req *http.Request, expired string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Only login again if no other call did so already
+	if t.sessionkey != "" && t.sessionkey != expired {
+		return t.sessionkey, nil
+	}
+
+	if err := t.login(req); err != nil {
+		return "", err
+	}
+
+	return t.sessionkey, nil
+}
+
+// login executes the login command and saves the returned session key and
+// cookies. It should be called while holding the lock.
+func (t *sessionTransport) login(req *http.Request) error {
+	params := url.Values{}
+	params.Set("command", "login")
+	params.Set("response", "json")
+	params.Set("username", t.username)
+	params.Set("password", t.password)
+	if t.domain != "" {
+		params.Set("domain", t.domain)
+	}
+
+	r, err := http.NewRequest("POST", t.apiURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	r = r.WithContext(req.Context())
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.transport.RoundTrip(r)
+	if err != nil {
+		return fmt.Errorf("Error logging in as %s: %s", t.username, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error logging in as %s: %s", t.username, err)
+	}
+
+	raw, err := responseValue(body)
+	if err != nil {
+		return fmt.Errorf("Error logging in as %s: %s", t.username, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e csError
+		if err := json.Unmarshal(raw, &e); err != nil || e.ErrorText == "" {
+			return fmt.Errorf("Error logging in as %s: %s", t.username, resp.Status)
+		}
+		return fmt.Errorf("Error logging in as %s: %s", t.username, e.ErrorText)
+	}
+
+	var l struct {
+		Sessionkey string `json:"sessionkey"`
+	}
+	if err := json.Unmarshal(raw, &l); err != nil {
+		return fmt.Errorf("Error logging in as %s: %s", t.username, err)
+	}
+	if l.Sessionkey == "" {
+		return fmt.Errorf("Error logging in as %s: no session key returned", t.username)
+	}
+
+	t.jar.SetCookies(r.URL, resp.Cookies())
+	t.sessionkey = l.Sessionkey
+
+	return nil
+}
+
+// withSession returns a copy of the request that is authenticated using the
+// given session key (and the session cookie) instead of an API key.
+func (t *sessionTransport) withSession(req *http.Request, body []byte, sessionkey string) (*http.Request, error) {
+	r := req.WithContext(req.Context())
+	r.Header = req.Header.Clone()
+
+	setSession := func(params url.Values) string {
+		params.Del("apiKey")
+		params.Del("signature")
+		params.Set("sessionkey", sessionkey)
+		return params.Encode()
+	}
+
+	u := *req.URL
+	u.RawQuery = setSession(req.URL.Query())
+	r.URL = &u
+
+	if body != nil {
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			params, err := url.ParseQuery(string(body))
+			if err != nil {
+				return nil, err
+			}
+			body = []byte(setSession(params))
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+
+	for _, c := range t.jar.Cookies(r.URL) {
+		r.AddCookie(c)
+	}
+
+	return r, nil
+}
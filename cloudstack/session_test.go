@@ -0,0 +1,106 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package cloudstack
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSessionTransport(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	cfg := Config{
+		APIURL:   sim.URL + "/client/api",
+		Username: testSimUsername,
+		Password: testSimPassword,
+		Timeout:  10,
+	}
+
+	cs, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Concurrent calls share a single session
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cs.Zone.ListZones(cs.Zone.NewListZonesParams()); err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := sim.count("login"); n != 1 {
+		t.Fatalf("Expected 1 call of login, got %d", n)
+	}
+
+	// Async jobs and POST calls use the session as well
+	if _, err := cs.VirtualMachine.UpdateVirtualMachine(
+		cs.VirtualMachine.NewUpdateVirtualMachineParams("00000000-0000-4000-8000-000000000001")); !isNotFound(err) {
+		t.Fatalf("Expected a not found error, got: %v", err)
+	}
+
+	// An expired session results in a new login
+	sim.expireSessions()
+
+	if _, err := cs.Zone.ListZones(cs.Zone.NewListZonesParams()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if n := sim.count("login"); n != 2 {
+		t.Fatalf("Expected 2 calls of login, got %d", n)
+	}
+	if n := sim.count("listZones"); n != 11 {
+		t.Fatalf("Expected 11 calls of listZones, got %d", n)
+	}
+}
+
+func TestSessionTransport_invalidCredentials(t *testing.T) {
+	sim := newSimulator(t)
+	defer sim.Close()
+
+	cfg := Config{
+		APIURL:   sim.URL + "/client/api",
+		Username: testSimUsername,
+		Password: "wrong",
+		Timeout:  10,
+	}
+
+	cs, err := cfg.NewClient()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	_, err = cs.Zone.ListZones(cs.Zone.NewListZonesParams())
+	if err == nil || !strings.Contains(err.Error(), "please provide valid credentials") {
+		t.Fatalf("Expected a login error, got: %v", err)
+	}
+
+	if n := sim.count("listZones"); n != 0 {
+		t.Fatalf("Expected no calls of listZones, got %d", n)
+	}
+}
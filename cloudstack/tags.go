@@ -35,14 +35,12 @@ func tagsSchema() *schema.Schema {
 	}
 }
 
-// setTags is a helper to set the tags for a resource. It expects the
-// tags field to be named "tags"
-func setTags(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourcetype string) error {
-	if tags, ok := d.GetOk("tags"); ok {
-		p := cs.Resourcetags.NewCreateTagsParams(
-			[]string{d.Id()},
-			resourcetype, tagsFromSchema(tags.(map[string]interface{})),
-		)
+// setTags is a helper to set the tags for a resource, including the
+// default tags of the provider. It expects the tags field to be named "tags"
+func setTags(cs *Client, d *schema.ResourceData, resourcetype string) error {
+	tags := withDefaultTags(cs, d.Get("tags").(map[string]interface{}))
+	if len(tags) > 0 {
+		p := cs.Resourcetags.NewCreateTagsParams([]string{d.Id()}, resourcetype, tags)
 		_, err := cs.Resourcetags.CreateTags(p)
 		if err != nil {
 			return err
@@ -54,12 +52,12 @@ func setTags(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourcety
 
 // updateTags is a helper to update only when tags field change tags
 // field to be named "tags"
-func updateTags(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourcetype string) error {
+func updateTags(cs *Client, d *schema.ResourceData, resourcetype string) error {
 	oraw, nraw := d.GetChange("tags")
 	o := oraw.(map[string]interface{})
 	n := nraw.(map[string]interface{})
 
-	remove, create := diffTags(tagsFromSchema(o), tagsFromSchema(n))
+	remove, create := diffTags(withDefaultTags(cs, o), withDefaultTags(cs, n))
 	log.Printf("[DEBUG] tags to remove: %v", remove)
 	log.Printf("[DEBUG] tags to create: %v", create)
 
@@ -87,6 +85,38 @@ func updateTags(cs *cloudstack.CloudStackClient, d *schema.ResourceData, resourc
 	return nil
 }
 
+// readTags is a helper to read the tags of a resource. Default tags of the
+// provider are left out (unless they are also configured for the resource),
+// so they do not cause a diff. It expects the tags field to be named "tags"
+func readTags(cs *Client, d *schema.ResourceData, tags []cloudstack.Tags) {
+	current := d.Get("tags").(map[string]interface{})
+
+	result := make(map[string]interface{}, len(tags))
+	for _, tag := range tags {
+		if v, ok := cs.defaultTags[tag.Key]; ok && v == tag.Value {
+			if _, ok := current[tag.Key]; !ok {
+				continue
+			}
+		}
+		result[tag.Key] = tag.Value
+	}
+
+	d.Set("tags", result)
+}
+
+// withDefaultTags returns the given tags merged with the default tags of the
+// provider, where the given tags take precedence over the default tags
+func withDefaultTags(cs *Client, tags map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(cs.defaultTags)+len(tags))
+	for k, v := range cs.defaultTags {
+		result[k] = v
+	}
+	for k, v := range tagsFromSchema(tags) {
+		result[k] = v
+	}
+	return result
+}
+
 // diffTags takes the old and the new tag sets and returns the difference of
 // both. The remaining tags are those that need to be removed and created
 func diffTags(oldTags, newTags map[string]string) (map[string]string, map[string]string) {
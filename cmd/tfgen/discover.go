@@ -0,0 +1,343 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-cloudstack/v2/cloudstack"
+)
+
+// object is a single CloudStack object that will be imported as a resource.
+type object struct {
+	// Type is the type of the Terraform resource, e.g. cloudstack_network.
+	Type string
+
+	// ID is the ID used to import the resource.
+	ID string
+
+	// Name is used to derive the name of the resource in the configuration.
+	Name string
+
+	// Referable is true when other resources refer to this resource by its
+	// ID. Rule aggregates share their ID with the object they belong to, so
+	// they should not be referred to.
+	Referable bool
+}
+
+// scope holds the project, or the account and domain, to walk. Without either
+// the account of the API credentials is walked.
+type scope struct {
+	project string
+	account string
+	domain  string
+}
+
+// discoverer walks all supported objects of an account or project.
+type discoverer struct {
+	cs        *cloudstack.CloudStackClient
+	projectid string
+	account   string
+	domainid  string
+	objects   []*object
+}
+
+func (d *discoverer) add(typ, id, name string, referable bool) {
+	d.objects = append(d.objects, &object{Type: typ, ID: id, Name: name, Referable: referable})
+}
+
+type accountSetter interface {
+	SetAccount(string)
+}
+
+// setScope scopes the parameters to the walked project, or to the walked
+// account and domain, the same way the provider scopes its resources.
+func (d *discoverer) setScope(p interface{}) {
+	if ps, ok := p.(cloudstack.ProjectIDSetter); ok && d.projectid != "" {
+		ps.SetProjectid(d.projectid)
+	}
+
+	if ps, ok := p.(cloudstack.DomainIDSetter); ok && d.domainid != "" {
+		ps.SetDomainid(d.domainid)
+	}
+
+	if ps, ok := p.(accountSetter); ok && d.account != "" {
+		ps.SetAccount(d.account)
+	}
+}
+
+// discover returns all objects in the order they should be generated.
+func discover(cs *cloudstack.CloudStackClient, sc scope) ([]*object, error) {
+	d := &discoverer{cs: cs, account: sc.account}
+
+	if sc.project != "" {
+		id, err := projectID(cs, sc.project)
+		if err != nil {
+			return nil, err
+		}
+		d.projectid = id
+	}
+
+	if sc.domain != "" {
+		id, err := domainID(cs, sc.domain)
+		if err != nil {
+			return nil, err
+		}
+		d.domainid = id
+	}
+
+	for _, f := range []func() error{
+		d.vpcs,
+		d.networkACLs,
+		d.networks,
+		d.instances,
+		d.disks,
+		d.ipAddresses,
+		d.loadBalancerRules,
+	} {
+		if err := f(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.objects, nil
+}
+
+func projectID(cs *cloudstack.CloudStackClient, project string) (string, error) {
+	if cloudstack.IsID(project) {
+		return project, nil
+	}
+
+	id, _, err := cs.Project.GetProjectID(project)
+	return id, err
+}
+
+func domainID(cs *cloudstack.CloudStackClient, domain string) (string, error) {
+	if cloudstack.IsID(domain) {
+		return domain, nil
+	}
+
+	id, _, err := cs.Domain.GetDomainID(domain)
+	return id, err
+}
+
+// validate checks that the scope either walks a project, or an account
+// together with the domain it belongs to.
+func (sc scope) validate() error {
+	if sc.project != "" && (sc.account != "" || sc.domain != "") {
+		return fmt.Errorf("A project cannot be combined with an account or domain")
+	}
+
+	if sc.account != "" && sc.domain == "" {
+		return fmt.Errorf("The domain of account %s is required when setting the account", sc.account)
+	}
+
+	return nil
+}
+
+func (d *discoverer) vpcs() error {
+	p := d.cs.VPC.NewListVPCsParams()
+	d.setScope(p)
+
+	l, err := d.cs.VPC.ListVPCs(p)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range l.VPCs {
+		d.add("cloudstack_vpc", v.Id, v.Name, true)
+	}
+
+	return nil
+}
+
+func (d *discoverer) networkACLs() error {
+	p := d.cs.NetworkACL.NewListNetworkACLListsParams()
+	d.setScope(p)
+
+	l, err := d.cs.NetworkACL.ListNetworkACLLists(p)
+	if err != nil {
+		return err
+	}
+
+	for _, acl := range l.NetworkACLLists {
+		// Skip the default ACLs, as they don't belong to a VPC
+		if acl.Vpcid == "" {
+			continue
+		}
+
+		d.add("cloudstack_network_acl", acl.Id, acl.Name, true)
+
+		ip := d.cs.NetworkACL.NewListNetworkACLsParams()
+		ip.SetAclid(acl.Id)
+		d.setScope(ip)
+
+		items, err := d.cs.NetworkACL.ListNetworkACLs(ip)
+		if err != nil {
+			return err
+		}
+
+		if items.Count > 0 {
+			d.add("cloudstack_network_acl_rule", acl.Id, acl.Name, false)
+		}
+	}
+
+	return nil
+}
+
+func (d *discoverer) networks() error {
+	p := d.cs.Network.NewListNetworksParams()
+	d.setScope(p)
+
+	l, err := d.cs.Network.ListNetworks(p)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range l.Networks {
+		// Shared networks are managed by the administrator
+		if n.Type == "Shared" {
+			continue
+		}
+
+		d.add("cloudstack_network", n.Id, n.Name, true)
+
+		// Networks in a VPC use network ACLs instead of egress rules
+		if n.Vpcid != "" {
+			continue
+		}
+
+		ep := d.cs.Firewall.NewListEgressFirewallRulesParams()
+		ep.SetNetworkid(n.Id)
+		d.setScope(ep)
+
+		rules, err := d.cs.Firewall.ListEgressFirewallRules(ep)
+		if err != nil {
+			return err
+		}
+
+		if rules.Count > 0 {
+			d.add("cloudstack_egress_firewall", n.Id, n.Name, false)
+		}
+	}
+
+	return nil
+}
+
+func (d *discoverer) instances() error {
+	p := d.cs.VirtualMachine.NewListVirtualMachinesParams()
+	d.setScope(p)
+
+	l, err := d.cs.VirtualMachine.ListVirtualMachines(p)
+	if err != nil {
+		return err
+	}
+
+	for _, vm := range l.VirtualMachines {
+		d.add("cloudstack_instance", vm.Id, vm.Name, true)
+	}
+
+	return nil
+}
+
+func (d *discoverer) disks() error {
+	p := d.cs.Volume.NewListVolumesParams()
+	p.SetType("DATADISK")
+	d.setScope(p)
+
+	l, err := d.cs.Volume.ListVolumes(p)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range l.Volumes {
+		d.add("cloudstack_disk", v.Id, v.Name, true)
+	}
+
+	return nil
+}
+
+func (d *discoverer) ipAddresses() error {
+	p := d.cs.Address.NewListPublicIpAddressesParams()
+	d.setScope(p)
+
+	l, err := d.cs.Address.ListPublicIpAddresses(p)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range l.PublicIpAddresses {
+		// Source NAT IP addresses are acquired together with their network
+		if !ip.Issourcenat {
+			d.add("cloudstack_ipaddress", ip.Id, ip.Ipaddress, true)
+		}
+
+		if ip.Isstaticnat {
+			d.add("cloudstack_static_nat", ip.Id, ip.Ipaddress, false)
+		}
+
+		// IP addresses in a VPC use network ACLs instead of firewall rules
+		if ip.Vpcid == "" {
+			fp := d.cs.Firewall.NewListFirewallRulesParams()
+			fp.SetIpaddressid(ip.Id)
+			d.setScope(fp)
+
+			rules, err := d.cs.Firewall.ListFirewallRules(fp)
+			if err != nil {
+				return err
+			}
+
+			if rules.Count > 0 {
+				d.add("cloudstack_firewall", ip.Id, ip.Ipaddress, false)
+			}
+		}
+
+		pp := d.cs.Firewall.NewListPortForwardingRulesParams()
+		pp.SetIpaddressid(ip.Id)
+		d.setScope(pp)
+
+		forwards, err := d.cs.Firewall.ListPortForwardingRules(pp)
+		if err != nil {
+			return err
+		}
+
+		if forwards.Count > 0 {
+			d.add("cloudstack_port_forward", ip.Id, ip.Ipaddress, false)
+		}
+	}
+
+	return nil
+}
+
+func (d *discoverer) loadBalancerRules() error {
+	p := d.cs.LoadBalancer.NewListLoadBalancerRulesParams()
+	d.setScope(p)
+
+	l, err := d.cs.LoadBalancer.ListLoadBalancerRules(p)
+	if err != nil {
+		return err
+	}
+
+	for _, lb := range l.LoadBalancerRules {
+		d.add("cloudstack_loadbalancer_rule", lb.Id, lb.Name, true)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// generated is a single resource read from the running environment.
+type generated struct {
+	object   *object
+	name     string
+	importID string
+	data     *schema.ResourceData
+}
+
+// generator imports and reads all discovered objects using the importers and
+// Read functions of the provider resources.
+type generator struct {
+	provider *schema.Provider
+	scope    scope
+	names    map[string]bool
+}
+
+func newGenerator(p *schema.Provider, sc scope) *generator {
+	return &generator{
+		provider: p,
+		scope:    sc,
+		names:    make(map[string]bool),
+	}
+}
+
+// read imports and reads a single object. It returns nil if the object no
+// longer exists.
+func (g *generator) read(o *object) (*generated, error) {
+	r, ok := g.provider.ResourcesMap[o.Type]
+	if !ok {
+		return nil, fmt.Errorf("Unknown resource type: %s", o.Type)
+	}
+
+	if r.Importer == nil {
+		return nil, fmt.Errorf("Resource type %s does not support import", o.Type)
+	}
+
+	importID := importID(r, o.ID, g.scope)
+
+	d := r.Data(nil)
+	d.SetId(importID)
+
+	ds, err := r.Importer.State(d, g.provider.Meta())
+	if err != nil {
+		return nil, fmt.Errorf("Error importing %s %s: %s", o.Type, o.ID, err)
+	}
+
+	d = ds[0]
+
+	if err := r.Read(d, g.provider.Meta()); err != nil {
+		return nil, fmt.Errorf("Error reading %s %s: %s", o.Type, o.ID, err)
+	}
+
+	if d.Id() == "" {
+		return nil, nil
+	}
+
+	return &generated{
+		object:   o,
+		name:     g.uniqueName(o.Type, o.Name),
+		importID: importID,
+		data:     d,
+	}, nil
+}
+
+// importID returns the ID used to import an object. Resources with a project
+// use the project name as prefix of the ID, and resources with an account use
+// the domain and account names as prefix of the ID.
+func importID(r *schema.Resource, id string, sc scope) string {
+	if _, ok := r.Schema["project"]; ok && sc.project != "" {
+		return sc.project + "/" + id
+	}
+
+	if _, ok := r.Schema["account"]; ok && sc.account != "" {
+		return sc.domain + "/" + sc.account + "/" + id
+	}
+
+	return id
+}
+
+// uniqueName returns a resource name that is unique for the resource type.
+func (g *generator) uniqueName(typ, name string) string {
+	name = resourceName(name)
+
+	unique := name
+	for i := 2; g.names[typ+"."+unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	g.names[typ+"."+unique] = true
+
+	return unique
+}
+
+// generate reads all objects and writes a configuration file per resource
+// type, together with a script containing the needed import commands.
+func (g *generator) generate(objects []*object, dir string) error {
+	var resources []*generated
+	for _, o := range objects {
+		r, err := g.read(o)
+		if err != nil {
+			return err
+		}
+		if r != nil {
+			resources = append(resources, r)
+		}
+	}
+
+	rd := &renderer{refs: make(map[string]string)}
+	for _, r := range resources {
+		if r.object.Referable {
+			rd.refs[r.object.ID] = r.object.Type + "." + r.name
+		}
+	}
+
+	files := make(map[string][]string)
+	imports := []string{"#!/bin/sh", "set -e", ""}
+
+	for _, r := range resources {
+		res := g.provider.ResourcesMap[r.object.Type]
+		file := strings.TrimPrefix(r.object.Type, "cloudstack_") + ".tf"
+
+		files[file] = append(files[file], rd.render(r.object.Type, r.name, res, r.data))
+		imports = append(imports, fmt.Sprintf(
+			"terraform import %s.%s %s", r.object.Type, r.name, shellQuote(r.importID)))
+	}
+
+	var names []string
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range names {
+		content := strings.Join(files[file], "\n")
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	script := strings.Join(imports, "\n") + "\n"
+	return ioutil.WriteFile(filepath.Join(dir, "import.sh"), []byte(script), 0755)
+}
+
+// configure configures the provider using the given provider arguments.
+func configure(p *schema.Provider, args map[string]interface{}) error {
+	raw, err := config.NewRawConfig(args)
+	if err != nil {
+		return err
+	}
+
+	return p.Configure(terraform.NewResourceConfig(raw))
+}
+
+// shellQuote quotes a value so it can be safely used in a shell script.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,80 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestImportID(t *testing.T) {
+	scoped := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"project": {Type: schema.TypeString, Optional: true},
+			"account": {Type: schema.TypeString, Optional: true},
+			"domain":  {Type: schema.TypeString, Optional: true},
+		},
+	}
+	unscoped := &schema.Resource{Schema: map[string]*schema.Schema{}}
+
+	cases := []struct {
+		resource *schema.Resource
+		scope    scope
+		expected string
+	}{
+		{scoped, scope{}, "id"},
+		{scoped, scope{project: "my-project"}, "my-project/id"},
+		{scoped, scope{account: "admin", domain: "ROOT"}, "ROOT/admin/id"},
+		{unscoped, scope{project: "my-project"}, "id"},
+		{unscoped, scope{account: "admin", domain: "ROOT"}, "id"},
+	}
+
+	for _, c := range cases {
+		if got := importID(c.resource, "id", c.scope); got != c.expected {
+			t.Errorf("Expected import ID %s for %+v, got %s", c.expected, c.scope, got)
+		}
+	}
+}
+
+func TestScopeValidate(t *testing.T) {
+	valid := []scope{
+		{},
+		{project: "my-project"},
+		{account: "admin", domain: "ROOT"},
+		{domain: "ROOT"},
+	}
+	for _, sc := range valid {
+		if err := sc.validate(); err != nil {
+			t.Errorf("Unexpected error for %+v: %s", sc, err)
+		}
+	}
+
+	invalid := []scope{
+		{account: "admin"},
+		{project: "my-project", account: "admin", domain: "ROOT"},
+		{project: "my-project", domain: "ROOT"},
+	}
+	for _, sc := range invalid {
+		if err := sc.validate(); err == nil {
+			t.Errorf("Expected an error for %+v", sc)
+		}
+	}
+}
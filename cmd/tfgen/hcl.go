@@ -0,0 +1,237 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// renderer renders resources as Terraform configuration.
+type renderer struct {
+	// refs maps the IDs of all referable resources to their address.
+	refs map[string]string
+}
+
+// render renders a resource block for the given resource data.
+func (r *renderer) render(typ, name string, res *schema.Resource, d *schema.ResourceData) string {
+	values := make(map[string]interface{}, len(res.Schema))
+	for k := range res.Schema {
+		values[k] = d.Get(k)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "resource %q %q {\n", typ, name)
+	r.renderBody(&buf, res.Schema, values, 1, typ+"."+name)
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// renderBody renders all configurable attributes first, followed by all
+// nested blocks. Both are sorted by name to get a stable output.
+func (r *renderer) renderBody(buf *bytes.Buffer, s map[string]*schema.Schema, values map[string]interface{}, indent int, self string) {
+	var attrs, blocks []string
+	for k, v := range s {
+		if !configurable(v) || omit(v, values[k]) {
+			continue
+		}
+
+		if _, ok := v.Elem.(*schema.Resource); ok {
+			blocks = append(blocks, k)
+		} else {
+			attrs = append(attrs, k)
+		}
+	}
+	sort.Strings(attrs)
+	sort.Strings(blocks)
+
+	prefix := strings.Repeat("  ", indent)
+
+	// Align the equal signs of all attributes, like terraform fmt does
+	width := 0
+	for _, k := range attrs {
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+
+	for _, k := range attrs {
+		fmt.Fprintf(buf, "%s%-*s = %s\n", prefix, width, k, r.value(k, s[k], values[k], indent, self))
+	}
+
+	for _, k := range blocks {
+		elem := s[k].Elem.(*schema.Resource)
+
+		for _, v := range elements(values[k]) {
+			buf.WriteString("\n")
+			fmt.Fprintf(buf, "%s%s {\n", prefix, k)
+			r.renderBody(buf, elem.Schema, v.(map[string]interface{}), indent+1, self)
+			fmt.Fprintf(buf, "%s}\n", prefix)
+		}
+	}
+}
+
+// value renders the value of a single attribute.
+func (r *renderer) value(k string, s *schema.Schema, v interface{}, indent int, self string) string {
+	switch s.Type {
+	case schema.TypeString:
+		return r.reference(k, v.(string), self)
+	case schema.TypeList, schema.TypeSet:
+		var items []string
+		for _, item := range elements(v) {
+			items = append(items, r.value(k, s.Elem.(*schema.Schema), item, indent, self))
+		}
+		if s.Type == schema.TypeSet {
+			sort.Strings(items)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case schema.TypeMap:
+		m := v.(map[string]interface{})
+
+		var keys []string
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		prefix := strings.Repeat("  ", indent)
+
+		var buf bytes.Buffer
+		buf.WriteString("{\n")
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "%s  %s = %s\n", prefix, quote(key), quote(fmt.Sprint(m[key])))
+		}
+		buf.WriteString(prefix + "}")
+		return buf.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// reference returns a reference to another resource when the value is the
+// ID of a referable resource, and a quoted string otherwise.
+func (r *renderer) reference(k string, v string, self string) string {
+	if !strings.HasSuffix(k, "_id") && !strings.HasSuffix(k, "_ids") {
+		return quote(v)
+	}
+
+	if addr, ok := r.refs[v]; ok && addr != self {
+		return addr + ".id"
+	}
+
+	return quote(v)
+}
+
+// configurable returns true for all attributes that can be configured.
+func configurable(s *schema.Schema) bool {
+	return (s.Required || s.Optional) && s.Deprecated == "" && s.Removed == ""
+}
+
+// omit returns true for optional attributes that don't need to be rendered,
+// because they are not set or have their default value.
+func omit(s *schema.Schema, v interface{}) bool {
+	if s.Required {
+		return false
+	}
+
+	if s.Default != nil && fmt.Sprint(s.Default) == fmt.Sprint(v) {
+		return true
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int:
+		return v == 0 && s.Default == nil
+	case float64:
+		return v == 0 && s.Default == nil
+	case bool:
+		return !v && s.Default == nil
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return len(elements(v)) == 0
+	}
+}
+
+// elements returns the elements of a list or set.
+func elements(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case *schema.Set:
+		return v.List()
+	case []interface{}:
+		return v
+	default:
+		return nil
+	}
+}
+
+// quote returns a quoted HCL string. Template sequences are escaped, so the
+// value is used literally.
+func quote(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+
+	for i, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(&buf, `\u%04x`, c)
+		case (c == '$' || c == '%') && strings.HasPrefix(s[i+1:], "{"):
+			buf.WriteRune(c)
+			buf.WriteRune(c)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// resourceName returns a valid resource name based on the given name.
+func resourceName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "_")
+	name = strings.Trim(name, "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "r_" + name
+	}
+
+	return strings.TrimSuffix(name, "_")
+}
@@ -0,0 +1,121 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestRender(t *testing.T) {
+	res := &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name":       {Type: schema.TypeString, Required: true},
+			"network_id": {Type: schema.TypeString, Optional: true},
+			"vpc_id":     {Type: schema.TypeString, Optional: true},
+			"expunge":    {Type: schema.TypeBool, Optional: true, Default: false},
+			"size":       {Type: schema.TypeInt, Optional: true, Computed: true},
+			"state":      {Type: schema.TypeString, Computed: true},
+			"old":        {Type: schema.TypeString, Optional: true, Deprecated: "Use name"},
+			"tags":       {Type: schema.TypeMap, Optional: true},
+			"rule": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ports":    {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}, Set: schema.HashString},
+						"protocol": {Type: schema.TypeString, Required: true},
+						"uuids":    {Type: schema.TypeMap, Computed: true},
+					},
+				},
+			},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, res.Schema, map[string]interface{}{
+		"name":       "web ${var}",
+		"network_id": "net-1",
+		"vpc_id":     "vpc-1",
+		"size":       10,
+		"old":        "foo",
+		"tags":       map[string]interface{}{"env": "test"},
+		"rule": []interface{}{
+			map[string]interface{}{"ports": []interface{}{"80", "443"}, "protocol": "tcp"},
+		},
+	})
+
+	r := &renderer{refs: map[string]string{"net-1": "cloudstack_network.net"}}
+
+	expected := `resource "cloudstack_instance" "web" {
+  name       = "web $${var}"
+  network_id = cloudstack_network.net.id
+  size       = 10
+  tags       = {
+    "env" = "test"
+  }
+  vpc_id     = "vpc-1"
+
+  rule {
+    ports    = ["443", "80"]
+    protocol = "tcp"
+  }
+}
+`
+
+	if got := r.render("cloudstack_instance", "web", res, d); got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		`foo`:         `"foo"`,
+		`say "hi"`:    `"say \"hi\""`,
+		"line\nbreak": `"line\nbreak"`,
+		`${var.foo}`:  `"$${var.foo}"`,
+		`%{if x}`:     `"%%{if x}"`,
+		`100% $ {}`:   `"100% $ {}"`,
+		`back\slash`:  `"back\\slash"`,
+		"bell\a":      `"bell\u0007"`,
+	}
+
+	for in, expected := range cases {
+		if got := quote(in); got != expected {
+			t.Errorf("Expected %s to be quoted as %s, got %s", in, expected, got)
+		}
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	cases := map[string]string{
+		"terraform-server1": "terraform_server1",
+		"Web Server":        "web_server",
+		"192.0.2.10":        "r_192_0_2_10",
+		"--":                "r",
+		"":                  "r",
+	}
+
+	for in, expected := range cases {
+		if got := resourceName(in); got != expected {
+			t.Errorf("Expected resource name %s for %q, got %s", expected, in, got)
+		}
+	}
+}
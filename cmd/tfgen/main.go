@@ -0,0 +1,100 @@
+//
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+// Command tfgen generates Terraform configuration and import commands for
+// the resources of an existing CloudStack account or project.
+//
+// The generated configuration uses the schemas of the provider resources and
+// is filled by the importers and Read functions of those resources, so the
+// generated configuration matches the imported state:
+//
+//	tfgen -project my-project -out ./generated
+//	cd ./generated && ./import.sh
+//
+// Use -account and -domain instead of -project to walk another account. Without
+// any of them, the account of the API credentials is walked.
+//
+// The API credentials are read from the same environment variables as the
+// provider uses, or can be given using a CloudMonkey config and profile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/terraform-providers/terraform-provider-cloudstack/cloudstack"
+)
+
+func main() {
+	var (
+		apiURL      = flag.String("api-url", os.Getenv("CLOUDSTACK_API_URL"), "The CloudStack API URL")
+		apiKey      = flag.String("api-key", os.Getenv("CLOUDSTACK_API_KEY"), "The CloudStack API key")
+		secretKey   = flag.String("secret-key", os.Getenv("CLOUDSTACK_SECRET_KEY"), "The CloudStack secret key")
+		config      = flag.String("config", "", "The path to a CloudMonkey config file")
+		profile     = flag.String("profile", "", "The CloudMonkey profile to use")
+		httpGetOnly = flag.Bool("http-get-only", false, "Only use HTTP GET requests")
+		timeout     = flag.Int("timeout", 900, "The timeout in seconds for async jobs")
+		project     = flag.String("project", "", "The name or ID of the project to generate the configuration for")
+		account     = flag.String("account", "", "The name of the account to generate the configuration for")
+		domain      = flag.String("domain", "", "The name or ID of the domain of the account")
+		out         = flag.String("out", ".", "The directory to write the generated files to")
+	)
+	flag.Parse()
+
+	args := map[string]interface{}{
+		"http_get_only": *httpGetOnly,
+		"timeout":       *timeout,
+	}
+
+	if *config != "" || *profile != "" {
+		args["config"] = *config
+		args["profile"] = *profile
+	} else {
+		args["api_url"] = *apiURL
+		args["api_key"] = *apiKey
+		args["secret_key"] = *secretKey
+	}
+
+	sc := scope{project: *project, account: *account, domain: *domain}
+
+	if err := run(args, sc, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args map[string]interface{}, sc scope, out string) error {
+	if err := sc.validate(); err != nil {
+		return err
+	}
+
+	p := cloudstack.Provider().(*schema.Provider)
+	if err := configure(p, args); err != nil {
+		return err
+	}
+
+	objects, err := discover(p.Meta().(*cloudstack.Client).CloudStackClient, sc)
+	if err != nil {
+		return err
+	}
+
+	return newGenerator(p, sc).generate(objects, out)
+}
@@ -8,6 +8,7 @@ require (
 	github.com/hashicorp/terraform v0.12.0
 	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
 	github.com/xanzy/go-cloudstack/v2 v2.8.0
+	golang.org/x/crypto v0.0.0-20190426145343-a29dc8fdc734
 	gopkg.in/ini.v1 v1.40.0 // indirect
 )
 